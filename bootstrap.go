@@ -0,0 +1,242 @@
+package socket
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cotton-go/socket/pkg/app"
+	"github.com/cotton-go/socket/pkg/config"
+	"github.com/cotton-go/socket/pkg/log"
+	"github.com/cotton-go/socket/pkg/registry"
+	"github.com/cotton-go/socket/pkg/server"
+	"github.com/cotton-go/socket/pkg/server/tcp"
+	"github.com/cotton-go/socket/pkg/snowflake"
+	"github.com/cotton-go/socket/pkg/worker"
+)
+
+// DefaultWorker 是根据 config.Config 中的 TCP 配置创建的 Worker 的名称，管理接口、HTTP 传输和节点服务使用该 Worker
+const DefaultWorker = "default"
+
+// RouteFunc 是一个函数类型，用于在 HTTP 服务器上注册自定义的路由，通过 Bootstrap 获取 Worker
+type RouteFunc func(router gin.IRouter, b *Bootstrap)
+
+// Option 是一个函数类型，用于配置 Bootstrap
+type Option func(*Bootstrap)
+
+// tcpEntry 表示一个额外的 TCP 服务器的配置
+type tcpEntry struct {
+	name string
+	conf tcp.Config
+	opts []worker.Options
+}
+
+// Bootstrap 结构体根据配置创建并持有 Worker 和服务器，同一进程中可以创建多个互不影响的实例
+type Bootstrap struct {
+	conf      config.Config
+	logger    *log.Logger
+	app       *app.App
+	opts      []worker.Options          // 默认 Worker 的选项
+	auth      worker.Authenticator      // 客户端身份校验函数，配置要求客户端身份时使用
+	extra     []tcpEntry                // 额外的 TCP 服务器
+	workers   map[string]*worker.Worker // 按名称保存的 Worker
+	nodes     registry.Registry         // 默认 Worker 的注册中心，没有配置时为 nil
+	snowflake *snowflake.Snowflake      // 生成 Worker 和连接 ID 的雪花算法，没有配置时为 nil,使用包级别的实例
+	routes    []RouteFunc               // 自定义路由的注册函数
+	servers   []server.Server           // 自定义的服务器
+}
+
+// WithLogger 设置日志记录器，默认根据 config.Config 中的 Logger 配置创建
+//
+// 参数：
+//   - logger *log.Logger 日志记录器
+//
+// 返回值：
+//   - Option Bootstrap 选项
+func WithLogger(logger *log.Logger) Option {
+	return func(b *Bootstrap) {
+		b.logger = logger
+	}
+}
+
+// WithWorkerOptions 设置默认 Worker 的选项，例如事件处理函数，在根据配置生成的选项之后生效，可以覆盖配置中的设置
+//
+// 参数：
+//   - opts ...worker.Options Worker 选项
+//
+// 返回值：
+//   - Option Bootstrap 选项
+func WithWorkerOptions(opts ...worker.Options) Option {
+	return func(b *Bootstrap) {
+		b.opts = append(b.opts, opts...)
+	}
+}
+
+// WithAuthenticator 设置客户端身份的校验函数，配置中的 Identity 要求客户端身份时，所有根据配置创建的 Worker 使用该函数。
+// 配置要求客户端身份而没有设置校验函数时创建失败，避免信任客户端声明的任意身份。
+//
+// 参数：
+//   - auth worker.Authenticator 身份校验函数，返回通过校验的身份
+//
+// 返回值：
+//   - Option Bootstrap 选项
+func WithAuthenticator(auth worker.Authenticator) Option {
+	return func(b *Bootstrap) {
+		b.auth = auth
+	}
+}
+
+// WithTCP 添加一个使用独立 Worker 的 TCP 服务器，例如在另一个端口上为不同的客户端使用不同的编解码器。
+// 雪花算法在同一个 Bootstrap 内共享，conf 中的 Snowflake 配置不生效；Worker 的 ID 由 WorkID 或雪花算法生成。
+//
+// 参数：
+//   - name string Worker 的名称，不能与 DefaultWorker 或其他服务器重复
+//   - conf tcp.Config TCP 服务器的配置
+//   - opts ...worker.Options Worker 选项
+//
+// 返回值：
+//   - Option Bootstrap 选项
+func WithTCP(name string, conf tcp.Config, opts ...worker.Options) Option {
+	return func(b *Bootstrap) {
+		b.extra = append(b.extra, tcpEntry{name: name, conf: conf, opts: opts})
+	}
+}
+
+// WithRoutes 添加在 HTTP 服务器上注册自定义路由的函数，函数在所有 Worker 创建之后调用
+//
+// 参数：
+//   - fns ...RouteFunc 路由注册函数
+//
+// 返回值：
+//   - Option Bootstrap 选项
+func WithRoutes(fns ...RouteFunc) Option {
+	return func(b *Bootstrap) {
+		b.routes = append(b.routes, fns...)
+	}
+}
+
+// WithServers 添加自定义的服务器，与内置的服务器一起启动和停止，并参与就绪检查
+//
+// 参数：
+//   - servers ...server.Server 服务器
+//
+// 返回值：
+//   - Option Bootstrap 选项
+func WithServers(servers ...server.Server) Option {
+	return func(b *Bootstrap) {
+		b.servers = append(b.servers, servers...)
+	}
+}
+
+// New 根据配置创建 Worker 和服务器。
+// 默认 Worker 和 TCP 服务器根据 conf.TCP 创建，HTTP 和 gRPC 服务器使用默认 Worker,
+// 之后是 WithTCP 添加的 TCP 服务器和 WithServers 添加的自定义服务器。
+//
+// 参数：
+//   - conf config.Config 配置
+//   - opts ...Option Bootstrap 选项
+//
+// 返回值：
+//   - *Bootstrap 创建完成、尚未启动的实例
+//   - error 配置无效或初始化失败时返回错误信息，不会退出进程
+func New(conf config.Config, opts ...Option) (*Bootstrap, error) {
+	b := &Bootstrap{
+		conf:    conf,
+		workers: make(map[string]*worker.Worker),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if b.logger == nil {
+		b.logger = log.NewLog(conf.Logger)
+	}
+
+	// HTTP 服务器的健康检查引用应用程序，先创建应用程序再添加服务器
+	b.app = app.NewApp(app.WithDrain(conf.Drain))
+
+	socket, work, err := b.initTCPServer(conf.TCP, true, b.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	b.workers[DefaultWorker] = work
+	servers := []server.Server{socket}
+	for _, entry := range b.extra {
+		if _, ok := b.workers[entry.name]; ok || entry.name == "" {
+			return nil, fmt.Errorf("duplicate tcp server name %q", entry.name)
+		}
+
+		socket, work, err := b.initTCPServer(entry.conf, false, entry.opts...)
+		if err != nil {
+			return nil, fmt.Errorf("tcp server %q: %w", entry.name, err)
+		}
+
+		b.workers[entry.name] = work
+		servers = append(servers, socket)
+	}
+
+	// 所有 Worker 创建之后再创建 HTTP 服务器，路由注册函数可以获取任意 Worker
+	httpServer, err := b.initHTTPServer(conf.HTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	grpcServer, err := b.initGRPCServer(conf.GRPC)
+	if err != nil {
+		return nil, err
+	}
+
+	servers = append(servers, httpServer, grpcServer)
+	b.app.Add(append(servers, b.servers...)...)
+	return b, nil
+}
+
+// Worker 返回指定名称的 Worker,不存在时返回 nil
+//
+// 参数：
+//   - name string Worker 的名称，DefaultWorker 为默认 Worker
+//
+// 返回值：
+//   - *worker.Worker Worker 实例
+func (b *Bootstrap) Worker(name string) *worker.Worker {
+	return b.workers[name]
+}
+
+// Workers 返回所有 Worker,键为 Worker 的名称
+func (b *Bootstrap) Workers() map[string]*worker.Worker {
+	workers := make(map[string]*worker.Worker, len(b.workers))
+	for name, work := range b.workers {
+		workers[name] = work
+	}
+
+	return workers
+}
+
+// Registry 返回默认 Worker 的注册中心，没有配置时返回 nil
+func (b *Bootstrap) Registry() registry.Registry {
+	return b.nodes
+}
+
+// Logger 返回日志记录器
+func (b *Bootstrap) Logger() *log.Logger {
+	return b.logger
+}
+
+// App 返回管理所有服务器的应用程序
+func (b *Bootstrap) App() *app.App {
+	return b.app
+}
+
+// Run 启动所有服务器并等待终止信号，见 app.App.Run
+//
+// 参数：
+//   - ctx context.Context 上下文，被取消时停止所有服务器
+//
+// 返回值：
+//   - error 返回错误信息
+func (b *Bootstrap) Run(ctx context.Context) error {
+	return b.app.Run(ctx)
+}
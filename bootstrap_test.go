@@ -0,0 +1,165 @@
+package socket
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cotton-go/socket/pkg/config"
+	"github.com/cotton-go/socket/pkg/log"
+	"github.com/cotton-go/socket/pkg/server/tcp"
+	"github.com/cotton-go/socket/pkg/snowflake"
+)
+
+// freePort 返回一个空闲的本地端口
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// customServer 结构体模拟一个自定义的服务器
+type customServer struct {
+	ready int32
+}
+
+func (s *customServer) Start(ctx context.Context) error {
+	atomic.StoreInt32(&s.ready, 1)
+	return nil
+}
+
+func (s *customServer) Stop(ctx context.Context) error {
+	atomic.StoreInt32(&s.ready, 0)
+	return nil
+}
+
+func (s *customServer) Ready() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+func TestBootstrap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var conf config.Config
+	conf.TCP.Host, conf.TCP.Port = "127.0.0.1", freePort(t)
+	conf.HTTP.Host, conf.HTTP.Port = "127.0.0.1", freePort(t)
+	conf.GRPC.Host, conf.GRPC.Port = "127.0.0.1", freePort(t)
+
+	custom := &customServer{}
+	b, err := New(conf,
+		WithLogger(log.NewLog(conf.Logger)),
+		WithTCP("second", tcp.Config{Host: "127.0.0.1", Port: freePort(t)}),
+		WithServers(custom),
+		WithRoutes(func(router gin.IRouter, b *Bootstrap) {
+			router.GET("/workers", func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, strconv.Itoa(len(b.Workers())))
+			})
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Worker(DefaultWorker) == nil || b.Worker("second") == nil || b.Worker(DefaultWorker) == b.Worker("second") {
+		t.Fatal("unexpected workers")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.Run(ctx)
+		close(done)
+	}()
+
+	base := "http://127.0.0.1:" + strconv.Itoa(conf.HTTP.Port)
+	deadline := time.Now().Add(time.Second * 5)
+	for b.App().Ready() != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("app is not ready: %v", b.App().Ready())
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if !custom.Ready() {
+		t.Fatal("custom server not started")
+	}
+
+	for path, want := range map[string]string{"/workers": "2", "/readyz": `{"status":"ok"}` + "\n"} {
+		resp, err := http.Get(base + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != want {
+			t.Fatalf("unexpected response %s %d %s", path, resp.StatusCode, body)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 10):
+		t.Fatal("app not stopped")
+	}
+
+	if custom.Ready() {
+		t.Fatal("custom server not stopped")
+	}
+}
+
+func TestBootstrapErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var conf config.Config
+	conf.TCP.Serializer = "unknown"
+	if _, err := New(conf, WithLogger(log.NewLog(conf.Logger))); err == nil {
+		t.Fatal("expected an error for an unknown serializer")
+	}
+
+	conf.TCP.Serializer = ""
+	if _, err := New(conf, WithLogger(log.NewLog(conf.Logger)), WithTCP(DefaultWorker, tcp.Config{})); err == nil {
+		t.Fatal("expected an error for a duplicate tcp server name")
+	}
+}
+
+func TestBootstrapSnowflake(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	before := snowflake.Default()
+	generators := make([]*snowflake.Snowflake, 0, 2)
+	for _, node := range []int64{7, 9} {
+		var conf config.Config
+		conf.GRPC.Host = "127.0.0.1"
+		conf.TCP.Snowflake = &tcp.SnowflakeConfig{Node: node}
+		b, err := New(conf, WithLogger(log.NewLog(conf.Logger)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b.snowflake == nil || b.snowflake.Parse(b.snowflake.Next()).Node != node {
+			t.Fatalf("snowflake of node %d not created", node)
+		}
+
+		generators = append(generators, b.snowflake)
+	}
+
+	if snowflake.Default() != before || generators[0] == generators[1] {
+		t.Fatal("the package level snowflake must not be replaced")
+	}
+}
@@ -1,107 +1,401 @@
 package socket
 
 import (
-	"net/http"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 
+	"github.com/cotton-go/socket/pkg/admin"
+	"github.com/cotton-go/socket/pkg/broker"
 	"github.com/cotton-go/socket/pkg/cache"
+	"github.com/cotton-go/socket/pkg/cluster"
 	"github.com/cotton-go/socket/pkg/codec"
-	"github.com/cotton-go/socket/pkg/log"
+	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/encoding"
+	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/handshake"
+	"github.com/cotton-go/socket/pkg/metrics"
+	"github.com/cotton-go/socket/pkg/registry"
 	"github.com/cotton-go/socket/pkg/server"
 	"github.com/cotton-go/socket/pkg/server/grpc"
 	httpx "github.com/cotton-go/socket/pkg/server/http"
 	"github.com/cotton-go/socket/pkg/server/tcp"
+	"github.com/cotton-go/socket/pkg/snowflake"
 	"github.com/cotton-go/socket/pkg/worker"
 )
 
-var (
-	work *worker.Worker
-)
+// initTCPServer 根据配置创建 Worker 和 TCP 服务器。
+// 雪花算法在同一个 Bootstrap 内共享，只根据主服务器的配置创建；主服务器的注册中心同时用于管理接口。
+// 调用方的 Worker 选项在根据配置生成的选项之后生效，可以覆盖配置中的设置。
+func (b *Bootstrap) initTCPServer(conf tcp.Config, primary bool, extra ...worker.Options) (server.Server, *worker.Worker, error) {
+	var (
+		opts   []worker.Options
+		logger = b.logger
+		cachex = cache.NewMemory()
+		client *redis.Client
+		nodes  registry.Registry
+	)
+
+	if conf.Redis != nil {
+		client = redis.NewClient(&redis.Options{
+			Addr:       conf.Redis.Addr,
+			Username:   conf.Redis.Username,
+			Password:   conf.Redis.Password,
+			MaxRetries: conf.Redis.MaxRetries,
+			DB:         conf.Redis.DB,
+		})
+	}
+
+	// 注册中心保存节点的地址和负载，节点服务通过注册中心找到连接所在的节点
+	if conf.Registry != nil {
+		switch {
+		case conf.Registry.File != "":
+			static, err := registry.LoadFile(conf.Registry.File)
+			if err != nil {
+				return nil, nil, fmt.Errorf("load registry: %w", err)
+			}
+
+			nodes = static
+		case conf.Registry.Gossip != "":
+			gossip, err := registry.NewGossip(conf.Registry.Gossip, []byte(conf.Registry.Key), conf.Registry.Seeds)
+			if err != nil {
+				return nil, nil, fmt.Errorf("init gossip: %w", err)
+			}
+
+			nodes = gossip
+		case client != nil:
+			nodes = registry.NewRedis(client, "")
+		default:
+			return nil, nil, errors.New("init registry: File, Gossip or Redis is required")
+		}
+	}
+
+	// 在创建 Worker 之前创建雪花算法，Worker 和连接的 ID 都由雪花算法生成
+	var sf, owner int64
+	if primary {
+		generator, node, claim, err := initSnowflake(conf, nodes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sf, owner = node, claim
+		b.snowflake = generator
+		b.nodes = nodes
+	}
+	if conf.Registry != nil {
+		node := registry.Node{TCP: conf.Registry.TCP, HTTP: conf.Registry.HTTP, GRPC: conf.Registry.GRPC, Snowflake: sf, Owner: owner}
+		opts = append(opts, worker.WithRegistry(nodes, node, conf.Registry.TTL))
+	}
+
+	// 集群模式下在线连接保存在 Redis 中，其他节点上的连接通过节点服务或 Redis 发布订阅转发消息
+	if conf.Cluster && client != nil {
+		cachex = cache.NewRedis(client)
+		switch {
+		case len(conf.Nodes) > 0:
+			opts = append(opts, worker.WithBroker(cluster.NewBroker(cluster.Static(conf.Nodes), cluster.WithSecret(b.conf.GRPC.Secret))))
+		case nodes != nil && conf.Registry.GRPC != "":
+			discovery, err := cluster.NewDiscovery(context.Background(), nodes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("init discovery: %w", err)
+			}
+
+			opts = append(opts, worker.WithBroker(cluster.NewBroker(discovery, cluster.WithSecret(b.conf.GRPC.Secret))))
+		default:
+			opts = append(opts, worker.WithBroker(broker.NewRedis(client, "")))
+		}
+	}
+
+	// 没有 Redis 时在线连接保存在本节点，其他节点上的连接通过节点服务查询和转发
+	if conf.Cluster && client == nil && nodes != nil && conf.Registry.GRPC != "" {
+		discovery, err := cluster.NewDiscovery(context.Background(), nodes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("init discovery: %w", err)
+		}
+
+		nb := cluster.NewBroker(discovery, cluster.WithSecret(b.conf.GRPC.Secret))
+		cachex = cluster.NewCache(cachex, discovery, nb)
+		opts = append(opts, worker.WithBroker(nb))
+	}
 
-func InitTCPServer(conf tcp.Config, logger *log.Logger, opts ...worker.Options) server.Server {
-	var cachex = cache.NewMemory()
-	// if conf.Redis != nil {
-	// cachex = cache.NewRedis(redis.NewClient(&redis.Options{
-	// 	Addr:       conf.Redis.Addr,
-	// 	Username:   conf.Redis.Username,
-	// 	Password:   conf.Redis.Password,
-	// 	MaxRetries: conf.Redis.MaxRetries,
-	// 	DB:         conf.Redis.DB,
-	// }))
-	// }
+	// 没有配置 Worker ID 时使用本实例的雪花算法生成，不使用包级别的实例
+	switch {
+	case conf.WorkID > 0:
+		opts = append(opts, worker.WithID(conf.WorkID))
+	case b.snowflake != nil:
+		opts = append(opts, worker.WithID(b.snowflake.Next()))
+	}
+
+	if b.snowflake != nil {
+		opts = append(opts, worker.WithIDGenerator(worker.SnowflakeID(b.snowflake)))
+	}
+
+	var (
+		icodec  codec.ICodec
+		tcpOpts []tcp.Option
+		ring    *codec.Keyring
+		err     error
+	)
+
+	// 配置了密钥环时，密文中携带密钥 ID,支持不停机轮换密钥
+	switch {
+	case conf.KeyFile != "":
+		ring, err = codec.LoadKeyring(conf.KeyFile)
+		tcpOpts = append(tcpOpts, tcp.WithServerStartBefore(func(ctx context.Context) {
+			go ring.Watch(ctx, conf.KeyFile, time.Second*10, func(err error) {
+				logger.Error("Failed to reload keyring", zap.Error(err))
+			})
+		}))
+	case len(conf.Keys) > 0:
+		ring, err = codec.NewKeyring(conf.KeyID, conf.Keys)
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("init keyring: %w", err)
+	}
+
+	switch {
+	case len(conf.Codecs) > 0:
+		icodec, err = codec.NewPipeline(conf.Codecs, ring)
+	case ring != nil:
+		icodec, err = codec.NewWithKeyring(conf.Codec, ring)
+	default:
+		icodec, err = codec.New(conf.Codec, conf.Secret)
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("init codec: %w", err)
+	}
 
 	opts = append(opts,
 		worker.WithCache(cachex),
-		worker.WithCodec(codec.New(conf.Codec, conf.Secret)),
+		worker.WithCodec(icodec),
+		worker.WithSecurity(func(c connection.Peer, e event.Event, err error) {
+			logger.Warn("Rejected event", zap.Int64("id", c.ID()), zap.String("topic", e.Topic), zap.Error(err))
+		}),
 	)
 
-	work = worker.NewWorker(opts...)
-	socket := tcp.NewServer(
-		logger,
+	if conf.Serializer != "" {
+		serializer, err := encoding.Get(conf.Serializer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("init serializer: %w", err)
+		}
+
+		opts = append(opts, worker.WithSerializer(serializer))
+	}
+
+	if conf.Binary {
+		opts = append(opts, worker.WithBinary(true))
+	}
+
+	if conf.Handshake != nil {
+		shakeOpts, err := conf.Handshake.Options(ring)
+		if err != nil {
+			return nil, nil, fmt.Errorf("init handshake: %w", err)
+		}
+
+		shake, err := handshake.NewServer(conf.Handshake.PrivateKey, shakeOpts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("init handshake: %w", err)
+		}
+
+		opts = append(opts, worker.WithHandshake(shake))
+	}
+
+	identity, err := b.identityOptions(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts = append(opts, identity...)
+	work := worker.NewWorker(append(opts, extra...)...)
+	tcpOpts = append(tcpOpts,
 		tcp.WithServerWorker(work),
 		tcp.WithServerHost(conf.Host),
 		tcp.WithServerPort(conf.Port),
 	)
 
-	return socket
+	socket := tcp.NewServer(logger, tcpOpts...)
+
+	return socket, work, nil
 }
 
-func InitHTTPServer(conf httpx.Config, logger *log.Logger) server.Server {
-	router := gin.Default()
-	router.GET("/v1/find", func(ctx *gin.Context) {
-		var req struct {
-			ID int64 `json:"id" form:"id"`
+// initSnowflake 根据配置创建雪花算法实例，节点 ID 为 0 时从注册中心分配，返回实例、使用的节点 ID 和占用者。
+// 配置了注册中心时即使没有 Snowflake 配置也从注册中心分配节点 ID,避免集群中的节点都使用节点 ID 0;
+// 两者都没有配置时返回 nil,使用包级别的实例。不修改包级别的实例，同一进程中的多个 Bootstrap 互不影响。
+func initSnowflake(conf tcp.Config, nodes registry.Registry) (*snowflake.Snowflake, int64, int64, error) {
+	sf := conf.Snowflake
+	if sf == nil {
+		if nodes == nil {
+			return nil, 0, 0, nil
 		}
 
-		if err := ctx.Bind(&req); err != nil {
-			ctx.JSON(http.StatusOK, gin.H{"code": 1, "msg": "获取参数错误"})
-			return
-		}
+		sf = &tcp.SnowflakeConfig{}
+	}
 
-		conn := work.Find(req.ID)
-		if conn == nil {
-			ctx.JSON(http.StatusOK, gin.H{"code": 1, "msg": "用户不在线"})
-			return
-		}
+	var opts []snowflake.Option
+	layout := snowflake.DefaultLayout
+	if sf.NodeBits > 0 || sf.SequenceBits > 0 {
+		layout.NodeBits, layout.SequenceBits = sf.NodeBits, sf.SequenceBits
+	}
 
-		ctx.JSON(http.StatusOK, gin.H{"data": conn, "code": 0, "msg": "ok"})
-	})
+	if !sf.Epoch.IsZero() {
+		layout.Epoch = sf.Epoch
+	}
 
-	router.POST("/v1/send", func(ctx *gin.Context) {
-		var req struct {
-			ID    int64  `form:"id" json:"id"`
-			Topic string `form:"topic" json:"topic"`
-			Data  any    `form:"data" json:"data"`
-		}
+	opts = append(opts, snowflake.WithLayout(layout))
+	if sf.Tolerance > 0 {
+		opts = append(opts, snowflake.WithTolerance(sf.Tolerance))
+	}
 
-		if err := ctx.Bind(&req); err != nil {
-			ctx.JSON(http.StatusOK, gin.H{"code": 1, "msg": "获取参数错误"})
-			return
+	var owner int64
+	node := sf.Node
+	if node == 0 && nodes != nil {
+		var err error
+		node, owner, err = registry.AllocateSnowflake(context.Background(), nodes, conf.WorkID, layout.MaxNode(), conf.Registry.TTL)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("allocate snowflake node: %w", err)
 		}
+	}
+
+	generator, err := snowflake.NewSnowflake(node, opts...)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("init snowflake: %w", err)
+	}
 
-		conn := work.Find(req.ID)
-		if conn == nil {
-			ctx.JSON(http.StatusOK, gin.H{"code": 1, "msg": "用户不在线"})
-			return
+	return generator, node, owner, nil
+}
+
+// identityOptions 根据配置返回连接 ID 生成器、身份校验和冲突策略的工作器选项。
+// 身份校验函数只能由调用方通过 WithAuthenticator 提供，配置要求客户端身份而没有校验函数时返回错误，
+// 否则任何客户端都可以声明其他用户的身份。
+func (b *Bootstrap) identityOptions(conf tcp.Config) ([]worker.Options, error) {
+	if conf.Identity == nil {
+		return nil, nil
+	}
+
+	var opts []worker.Options
+	switch conf.Identity.Generator {
+	case "", "snowflake":
+		opts = append(opts, worker.WithIDGenerator(worker.SnowflakeID(b.snowflake)))
+	case "uuidv7":
+		opts = append(opts, worker.WithIDGenerator(worker.UUIDv7ID()))
+	case "identity":
+		opts = append(opts, worker.WithIDGenerator(worker.IdentityID()))
+	default:
+		return nil, fmt.Errorf("unknown connection id generator %q", conf.Identity.Generator)
+	}
+
+	if conf.Identity.Required || conf.Identity.Generator == "identity" {
+		if b.auth == nil {
+			return nil, errors.New("identity is required but no authenticator is configured, pass socket.WithAuthenticator")
 		}
 
-		if err := conn.Send(req.Topic, req.Data); err != nil {
-			ctx.JSON(http.StatusOK, gin.H{"code": 1, "msg": "发送数据失败"})
-			return
+		opts = append(opts, worker.WithIdentity(b.auth))
+	}
+
+	switch conf.Identity.Conflict {
+	case "", "reject":
+		opts = append(opts, worker.WithConflict(worker.ConflictReject))
+	case "replace":
+		opts = append(opts, worker.WithConflict(worker.ConflictReplace))
+	default:
+		return nil, fmt.Errorf("unknown connection id conflict policy %q", conf.Identity.Conflict)
+	}
+
+	return opts, nil
+}
+
+// initHTTPServer 创建 HTTP 服务器，管理接口和 HTTP 传输使用默认的 Worker,之后调用路由注册函数
+func (b *Bootstrap) initHTTPServer(conf httpx.Config) (server.Server, error) {
+	logger, work := b.logger, b.Worker(DefaultWorker)
+	opts := []admin.Option{admin.WithRegistry(b.nodes), admin.WithLogger(logger), admin.WithInsecure(conf.Insecure), admin.WithSnowflake(b.snowflake)}
+	for _, token := range conf.Tokens {
+		switch scope := admin.Scope(token.Scope); scope {
+		case admin.ScopeRead, admin.ScopeWrite:
+			opts = append(opts, admin.WithToken(token.Token, scope))
+		default:
+			return nil, fmt.Errorf("unknown admin token scope %q", token.Scope)
 		}
+	}
+
+	router := gin.Default()
+	admin.New(work, opts...).Register(router)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
-		ctx.JSON(http.StatusOK, gin.H{"msg": "ok", "code": 0})
-	})
+	// 健康检查和就绪检查反映服务器的状态，收到终止信号后就绪检查失败
+	probes := httpx.NewProbeHandler(b.app, conf.Pprof)
+	router.GET("/healthz", gin.WrapH(probes))
+	router.GET("/readyz", gin.WrapH(probes))
+	if conf.Pprof {
+		router.Any("/debug/pprof/*name", gin.WrapH(probes))
+	}
 
-	return httpx.NewServer(
-		logger,
-		router,
+	serverOpts := []httpx.Option{
 		httpx.WithServerHost(conf.Host),
 		httpx.WithServerPort(conf.Port),
+	}
+
+	// 挂载 HTTP 传输，无法使用 TCP 的客户端通过 SSE 或长轮询连接到 Worker
+	if conf.Transport != nil {
+		path := strings.TrimSuffix(conf.Transport.Path, "/")
+		if path == "" {
+			path = "/transport"
+		}
+
+		transport := httpx.NewTransport(
+			work,
+			httpx.WithIdleTimeout(conf.Transport.IdleTimeout),
+			httpx.WithPollTimeout(conf.Transport.PollTimeout),
+			httpx.WithMaxSessions(conf.Transport.MaxSessions),
+		)
+
+		router.Any(path+"/*action", gin.WrapH(transport))
+		serverOpts = append(serverOpts, httpx.WithServerTransport(transport))
+	}
+
+	for _, fn := range b.routes {
+		fn(router, b)
+	}
+
+	return httpx.NewServer(logger, router, serverOpts...), nil
+}
+
+// initGRPCServer 创建 gRPC 服务器，并为默认的 Worker 注册节点服务。
+// 节点服务可以向任意连接发送消息和踢出连接，监听非回环地址时必须配置共享密钥。
+func (b *Bootstrap) initGRPCServer(conf grpc.Config) (server.Server, error) {
+	work := b.Worker(DefaultWorker)
+	if conf.Secret == "" && !loopback(conf.Host) {
+		return nil, fmt.Errorf("GRPC.Secret is required when the node service listens on a non-loopback address: %q", conf.Host)
+	}
+
+	s := grpc.NewServer(
+		b.logger,
+		grpc.WithServerHost(conf.Host),
+		grpc.WithServerPort(conf.Port),
+		grpc.WithServerOptions(cluster.ServerSecret(conf.Secret)),
 	)
+
+	// 注册节点服务，其他节点通过该服务向本节点上的连接发送消息
+	if work != nil {
+		cluster.NewService(work).Register(s)
+	}
+
+	return s, nil
 }
 
-func InitGRPCServer(conf grpc.Config, logger *log.Logger) server.Server {
-	return grpc.NewServer(logger)
+// loopback 返回主机名是否只能从本机访问，为空或 0.0.0.0 时监听所有地址
+func loopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
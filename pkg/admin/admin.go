@@ -0,0 +1,269 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/cotton-go/socket/pkg/log"
+	"github.com/cotton-go/socket/pkg/registry"
+	"github.com/cotton-go/socket/pkg/snowflake"
+	"github.com/cotton-go/socket/pkg/worker"
+)
+
+// Scope 表示 API 令牌的权限范围
+type Scope string
+
+const (
+	// ScopeRead 只能调用查询接口
+	ScopeRead Scope = "read"
+
+	// ScopeWrite 可以调用所有接口，包含 ScopeRead 的权限
+	ScopeWrite Scope = "write"
+)
+
+// allows 返回权限范围是否包含 required
+func (s Scope) allows(required Scope) bool {
+	return s == ScopeWrite || s == required
+}
+
+// 错误码，所有接口的响应都包含 code 和 msg 字段，code 为 0 时表示成功
+const (
+	CodeOK           = 0    // 成功
+	CodeInvalidParam = 1001 // 参数错误
+	CodeUnauthorized = 1002 // 没有提供令牌或令牌无效
+	CodeForbidden    = 1003 // 令牌的权限范围不足
+	CodeNotFound     = 1004 // 连接不在线或资源不存在
+	CodeUnsupported  = 1005 // 缓存、消息代理或注册中心不支持该功能
+	CodeInternal     = 1006 // 内部错误
+	CodeTimeout      = 1007 // 等待客户端响应超时
+	CodeBusy         = 1008 // 正在执行的异步任务过多
+)
+
+// statuses 是错误码对应的 HTTP 状态码
+var statuses = map[int]int{
+	CodeOK:           http.StatusOK,
+	CodeInvalidParam: http.StatusBadRequest,
+	CodeUnauthorized: http.StatusUnauthorized,
+	CodeForbidden:    http.StatusForbidden,
+	CodeNotFound:     http.StatusNotFound,
+	CodeUnsupported:  http.StatusNotImplemented,
+	CodeInternal:     http.StatusInternalServerError,
+	CodeTimeout:      http.StatusGatewayTimeout,
+	CodeBusy:         http.StatusTooManyRequests,
+}
+
+// Option 是一个函数类型，用于配置管理接口
+type Option func(*API)
+
+// WithToken 添加一个 API 令牌，没有添加令牌时拒绝所有请求，除非通过 WithInsecure 开放管理接口
+//
+// 参数：
+//   - token string 令牌，请求时放在 Authorization: Bearer <token> 请求头中
+//   - scope Scope 令牌的权限范围
+//
+// 返回值：
+//   - Option 管理接口选项
+func WithToken(token string, scope Scope) Option {
+	return func(a *API) {
+		a.tokens[token] = scope
+	}
+}
+
+// WithInsecure 设置没有添加令牌时是否允许所有请求，仅用于本地开发，默认拒绝所有请求
+//
+// 参数：
+//   - value bool 是否允许没有令牌的请求
+//
+// 返回值：
+//   - Option 管理接口选项
+func WithInsecure(value bool) Option {
+	return func(a *API) {
+		a.insecure = value
+	}
+}
+
+// WithLogger 设置日志记录器，默认不记录日志
+//
+// 参数：
+//   - logger *log.Logger 日志记录器
+//
+// 返回值：
+//   - Option 管理接口选项
+func WithLogger(logger *log.Logger) Option {
+	return func(a *API) {
+		a.logger = logger
+	}
+}
+
+// WithRegistry 设置注册中心，用于查询节点列表
+//
+// 参数：
+//   - value registry.Registry 注册中心
+//
+// 返回值：
+//   - Option 管理接口选项
+func WithRegistry(value registry.Registry) Option {
+	return func(a *API) {
+		a.registry = value
+	}
+}
+
+// WithSnowflake 设置解析 ID 使用的雪花算法实例，默认使用包级别的实例
+//
+// 参数：
+//   - value *snowflake.Snowflake 生成连接 ID 的雪花算法实例
+//
+// 返回值：
+//   - Option 管理接口选项
+func WithSnowflake(value *snowflake.Snowflake) Option {
+	return func(a *API) {
+		a.snowflake = value
+	}
+}
+
+// API 结构体，基于 Worker 实现管理连接的 HTTP 接口
+type API struct {
+	work      *worker.Worker       // 本节点的 Worker
+	registry  registry.Registry    // 注册中心，为 nil 时不支持查询节点
+	snowflake *snowflake.Snowflake // 解析 ID 使用的雪花算法实例，为 nil 时使用包级别的实例
+	tokens    map[string]Scope     // API 令牌和权限范围
+	insecure  bool                 // 没有令牌时是否允许所有请求
+	logger    *log.Logger          // 日志记录器
+	started   time.Time            // 创建时间，用于统计运行时长
+	jobs      jobs                 // 异步的批量发送任务
+}
+
+// New 创建管理接口
+//
+// 参数：
+//   - work *worker.Worker 本节点的 Worker
+//   - opts ...Option 管理接口选项
+//
+// 返回值：
+//   - *API 管理接口
+func New(work *worker.Worker, opts ...Option) *API {
+	a := &API{work: work, tokens: make(map[string]Scope), started: time.Now(), logger: &log.Logger{Logger: zap.NewNop()}}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	switch {
+	case len(a.tokens) > 0:
+	case a.insecure:
+		a.logger.Warn("Admin api tokens not configured and insecure is enabled, all requests are allowed")
+	default:
+		a.logger.Warn("Admin api tokens not configured, all requests are denied")
+	}
+
+	return a
+}
+
+// Register 将管理接口注册到路由
+//
+// 参数：
+//   - router gin.IRouter 路由
+func (a *API) Register(router gin.IRouter) {
+	read := router.Group("/v1", a.auth(ScopeRead))
+	read.GET("/find", a.find)
+	read.GET("/online", a.online)
+	read.GET("/presence", a.presence)
+	read.GET("/presence/watch", a.watch)
+	read.GET("/id", a.parseID)
+	read.GET("/nodes", a.nodes)
+	read.GET("/stats", a.stats)
+	read.GET("/connections", a.connections)
+	read.GET("/connections/:id", a.connection)
+	read.GET("/connections/:id/rooms", a.joined)
+	read.GET("/rooms/:room/members", a.members)
+	read.GET("/users/:identity", a.user)
+	read.GET("/jobs/:id", a.job)
+
+	write := router.Group("/v1", a.auth(ScopeWrite))
+	write.POST("/send", a.send)
+	write.POST("/broadcast", a.broadcast)
+	write.POST("/batch", a.batch)
+	write.POST("/connections/:id/send", a.send)
+	write.POST("/connections/:id/kick", a.kick)
+	write.POST("/connections/:id/request", a.request)
+	write.PUT("/rooms/:room/members/:id", a.join)
+	write.DELETE("/rooms/:room/members/:id", a.leave)
+}
+
+// auth 返回校验 API 令牌权限范围的中间件
+func (a *API) auth(required Scope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if len(a.tokens) == 0 {
+			if !a.insecure {
+				abort(ctx, CodeUnauthorized, "没有配置 API 令牌，管理接口已禁用")
+			}
+
+			return
+		}
+
+		token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			abort(ctx, CodeUnauthorized, "缺少 API 令牌")
+			return
+		}
+
+		scope, ok := a.lookup(token)
+		if !ok {
+			abort(ctx, CodeUnauthorized, "API 令牌无效")
+			return
+		}
+
+		if !scope.allows(required) {
+			abort(ctx, CodeForbidden, "API 令牌权限不足")
+			return
+		}
+	}
+}
+
+// lookup 以固定时间比较查找令牌，避免通过响应时间猜测令牌
+func (a *API) lookup(token string) (Scope, bool) {
+	var (
+		found Scope
+		ok    bool
+	)
+
+	for candidate, scope := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			found, ok = scope, true
+		}
+	}
+
+	return found, ok
+}
+
+// success 返回成功的响应
+func success(ctx *gin.Context, data any) {
+	ctx.JSON(http.StatusOK, gin.H{"data": data, "code": CodeOK, "msg": "ok"})
+}
+
+// abort 返回错误码和错误信息，并终止后续的处理函数
+func abort(ctx *gin.Context, code int, msg string) {
+	ctx.AbortWithStatusJSON(statuses[code], gin.H{"code": code, "msg": msg})
+}
+
+// fail 根据 Worker 返回的错误选择错误码
+func fail(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, worker.ErrNotFound):
+		abort(ctx, CodeNotFound, "用户不在线")
+	case errors.Is(err, context.DeadlineExceeded):
+		abort(ctx, CodeTimeout, "等待客户端响应超时")
+	case errors.Is(err, worker.ErrNoBroker), errors.Is(err, worker.ErrNoPresence), errors.Is(err, worker.ErrNoRooms):
+		abort(ctx, CodeUnsupported, err.Error())
+	default:
+		fmt.Println("admin api error", msg, err)
+		abort(ctx, CodeInternal, msg)
+	}
+}
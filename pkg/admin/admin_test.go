@@ -0,0 +1,295 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/worker"
+)
+
+// response 表示管理接口的响应
+type response struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// call 调用管理接口，返回 HTTP 状态码和响应
+func call(t *testing.T, router http.Handler, method, path, token, body string) (int, response) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+
+	return rec.Code, resp
+}
+
+// receive 等待客户端收到指定主题的事件
+func receive(t *testing.T, received chan event.Event, topic string) event.Event {
+	t.Helper()
+
+	timeout := time.After(time.Second * 2)
+	for {
+		select {
+		case e := <-received:
+			if e.Topic == topic {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("event %q not received", topic)
+		}
+	}
+}
+
+func TestAPI(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gin.SetMode(gin.TestMode)
+	work := worker.NewWorker(worker.WithContext(ctx))
+	router := gin.New()
+	api := New(work, WithToken("reader", ScopeRead), WithToken("writer", ScopeWrite))
+	api.Register(router)
+	time.Sleep(time.Millisecond * 100)
+
+	received := make(chan event.Event, 10)
+	server, client := net.Pipe()
+	connection.NewConnection(
+		connection.WithConn(client),
+		connection.WithClient(true),
+		connection.WithContext(ctx),
+		connection.WithHandle(func(c connection.Peer, e event.Event) {
+			if e.Topic == "config" && e.Seq != 0 {
+				c.Reply(e, e.Data)
+				return
+			}
+
+			received <- e
+		}),
+	)
+
+	conn := work.Connection(server)
+	deadline := time.Now().Add(time.Second * 2)
+	for work.Local(conn.ID()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not online")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	id := strconv.FormatInt(conn.ID(), 10)
+
+	t.Run("auth", func(t *testing.T) {
+		if status, resp := call(t, router, http.MethodGet, "/v1/stats", "", ""); status != http.StatusUnauthorized || resp.Code != CodeUnauthorized {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+
+		if status, resp := call(t, router, http.MethodGet, "/v1/stats", "unknown", ""); status != http.StatusUnauthorized || resp.Code != CodeUnauthorized {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+
+		if status, resp := call(t, router, http.MethodPost, "/v1/broadcast", "reader", `{"topic":"news"}`); status != http.StatusForbidden || resp.Code != CodeForbidden {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+
+		// 写权限包含读权限
+		if status, resp := call(t, router, http.MethodGet, "/v1/stats", "writer", ""); status != http.StatusOK || resp.Code != CodeOK {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+	})
+
+	t.Run("insecure", func(t *testing.T) {
+		denied := gin.New()
+		New(work).Register(denied)
+		if status, resp := call(t, denied, http.MethodGet, "/v1/stats", "", ""); status != http.StatusUnauthorized || resp.Code != CodeUnauthorized {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+
+		allowed := gin.New()
+		New(work, WithInsecure(true)).Register(allowed)
+		if status, resp := call(t, allowed, http.MethodGet, "/v1/stats", "", ""); status != http.StatusOK || resp.Code != CodeOK {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+	})
+
+	t.Run("connections", func(t *testing.T) {
+		_, resp := call(t, router, http.MethodGet, "/v1/connections?count=10", "reader", "")
+		var page struct {
+			List []connection.Info `json:"list"`
+		}
+
+		if err := json.Unmarshal(resp.Data, &page); err != nil || len(page.List) != 1 || page.List[0].ID != conn.ID() {
+			t.Fatalf("unexpected page %s %v", resp.Data, err)
+		}
+
+		_, resp = call(t, router, http.MethodGet, "/v1/connections?node=99", "reader", "")
+		if err := json.Unmarshal(resp.Data, &page); err != nil || len(page.List) != 0 {
+			t.Fatalf("unexpected page %s %v", resp.Data, err)
+		}
+
+		if status, resp := call(t, router, http.MethodGet, "/v1/connections/abc", "reader", ""); status != http.StatusBadRequest || resp.Code != CodeInvalidParam {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+
+		if status, resp := call(t, router, http.MethodGet, "/v1/connections/1", "reader", ""); status != http.StatusNotFound || resp.Code != CodeNotFound {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+	})
+
+	t.Run("rooms", func(t *testing.T) {
+		if _, resp := call(t, router, http.MethodPut, "/v1/rooms/lobby/members/"+id, "writer", ""); resp.Code != CodeOK {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+
+		_, resp := call(t, router, http.MethodGet, "/v1/connections/"+id, "reader", "")
+		var d detail
+		if err := json.Unmarshal(resp.Data, &d); err != nil || len(d.Rooms) != 1 || d.Rooms[0] != "lobby" || d.Presence == nil || !d.Presence.Online {
+			t.Fatalf("unexpected detail %s %v", resp.Data, err)
+		}
+
+		if _, resp := call(t, router, http.MethodPost, "/v1/broadcast", "writer", `{"room":"lobby","topic":"news","data":"hello"}`); resp.Code != CodeOK {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+
+		receive(t, received, "news")
+
+		if _, resp := call(t, router, http.MethodDelete, "/v1/rooms/lobby/members/"+id, "writer", ""); resp.Code != CodeOK {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+
+		_, resp = call(t, router, http.MethodGet, "/v1/rooms/lobby/members", "reader", "")
+		if string(resp.Data) != "[]" && string(resp.Data) != "null" {
+			t.Fatalf("unexpected members %s", resp.Data)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		if status, resp := call(t, router, http.MethodPost, "/v1/batch", "writer", `{"ids":[1]}`); status != http.StatusBadRequest || resp.Code != CodeInvalidParam {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+
+		body := `{"ids":[` + id + `,404],"users":["nobody"],"payloads":[{"topic":"first"},{"topic":"second"}]}`
+		_, resp := call(t, router, http.MethodPost, "/v1/batch", "writer", body)
+		var report Report
+		if err := json.Unmarshal(resp.Data, &report); err != nil || len(report.Results) != 3 {
+			t.Fatalf("unexpected report %s %v", resp.Data, err)
+		}
+
+		if report.Summary[worker.StatusDelivered] != 1 || report.Summary[worker.StatusOffline] != 2 || report.Results[0].Status != worker.StatusDelivered {
+			t.Fatalf("unexpected report %+v", report)
+		}
+
+		receive(t, received, "first")
+		receive(t, received, "second")
+
+		// 异步任务立即返回任务 ID,完成后可以查询结果
+		_, resp = call(t, router, http.MethodPost, "/v1/batch", "writer", `{"ids":[`+id+`],"topic":"async","async":true}`)
+		var created struct {
+			Job string `json:"job"`
+		}
+
+		if err := json.Unmarshal(resp.Data, &created); err != nil || created.Job == "" {
+			t.Fatalf("unexpected job %s %v", resp.Data, err)
+		}
+
+		receive(t, received, "async")
+		deadline := time.Now().Add(time.Second * 2)
+		for {
+			_, resp = call(t, router, http.MethodGet, "/v1/jobs/"+created.Job, "reader", "")
+			var j job
+			if err := json.Unmarshal(resp.Data, &j); err != nil {
+				t.Fatal(err)
+			}
+
+			if j.Done {
+				if j.Report == nil || j.Report.Summary[worker.StatusDelivered] != 1 {
+					t.Fatalf("unexpected job %+v", j)
+				}
+
+				break
+			}
+
+			if time.Now().After(deadline) {
+				t.Fatal("job is not done")
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		if status, _ := call(t, router, http.MethodGet, "/v1/jobs/unknown", "reader", ""); status != http.StatusNotFound {
+			t.Fatalf("unexpected status %d", status)
+		}
+
+		// 任务保存在缓存中，共享缓存的其他节点也可以查询
+		other := gin.New()
+		New(work, WithToken("reader", ScopeRead)).Register(other)
+		if status, _ := call(t, other, http.MethodGet, "/v1/jobs/"+created.Job, "reader", ""); status != http.StatusOK {
+			t.Fatalf("job not found on another node: %d", status)
+		}
+
+		// 正在执行的异步任务达到上限时拒绝新的任务
+		atomic.StoreInt32(&api.jobs.running, maxJobs)
+		defer atomic.StoreInt32(&api.jobs.running, 0)
+		if status, resp := call(t, router, http.MethodPost, "/v1/batch", "writer", `{"ids":[`+id+`],"topic":"async","async":true}`); status != http.StatusTooManyRequests || resp.Code != CodeBusy {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+	})
+
+	t.Run("request", func(t *testing.T) {
+		_, resp := call(t, router, http.MethodPost, "/v1/connections/"+id+"/request", "writer", `{"topic":"config","data":{"key":"value"}}`)
+		if resp.Code != CodeOK || string(resp.Data) != `{"key":"value"}` {
+			t.Fatalf("unexpected response %+v %s", resp, resp.Data)
+		}
+
+		status, resp := call(t, router, http.MethodPost, "/v1/connections/"+id+"/request", "writer", `{"topic":"ignored","timeout":100}`)
+		if status != http.StatusGatewayTimeout || resp.Code != CodeTimeout {
+			t.Fatalf("unexpected response %d %+v", status, resp)
+		}
+	})
+
+	t.Run("kick", func(t *testing.T) {
+		if _, resp := call(t, router, http.MethodPost, "/v1/connections/"+id+"/kick", "writer", `{"reason":"maintenance"}`); resp.Code != CodeOK {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+
+		if e := receive(t, received, event.TopicByKick); e.Data != "maintenance" {
+			t.Fatalf("unexpected reason %v", e.Data)
+		}
+
+		deadline := time.Now().Add(time.Second * 2)
+		for work.Local(conn.ID()) != nil {
+			if time.Now().After(deadline) {
+				t.Fatal("connection is not closed")
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package admin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/cotton-go/socket/pkg/cache"
+	"github.com/cotton-go/socket/pkg/worker"
+)
+
+const (
+	// maxTargets 是一次批量发送的最大目标数，房间算作一个目标
+	maxTargets = 10000
+
+	// jobTTL 是异步任务保留的时间，任务完成时重新计时
+	jobTTL = time.Minute * 10
+
+	// maxJobs 是每个节点同时执行的最大异步任务数
+	maxJobs = 16
+
+	// jobKey 是异步任务在缓存中的键名前缀
+	jobKey = "admin:job:"
+)
+
+// batchRequest 表示批量发送的请求，payloads 为空时使用 topic 和 data 作为唯一的事件
+type batchRequest struct {
+	IDs      []int64          `json:"ids"`      // 连接 ID
+	Users    []string         `json:"users"`    // 客户端的身份，通过 Worker.Lookup 查找连接
+	Rooms    []string         `json:"rooms"`    // 房间名称，发送给所有节点上的房间成员
+	Payloads []worker.Payload `json:"payloads"` // 按顺序发送给每个目标的事件
+	Topic    string           `json:"topic"`
+	Data     any              `json:"data"`
+	Async    bool             `json:"async"` // 为 true 时立即返回任务 ID,通过 /v1/jobs/:id 查询结果
+}
+
+// Result 结构体表示批量发送时单个目标的发送结果
+type Result struct {
+	Type   string                `json:"type"`            // 目标类型:connection、user、room
+	Target string                `json:"target"`          // 连接 ID、客户端的身份或房间名称
+	ID     int64                 `json:"id,omitempty"`    // 目标对应的连接 ID,房间为 0
+	Status worker.DeliveryStatus `json:"status"`          // 发送结果
+	Error  string                `json:"error,omitempty"` // 失败的原因
+}
+
+// Report 结构体表示批量发送的结果
+type Report struct {
+	Results []Result                      `json:"results"` // 每个目标的发送结果，顺序与请求一致
+	Summary map[worker.DeliveryStatus]int `json:"summary"` // 每种发送结果的目标数
+}
+
+// job 结构体表示异步的批量发送任务
+type job struct {
+	ID       string    `json:"id"`
+	Done     bool      `json:"done"`             // 是否已经完成
+	Created  time.Time `json:"created"`          // 创建时间
+	Finished time.Time `json:"finished"`         // 完成时间，未完成时为零值
+	Report   *Report   `json:"report,omitempty"` // 完成后的发送结果
+}
+
+// jobs 结构体保存异步任务。缓存实现了 cache.Store 时任务保存在缓存中，
+// 负载均衡把查询请求转发到任意节点都可以查到；否则保存在本节点。任务在 jobTTL 后被清理。
+type jobs struct {
+	once    sync.Once
+	local   cache.Store // 缓存不支持 cache.Store 时使用的本节点存储
+	running int32       // 本节点正在执行的任务数
+}
+
+// store 返回保存任务的存储
+func (j *jobs) store(c cache.ICache) cache.Store {
+	if store, ok := c.(cache.Store); ok {
+		return store
+	}
+
+	j.once.Do(func() {
+		j.local = cache.NewMemory().(cache.Store)
+	})
+
+	return j.local
+}
+
+// acquire 占用一个任务槽位，本节点正在执行的任务数达到 maxJobs 时返回 false
+func (j *jobs) acquire() bool {
+	if atomic.AddInt32(&j.running, 1) > maxJobs {
+		atomic.AddInt32(&j.running, -1)
+		return false
+	}
+
+	return true
+}
+
+// release 释放任务槽位
+func (j *jobs) release() {
+	atomic.AddInt32(&j.running, -1)
+}
+
+// save 保存任务
+func (j *jobs) save(store cache.Store, t *job) error {
+	value, err := sonic.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	return store.Put(jobKey+t.ID, value, jobTTL)
+}
+
+// get 返回任务，不存在或已过期时返回 nil
+func (j *jobs) get(store cache.Store, id string) (*job, error) {
+	value, err := store.Get(jobKey + id)
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	var t job
+	if err := sonic.Unmarshal(value, &t); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// batch 向多个连接、客户端身份和房间发送一个或多个事件，返回每个目标的发送结果
+func (a *API) batch(ctx *gin.Context) {
+	var req batchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abort(ctx, CodeInvalidParam, "获取参数错误")
+		return
+	}
+
+	if len(req.Payloads) == 0 && req.Topic != "" {
+		req.Payloads = []worker.Payload{{Topic: req.Topic, Data: req.Data}}
+	}
+
+	if len(req.Payloads) == 0 {
+		abort(ctx, CodeInvalidParam, "事件不能为空")
+		return
+	}
+
+	for _, payload := range req.Payloads {
+		if payload.Topic == "" {
+			abort(ctx, CodeInvalidParam, "事件主题不能为空")
+			return
+		}
+	}
+
+	if total := len(req.IDs) + len(req.Users) + len(req.Rooms); total == 0 || total > maxTargets {
+		abort(ctx, CodeInvalidParam, "目标数量必须在 1 到 "+strconv.Itoa(maxTargets)+" 之间")
+		return
+	}
+
+	if !req.Async {
+		success(ctx, a.deliver(req))
+		return
+	}
+
+	if !a.jobs.acquire() {
+		abort(ctx, CodeBusy, "正在执行的异步任务过多，请稍后重试")
+		return
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		a.jobs.release()
+		fail(ctx, err, "创建任务失败")
+		return
+	}
+
+	store := a.jobs.store(a.work.Cache())
+	t := &job{ID: hex.EncodeToString(b), Created: time.Now()}
+	if err := a.jobs.save(store, t); err != nil {
+		a.jobs.release()
+		fail(ctx, err, "创建任务失败")
+		return
+	}
+
+	go func() {
+		defer a.jobs.release()
+		t.Report = a.deliver(req)
+		t.Done, t.Finished = true, time.Now()
+		if err := a.jobs.save(store, t); err != nil {
+			a.logger.Error("Failed to save batch job", zap.String("job", t.ID), zap.Error(err))
+		}
+	}()
+
+	success(ctx, gin.H{"job": t.ID})
+}
+
+// job 查询异步任务的状态和发送结果
+func (a *API) job(ctx *gin.Context) {
+	t, err := a.jobs.get(a.jobs.store(a.work.Cache()), ctx.Param("id"))
+	if err != nil {
+		fail(ctx, err, "查询任务失败")
+		return
+	}
+
+	if t == nil {
+		abort(ctx, CodeNotFound, "任务不存在或已过期")
+		return
+	}
+
+	success(ctx, t)
+}
+
+// deliver 解析目标并发送事件，连接 ID 和客户端身份合并后通过 Worker.Multicast 一次发送
+func (a *API) deliver(req batchRequest) *Report {
+	results := make([]Result, 0, len(req.IDs)+len(req.Users)+len(req.Rooms))
+	ids := make([]int64, 0, len(req.IDs)+len(req.Users))
+	for _, id := range req.IDs {
+		results = append(results, Result{Type: "connection", Target: strconv.FormatInt(id, 10), ID: id})
+		ids = append(ids, id)
+	}
+
+	for _, identity := range req.Users {
+		result := Result{Type: "user", Target: identity, Status: worker.StatusOffline}
+		if conn := a.work.Lookup(identity); conn != nil {
+			result.ID = conn.ID()
+			ids = append(ids, result.ID)
+		}
+
+		results = append(results, result)
+	}
+
+	deliveries := make(map[int64]worker.Delivery, len(ids))
+	for _, d := range a.work.Multicast(ids, req.Payloads...) {
+		deliveries[d.ID] = d
+	}
+
+	for i := range results {
+		if d, ok := deliveries[results[i].ID]; ok && results[i].ID != 0 {
+			results[i].Status, results[i].Error = d.Status, d.Error
+		}
+	}
+
+	for _, room := range req.Rooms {
+		result := Result{Type: "room", Target: room, Status: worker.StatusRouted}
+		for _, payload := range req.Payloads {
+			if err := a.work.BroadcastRoom(room, payload.Topic, payload.Data); err != nil {
+				result.Status, result.Error = worker.StatusFailed, err.Error()
+				break
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	summary := make(map[worker.DeliveryStatus]int)
+	for _, result := range results {
+		summary[result.Status]++
+	}
+
+	return &Report{Results: results, Summary: summary}
+}
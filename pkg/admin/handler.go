@@ -0,0 +1,493 @@
+package admin
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cotton-go/socket/pkg/cache"
+	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/snowflake"
+)
+
+const (
+	// defaultCount 是分页查询默认返回的数量
+	defaultCount = 100
+
+	// defaultRequestTimeout 是请求客户端时默认等待响应的时间
+	defaultRequestTimeout = time.Second * 5
+
+	// maxRequestTimeout 是请求客户端时最长等待响应的时间
+	maxRequestTimeout = time.Minute
+)
+
+// detail 结构体表示连接的详细信息
+type detail struct {
+	Info     connection.Info `json:"info"`               // 连接信息
+	Identity any             `json:"identity,omitempty"` // 客户端通过校验的身份，只有本节点上的连接可以获取
+	Presence *cache.Presence `json:"presence,omitempty"` // 在线状态，缓存不支持时为空
+	Rooms    []string        `json:"rooms,omitempty"`    // 加入的房间，缓存不支持时为空
+}
+
+// idParam 解析路径中的连接 ID
+func idParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		abort(ctx, CodeInvalidParam, "连接 ID 无效")
+		return 0, false
+	}
+
+	return id, true
+}
+
+// describe 查询连接的详细信息
+func (a *API) describe(conn connection.Peer) detail {
+	d := detail{Info: conn.Info()}
+	if local := a.work.Local(d.Info.ID); local != nil {
+		d.Identity, _ = local.Attributes().Get("identity")
+	}
+
+	if status, err := a.work.Status(d.Info.ID); err == nil {
+		d.Presence = &status
+	}
+
+	d.Rooms, _ = a.work.Rooms(d.Info.ID)
+	return d
+}
+
+// find 查询在线连接的信息
+func (a *API) find(ctx *gin.Context) {
+	var req struct {
+		ID int64 `json:"id" form:"id"`
+	}
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		abort(ctx, CodeInvalidParam, "获取参数错误")
+		return
+	}
+
+	conn := a.work.Find(req.ID)
+	if conn == nil {
+		abort(ctx, CodeNotFound, "用户不在线")
+		return
+	}
+
+	success(ctx, conn.Info())
+}
+
+// online 分页查询所有节点上的在线连接
+func (a *API) online(ctx *gin.Context) {
+	var req struct {
+		Cursor uint64 `json:"cursor" form:"cursor"`
+		Count  int64  `json:"count" form:"count"`
+	}
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		abort(ctx, CodeInvalidParam, "获取参数错误")
+		return
+	}
+
+	lister, ok := a.work.Cache().(cache.Lister)
+	if !ok {
+		abort(ctx, CodeUnsupported, "缓存不支持查询在线连接")
+		return
+	}
+
+	if req.Count <= 0 {
+		req.Count = defaultCount
+	}
+
+	total, err := lister.Count()
+	if err != nil {
+		fail(ctx, err, "获取在线连接失败")
+		return
+	}
+
+	list, next, err := lister.List(req.Cursor, req.Count)
+	if err != nil {
+		fail(ctx, err, "获取在线连接失败")
+		return
+	}
+
+	success(ctx, gin.H{"list": list, "cursor": next, "total": total})
+}
+
+// connections 分页查询在线连接，可以按照节点和房间过滤。
+// 按房间过滤时游标为房间成员的偏移量，否则为缓存返回的游标，过滤后一页的数量可能少于 count
+func (a *API) connections(ctx *gin.Context) {
+	var req struct {
+		Cursor uint64 `json:"cursor" form:"cursor"`
+		Count  int64  `json:"count" form:"count"`
+		Node   int64  `json:"node" form:"node"`
+		Room   string `json:"room" form:"room"`
+	}
+
+	if err := ctx.ShouldBind(&req); err != nil || req.Count < 0 {
+		abort(ctx, CodeInvalidParam, "获取参数错误")
+		return
+	}
+
+	if req.Count == 0 {
+		req.Count = defaultCount
+	}
+
+	var (
+		list []connection.Info
+		next uint64
+	)
+
+	if req.Room != "" {
+		members, err := a.work.Members(req.Room)
+		if err != nil {
+			fail(ctx, err, "获取房间成员失败")
+			return
+		}
+
+		if req.Cursor < uint64(len(members)) {
+			end := req.Cursor + uint64(req.Count)
+			if end < uint64(len(members)) {
+				next = end
+			} else {
+				end = uint64(len(members))
+			}
+
+			list = members[req.Cursor:end]
+		}
+	} else {
+		lister, ok := a.work.Cache().(cache.Lister)
+		if !ok {
+			abort(ctx, CodeUnsupported, "缓存不支持查询在线连接")
+			return
+		}
+
+		var err error
+		if list, next, err = lister.List(req.Cursor, req.Count); err != nil {
+			fail(ctx, err, "获取在线连接失败")
+			return
+		}
+	}
+
+	filtered := make([]connection.Info, 0, len(list))
+	for _, info := range list {
+		if req.Node == 0 || info.WorkID == req.Node {
+			filtered = append(filtered, info)
+		}
+	}
+
+	success(ctx, gin.H{"list": filtered, "cursor": next})
+}
+
+// connection 查询连接的详细信息
+func (a *API) connection(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	conn := a.work.Find(id)
+	if conn == nil {
+		abort(ctx, CodeNotFound, "用户不在线")
+		return
+	}
+
+	success(ctx, a.describe(conn))
+}
+
+// user 根据客户端的身份查询连接的详细信息
+func (a *API) user(ctx *gin.Context) {
+	conn := a.work.Lookup(ctx.Param("identity"))
+	if conn == nil {
+		abort(ctx, CodeNotFound, "用户不在线")
+		return
+	}
+
+	success(ctx, a.describe(conn))
+}
+
+// presence 查询连接的在线状态，连接离线后返回最后一次在线的时间
+func (a *API) presence(ctx *gin.Context) {
+	var req struct {
+		ID int64 `json:"id" form:"id"`
+	}
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		abort(ctx, CodeInvalidParam, "获取参数错误")
+		return
+	}
+
+	status, err := a.work.Status(req.ID)
+	if err != nil {
+		fail(ctx, err, "获取在线状态失败")
+		return
+	}
+
+	success(ctx, status)
+}
+
+// watch 以 Server-Sent Events 推送所有节点上连接的在线状态变化
+func (a *API) watch(ctx *gin.Context) {
+	events, err := a.work.Subscribe(ctx.Request.Context())
+	if err != nil {
+		fail(ctx, err, "订阅在线状态失败")
+		return
+	}
+
+	ctx.Stream(func(w io.Writer) bool {
+		presence, ok := <-events
+		if !ok {
+			return false
+		}
+
+		ctx.SSEvent("presence", presence)
+		return true
+	})
+}
+
+// parseID 解析由雪花算法生成的连接 ID,用于排查问题
+func (a *API) parseID(ctx *gin.Context) {
+	var req struct {
+		ID int64 `json:"id" form:"id"`
+	}
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		abort(ctx, CodeInvalidParam, "获取参数错误")
+		return
+	}
+
+	if a.snowflake != nil {
+		success(ctx, a.snowflake.Parse(req.ID))
+		return
+	}
+
+	success(ctx, snowflake.Parse(req.ID))
+}
+
+// nodes 查询注册中心中的节点
+func (a *API) nodes(ctx *gin.Context) {
+	if a.registry == nil {
+		abort(ctx, CodeUnsupported, "未启用注册中心")
+		return
+	}
+
+	list, err := a.registry.Discover(ctx)
+	if err != nil {
+		fail(ctx, err, "获取节点失败")
+		return
+	}
+
+	success(ctx, list)
+}
+
+// stats 查询本节点和集群的统计信息，缓存或注册中心不支持时对应字段为空
+func (a *API) stats(ctx *gin.Context) {
+	stats := gin.H{
+		"node":   a.work.ID(),
+		"local":  a.work.Count(),
+		"uptime": time.Since(a.started).Round(time.Second).String(),
+	}
+
+	if lister, ok := a.work.Cache().(cache.Lister); ok {
+		if total, err := lister.Count(); err == nil {
+			stats["online"] = total
+		}
+	}
+
+	if a.registry != nil {
+		if list, err := a.registry.Discover(ctx); err == nil {
+			stats["nodes"] = len(list)
+		}
+	}
+
+	success(ctx, stats)
+}
+
+// send 向连接发送事件，连接位于其他节点时通过消息代理转发。连接 ID 可以在路径或请求体中
+func (a *API) send(ctx *gin.Context) {
+	var req struct {
+		ID    int64  `form:"id" json:"id"`
+		Topic string `form:"topic" json:"topic"`
+		Data  any    `form:"data" json:"data"`
+	}
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		abort(ctx, CodeInvalidParam, "获取参数错误")
+		return
+	}
+
+	if ctx.Param("id") != "" {
+		id, ok := idParam(ctx)
+		if !ok {
+			return
+		}
+
+		req.ID = id
+	}
+
+	if req.Topic == "" {
+		abort(ctx, CodeInvalidParam, "事件主题不能为空")
+		return
+	}
+
+	if err := a.work.Send(req.ID, req.Topic, req.Data); err != nil {
+		fail(ctx, err, "发送数据失败")
+		return
+	}
+
+	success(ctx, nil)
+}
+
+// request 向连接发送请求事件，在超时时间内等待客户端的响应并返回响应数据
+func (a *API) request(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Topic   string `form:"topic" json:"topic"`
+		Data    any    `form:"data" json:"data"`
+		Timeout int64  `form:"timeout" json:"timeout"` // 等待响应的毫秒数
+	}
+
+	if err := ctx.ShouldBind(&req); err != nil || req.Timeout < 0 {
+		abort(ctx, CodeInvalidParam, "获取参数错误")
+		return
+	}
+
+	if req.Topic == "" {
+		abort(ctx, CodeInvalidParam, "事件主题不能为空")
+		return
+	}
+
+	timeout := time.Duration(req.Timeout) * time.Millisecond
+	if timeout == 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	if timeout > maxRequestTimeout {
+		timeout = maxRequestTimeout
+	}
+
+	c, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+	defer cancel()
+
+	reply, err := a.work.Request(c, id, req.Topic, req.Data)
+	if err != nil {
+		fail(ctx, err, "请求客户端失败")
+		return
+	}
+
+	success(ctx, reply)
+}
+
+// kick 踢出连接，原因在关闭前发送给客户端
+func (a *API) kick(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `form:"reason" json:"reason"`
+	}
+
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBind(&req); err != nil {
+			abort(ctx, CodeInvalidParam, "获取参数错误")
+			return
+		}
+	}
+
+	if err := a.work.Evict(id, req.Reason); err != nil {
+		fail(ctx, err, "踢出连接失败")
+		return
+	}
+
+	success(ctx, nil)
+}
+
+// broadcast 向房间或所有节点上的连接广播事件
+func (a *API) broadcast(ctx *gin.Context) {
+	var req struct {
+		Room  string `form:"room" json:"room"`
+		Topic string `form:"topic" json:"topic"`
+		Data  any    `form:"data" json:"data"`
+	}
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		abort(ctx, CodeInvalidParam, "获取参数错误")
+		return
+	}
+
+	if req.Topic == "" {
+		abort(ctx, CodeInvalidParam, "事件主题不能为空")
+		return
+	}
+
+	if err := a.work.BroadcastRoom(req.Room, req.Topic, req.Data); err != nil {
+		fail(ctx, err, "广播失败")
+		return
+	}
+
+	success(ctx, nil)
+}
+
+// members 查询房间的成员
+func (a *API) members(ctx *gin.Context) {
+	members, err := a.work.Members(ctx.Param("room"))
+	if err != nil {
+		fail(ctx, err, "获取房间成员失败")
+		return
+	}
+
+	success(ctx, members)
+}
+
+// joined 查询连接加入的房间
+func (a *API) joined(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	rooms, err := a.work.Rooms(id)
+	if err != nil {
+		fail(ctx, err, "获取房间失败")
+		return
+	}
+
+	success(ctx, rooms)
+}
+
+// join 将连接加入房间
+func (a *API) join(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := a.work.Join(ctx.Param("room"), id); err != nil {
+		fail(ctx, err, "加入房间失败")
+		return
+	}
+
+	success(ctx, nil)
+}
+
+// leave 将连接移出房间
+func (a *API) leave(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := a.work.Leave(ctx.Param("room"), id); err != nil {
+		fail(ctx, err, "离开房间失败")
+		return
+	}
+
+	success(ctx, nil)
+}
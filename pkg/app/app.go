@@ -2,18 +2,30 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/cotton-go/socket/pkg/server"
 )
 
+// ErrDraining 表示应用程序正在停止，不再接受新的客户端
+var ErrDraining = errors.New("app is draining")
+
 // App 结构体表示一个应用程序，包含名称和服务器列表。
 type App struct {
-	name    string          // 应用程序的名称
-	servers []server.Server // 应用程序关联的服务器列表
+	name     string          // 应用程序的名称
+	servers  []server.Server // 应用程序关联的服务器列表
+	drain    time.Duration   // 收到终止信号后，停止服务器前保持未就绪状态的时间
+	draining int32           // 是否已经收到终止信号
+	lock     sync.Mutex      // 保护 errs
+	errs     []error         // 服务器启动失败的错误
 }
 
 // Option 类型表示一个函数，用于修改 App 结构体的属性。
@@ -29,8 +41,15 @@ func NewApp(opts ...Option) *App {
 	return a
 }
 
+// Add 向应用程序添加服务器，服务器需要在 Run 之前添加。
+// 服务器的处理函数需要引用应用程序时(例如健康检查),先创建应用程序再添加服务器。
+func (a *App) Add(servers ...server.Server) {
+	a.servers = append(a.servers, servers...)
+}
+
 // Run启动应用程序并等待终止信号。
-// 如果任何服务器启动失败或停止失败，则返回错误。
+// 服务器启动失败时记录错误，Healthy 返回该错误。
+// 收到终止信号后先将应用程序标记为未就绪，等待 drain 时间让负载均衡器不再转发新的客户端，再停止每个服务器。
 func (a *App) Run(ctx context.Context) error {
 	var cancel context.CancelFunc
 	ctx, cancel = context.WithCancel(ctx) // 创建一个可以被取消的新上下文
@@ -38,6 +57,7 @@ func (a *App) Run(ctx context.Context) error {
 
 	signals := make(chan os.Signal, 1)                      // 创建一个接收操作系统信号的通道
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM) // 通知 goroutine 应该监听哪些信号
+	defer signal.Stop(signals)
 
 	// 在单独的 goroutine中启动每个服务器
 	for _, srv := range a.servers {
@@ -45,6 +65,9 @@ func (a *App) Run(ctx context.Context) error {
 			err := srv.Start(ctx) // 启动服务器
 			if err != nil {
 				log.Printf("Server start err: %v", err) // 如果服务器启动失败，记录错误日志
+				a.lock.Lock()
+				a.errs = append(a.errs, fmt.Errorf("%T: %w", srv, err))
+				a.lock.Unlock()
 			}
 		}(srv)
 	}
@@ -59,6 +82,13 @@ func (a *App) Run(ctx context.Context) error {
 		log.Println("Context canceled")
 	}
 
+	// 先标记为未就绪，负载均衡器通过就绪检查发现后不再转发新的客户端
+	atomic.StoreInt32(&a.draining, 1)
+	if a.drain > 0 {
+		log.Printf("Draining for %s", a.drain)
+		time.Sleep(a.drain)
+	}
+
 	// 以优雅的方式停止每个服务器
 	for _, srv := range a.servers {
 		err := srv.Stop(ctx) // 停止服务器
@@ -69,3 +99,30 @@ func (a *App) Run(ctx context.Context) error {
 
 	return nil // 在成功时返回nil
 }
+
+// Healthy 返回应用程序是否健康，有服务器启动失败时返回启动失败的错误，适用于存活检查
+func (a *App) Healthy() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return errors.Join(a.errs...)
+}
+
+// Ready 返回应用程序是否可以接受新的客户端，适用于就绪检查。
+// 收到终止信号后返回 ErrDraining,有服务器启动失败或还没有开始监听时返回错误。
+func (a *App) Ready() error {
+	if atomic.LoadInt32(&a.draining) == 1 {
+		return ErrDraining
+	}
+
+	if err := a.Healthy(); err != nil {
+		return err
+	}
+
+	for _, srv := range a.servers {
+		if !srv.Ready() {
+			return fmt.Errorf("%T is not ready", srv)
+		}
+	}
+
+	return nil
+}
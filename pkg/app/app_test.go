@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeServer 结构体模拟一个服务器，err 不为空时启动失败
+type fakeServer struct {
+	err     error
+	ready   int32
+	stopped int32
+}
+
+func (s *fakeServer) Start(ctx context.Context) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	atomic.StoreInt32(&s.ready, 1)
+	return nil
+}
+
+func (s *fakeServer) Stop(ctx context.Context) error {
+	atomic.StoreInt32(&s.ready, 0)
+	atomic.StoreInt32(&s.stopped, 1)
+	return nil
+}
+
+func (s *fakeServer) Ready() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+// waitFor 等待条件成立
+func waitFor(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second * 2)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	srv := &fakeServer{}
+	a := NewApp(WithServer(srv), WithDrain(time.Millisecond*300))
+	if err := a.Ready(); err == nil {
+		t.Fatal("ready before start")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, "app is not ready", func() bool { return a.Ready() == nil })
+
+	// 收到终止信号后立即变为未就绪，服务器在 drain 时间后才停止
+	cancel()
+	waitFor(t, "app is still ready", func() bool { return errors.Is(a.Ready(), ErrDraining) })
+	if atomic.LoadInt32(&srv.stopped) == 1 {
+		t.Fatal("server stopped before drain")
+	}
+
+	<-done
+	if atomic.LoadInt32(&srv.stopped) != 1 {
+		t.Fatal("server not stopped")
+	}
+
+	if err := a.Healthy(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartError(t *testing.T) {
+	failed := &fakeServer{err: errors.New("address already in use")}
+	a := NewApp()
+	a.Add(&fakeServer{}, failed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, "start error not recorded", func() bool { return a.Healthy() != nil })
+	if err := a.Ready(); !errors.Is(err, failed.err) {
+		t.Fatalf("unexpected ready %v", err)
+	}
+
+	cancel()
+	<-done
+}
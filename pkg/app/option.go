@@ -1,6 +1,10 @@
 package app
 
-import "github.com/cotton-go/socket/pkg/server"
+import (
+	"time"
+
+	"github.com/cotton-go/socket/pkg/server"
+)
 
 // WithServer 根据传入的服务器列表设置 App 实例的服务器属性。
 func WithServer(servers ...server.Server) Option {
@@ -15,3 +19,11 @@ func WithName(name string) Option {
 		a.name = name
 	}
 }
+
+// WithDrain 设置收到终止信号后，停止服务器前保持未就绪状态的时间。
+// 该时间应大于负载均衡器就绪检查的间隔，使其在服务器停止前不再转发新的客户端。
+func WithDrain(drain time.Duration) Option {
+	return func(a *App) {
+		a.drain = drain
+	}
+}
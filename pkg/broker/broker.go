@@ -0,0 +1,46 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrClosed 表示消息代理已关闭
+	ErrClosed = errors.New("broker closed")
+
+	// ErrNoSubscriber 表示指定节点的通道没有订阅者，节点可能已经宕机，消息没有被任何节点接收
+	ErrNoSubscriber = errors.New("no subscriber")
+)
+
+// Message 表示在节点之间转发的消息
+type Message struct {
+	To      int64         `json:"to"`                // 接收消息的连接 ID,为 0 时发送给节点上的所有连接
+	Targets []int64       `json:"targets,omitempty"` // 接收消息的多个连接 ID,To 为 0 时只发送给节点上的这些连接
+	Room    string        `json:"room,omitempty"`    // 房间名称，To 为 0 时只发送给节点上的房间成员
+	Kick    bool          `json:"kick,omitempty"`    // 为 true 时关闭接收消息的连接，不发送事件
+	Topic   string        `json:"topic"`             // 事件主题
+	Data    any           `json:"data"`              // 事件数据，响应消息中为客户端的响应数据
+	From    int64         `json:"from,omitempty"`    // 发送请求的节点，响应消息发布到该节点
+	Request uint64        `json:"request,omitempty"` // 请求序号，不为 0 时向连接发送请求事件并将客户端的响应发回 From 节点
+	Reply   uint64        `json:"reply,omitempty"`   // 响应的请求序号，不为 0 时表示该消息是请求的响应
+	Timeout time.Duration `json:"timeout,omitempty"` // 等待客户端响应的超时时间
+	Error   string        `json:"error,omitempty"`   // 响应消息中请求失败的原因
+}
+
+// Broker 接口定义了节点之间转发消息的方法，每个节点订阅以自己的 ID 命名的通道
+type Broker interface {
+	// Publish 将消息发布到指定节点的通道，通道没有订阅者时返回 ErrNoSubscriber
+	Publish(ctx context.Context, node int64, msg Message) error
+
+	// Subscribe 订阅指定节点的通道，收到消息时调用 fn,直到上下文被取消
+	Subscribe(ctx context.Context, node int64, fn func(Message)) error
+}
+
+// Requester 是消息代理的可选接口，直接调用连接所在的节点向连接发送请求并等待客户端的响应。
+// 没有实现该接口时，Worker 通过 Message 的 Request 和 Reply 字段在节点之间关联请求和响应
+type Requester interface {
+	// Request 向指定节点上的连接 msg.To 发送请求事件，返回客户端的响应数据，等待时间由上下文决定
+	Request(ctx context.Context, node int64, msg Message) (any, error)
+}
@@ -0,0 +1,58 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNoSubscriber(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	defer client.Close()
+
+	brokers := map[string]Broker{"memory": NewMemory()}
+	if err := client.Ping(context.Background()).Err(); err == nil {
+		brokers["redis"] = NewRedis(client, "socket:test:node:")
+	}
+
+	for name, b := range brokers {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// 节点没有订阅时发布失败，而不是当作已经送达
+			if err := b.Publish(ctx, 1, Message{To: 1, Topic: "news"}); !errors.Is(err, ErrNoSubscriber) {
+				t.Fatalf("expected ErrNoSubscriber, got %v", err)
+			}
+
+			received := make(chan Message, 1)
+			go b.Subscribe(ctx, 1, func(msg Message) { received <- msg })
+
+			deadline := time.Now().Add(time.Second * 2)
+			for {
+				err := b.Publish(ctx, 1, Message{To: 1, Topic: "news"})
+				if err == nil {
+					break
+				}
+
+				if !errors.Is(err, ErrNoSubscriber) || time.Now().After(deadline) {
+					t.Fatal(err)
+				}
+
+				time.Sleep(time.Millisecond * 10)
+			}
+
+			select {
+			case msg := <-received:
+				if msg.Topic != "news" {
+					t.Fatalf("unexpected message %+v", msg)
+				}
+			case <-time.After(time.Second * 2):
+				t.Fatal("message is not received")
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package broker
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory 结构体，在同一个进程内的节点之间转发消息，用于测试和单机部署
+type Memory struct {
+	lock  sync.RWMutex
+	nodes map[int64][]chan Message // 节点 ID 与订阅通道的映射
+}
+
+// NewMemory 创建一个进程内的消息代理
+func NewMemory() Broker {
+	return &Memory{nodes: make(map[int64][]chan Message)}
+}
+
+// Publish 将消息发送给指定节点的所有订阅者
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node int64 节点 ID
+//   - msg Message 消息
+//
+// 返回值：
+//   - error 上下文被取消时返回错误信息，节点没有订阅者时返回 ErrNoSubscriber
+func (m *Memory) Publish(ctx context.Context, node int64, msg Message) error {
+	m.lock.RLock()
+	subs := append([]chan Message(nil), m.nodes[node]...)
+	m.lock.RUnlock()
+
+	if len(subs) == 0 {
+		return ErrNoSubscriber
+	}
+
+	for _, ch := range subs {
+		select {
+		case ch <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Subscribe 订阅指定节点的消息，直到上下文被取消
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node int64 节点 ID
+//   - fn func(Message) 收到消息时调用的函数
+//
+// 返回值：
+//   - error 上下文被取消时返回 nil
+func (m *Memory) Subscribe(ctx context.Context, node int64, fn func(Message)) error {
+	ch := make(chan Message, 100)
+	m.lock.Lock()
+	m.nodes[node] = append(m.nodes[node], ch)
+	m.lock.Unlock()
+
+	defer func() {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		subs := m.nodes[node]
+		for i, sub := range subs {
+			if sub == ch {
+				m.nodes[node] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-ch:
+			fn(msg)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/bytedance/sonic"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 结构体，使用 Redis 发布订阅在节点之间转发消息
+type Redis struct {
+	store  *redis.Client // Redis 客户端
+	prefix string        // 通道名称前缀
+}
+
+// NewRedis 创建一个使用 Redis 发布订阅的消息代理
+//
+// 参数：
+//   - store *redis.Client Redis 客户端
+//   - prefix string 通道名称前缀，为空时使用 "socket:node:"
+//
+// 返回值：
+//   - Broker 消息代理
+func NewRedis(store *redis.Client, prefix string) Broker {
+	if prefix == "" {
+		prefix = "socket:node:"
+	}
+
+	return &Redis{store: store, prefix: prefix}
+}
+
+// channel 返回指定节点的通道名称
+func (r *Redis) channel(node int64) string {
+	return r.prefix + strconv.FormatInt(node, 10)
+}
+
+// Publish 将消息序列化后发布到指定节点的通道
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node int64 节点 ID
+//   - msg Message 消息
+//
+// 返回值：
+//   - error 返回错误信息，没有节点订阅该通道时返回 ErrNoSubscriber
+func (r *Redis) Publish(ctx context.Context, node int64, msg Message) error {
+	b, err := sonic.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	// PUBLISH 返回收到消息的订阅者数量，为 0 时说明节点没有在线
+	n, err := r.store.Publish(ctx, r.channel(node), b).Result()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNoSubscriber
+	}
+
+	return nil
+}
+
+// Subscribe 订阅指定节点的通道，直到上下文被取消
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node int64 节点 ID
+//   - fn func(Message) 收到消息时调用的函数
+//
+// 返回值：
+//   - error 订阅失败时返回错误信息，上下文被取消时返回 nil
+func (r *Redis) Subscribe(ctx context.Context, node int64, fn func(Message)) error {
+	sub := r.store.Subscribe(ctx, r.channel(node))
+	defer sub.Close()
+
+	// 等待订阅确认，确保返回前已经开始接收消息
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case payload, ok := <-ch:
+			if !ok {
+				return ErrClosed
+			}
+
+			var msg Message
+			if err := sonic.UnmarshalString(payload.Payload, &msg); err != nil {
+				fmt.Println("broker message error", err)
+				continue
+			}
+
+			fn(msg)
+		}
+	}
+}
@@ -1,15 +1,86 @@
 package cache
 
-import "github.com/cotton-go/socket/pkg/connection"
+import (
+	"context"
+	"time"
+
+	"github.com/cotton-go/socket/pkg/connection"
+)
 
 // ICache 是一个接口，定义了在线、离线和查找操作的方法
 type ICache interface {
 	// Online 方法接受一个连接对象作为参数，返回一个错误信息
-	Online(conn *connection.Connection) error
+	Online(conn connection.Peer) error
 
 	// Offline 方法接受一个连接对象作为参数，返回一个错误信息
-	Offline(conn *connection.Connection) error
+	Offline(conn connection.Peer) error
+
+	// Find 方法接受一个整型 id 作为参数，返回一个连接对象，连接不在线时返回 nil
+	Find(id int64) connection.Peer
+}
+
+// Lease 是缓存的可选接口，在线状态与节点的租约绑定，节点的租约过期后其连接不再视为在线
+type Lease interface {
+	// Renew 续约节点的租约，并清理租约已过期的节点的连接，返回续约前租约是否有效
+	Renew(node int64, ttl time.Duration) (bool, error)
+
+	// Reset 清除节点的所有连接和租约，节点重启时调用以清理上次运行遗留的连接
+	Reset(node int64) error
+}
+
+// Lister 是缓存的可选接口，用于统计和分页查询所有节点上的在线连接
+type Lister interface {
+	// Count 返回在线连接数
+	Count() (int64, error)
+
+	// List 从游标位置开始返回最多 count 个在线连接，返回的游标为 0 时表示已经没有更多连接
+	List(cursor uint64, count int64) ([]connection.Info, uint64, error)
+}
+
+// Locator 是缓存的可选接口，用于一次查询多个连接所在的节点，批量发送时减少访问缓存的次数
+type Locator interface {
+	// Locate 返回在线连接的 ID 到所在节点工作 ID 的映射，不在线的连接不包含在结果中
+	Locate(ids []int64) (map[int64]int64, error)
+}
+
+// Presence 结构体表示连接的在线状态，在连接上线、离线时发布
+type Presence struct {
+	ID       int64     `json:"id"`       // 连接的 ID
+	WorkID   int64     `json:"workID"`   // 连接所在节点的工作 ID
+	Online   bool      `json:"online"`   // 连接是否在线
+	LastSeen time.Time `json:"lastSeen"` // 最后一次在线的时间，连接在线时为状态变化的时间，从未上线时为零值
+}
+
+// Notifier 是缓存的可选接口，在连接上线、离线时发布在线状态，并记录连接最后一次在线的时间
+type Notifier interface {
+	// Subscribe 订阅所有节点上连接的在线状态变化，上下文被取消时关闭返回的通道
+	Subscribe(ctx context.Context) (<-chan Presence, error)
+
+	// Status 返回连接的在线状态，连接离线后返回最后一次在线的时间
+	Status(id int64) (Presence, error)
+}
+
+// Rooms 是缓存的可选接口，房间成员保存在缓存中，在所有节点之间共享。
+// 连接离线或所在节点的租约被清理时，连接会离开所有房间。
+type Rooms interface {
+	// Join 将连接加入房间
+	Join(room string, conn connection.Peer) error
+
+	// Leave 将连接移出房间
+	Leave(room string, conn connection.Peer) error
+
+	// Members 返回房间中所有节点上的成员
+	Members(room string) ([]connection.Info, error)
+
+	// Joined 返回连接加入的所有房间
+	Joined(id int64) ([]string, error)
+}
+
+// Store 是缓存的可选接口，用于在所有节点之间共享带有过期时间的数据，例如管理接口的异步任务
+type Store interface {
+	// Put 保存数据，ttl 后过期
+	Put(key string, value []byte, ttl time.Duration) error
 
-	// Find 方法接受一个整型 id 作为参数，返回一个连接对象指针
-	Find(id int64) *connection.Connection
+	// Get 返回数据，不存在或已经过期时返回 nil
+	Get(key string) ([]byte, error)
 }
@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/cotton-go/socket/pkg/connection"
+)
+
+func TestMemoryList(t *testing.T) {
+	m := NewMemory().(*Memory)
+	for id := int64(1); id <= 5; id++ {
+		m.Online(connection.NewFake(id, 1))
+	}
+
+	if count, _ := m.Count(); count != 5 {
+		t.Fatalf("unexpected count %d", count)
+	}
+
+	var (
+		ids    []int64
+		cursor uint64
+	)
+
+	for {
+		list, next, err := m.List(cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, info := range list {
+			ids = append(ids, info.ID)
+		}
+
+		if next == 0 {
+			break
+		}
+
+		cursor = next
+	}
+
+	if len(ids) != 5 || ids[0] != 1 || ids[4] != 5 {
+		t.Fatalf("unexpected ids %v", ids)
+	}
+}
+
+func TestRedisLease(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	defer client.Close()
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skip("redis is not available:", err)
+	}
+
+	c := NewRedis(client).(*Redis)
+	c.prefix = "socket:test:presence:"
+	const alive, crashed = 9001, 9002
+	defer c.Reset(alive)
+	defer c.Reset(crashed)
+
+	if ok, err := c.Renew(crashed, time.Millisecond*200); err != nil || ok {
+		t.Fatalf("unexpected renew %v %v", ok, err)
+	}
+
+	c.Online(connection.NewFake(1, crashed))
+	c.Join("lobby", connection.NewFake(1, crashed))
+	if c.Find(1) == nil {
+		t.Fatal("connection is not online")
+	}
+
+	// 节点宕机后租约过期，连接不再在线，并在其他节点续约时被清理
+	time.Sleep(time.Millisecond * 300)
+	if c.Find(1) != nil {
+		t.Fatal("connection of crashed node is still online")
+	}
+
+	if _, err := c.Renew(alive, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, _ := client.SCard(context.Background(), c.nodeKey(crashed)).Result(); n != 0 {
+		t.Fatalf("connections of crashed node are not purged: %d", n)
+	}
+
+	if rooms, _ := c.Joined(1); len(rooms) != 0 {
+		t.Fatalf("rooms of crashed node are not purged: %v", rooms)
+	}
+
+	if members, _ := c.Members("lobby"); len(members) != 0 {
+		t.Fatalf("members of crashed node are not purged: %v", members)
+	}
+
+	if ok, err := c.Renew(alive, time.Second); err != nil || !ok {
+		t.Fatalf("unexpected renew %v %v", ok, err)
+	}
+
+	c.Online(connection.NewFake(2, alive))
+	list, _, err := c.List(0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, info := range list {
+		found = found || info.ID == 2
+	}
+
+	if !found {
+		t.Fatalf("connection is not listed: %+v", list)
+	}
+
+	c.Offline(connection.NewFake(2, alive))
+}
+
+func TestRedisMoved(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	defer client.Close()
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skip("redis is not available:", err)
+	}
+
+	c := NewRedis(client).(*Redis)
+	c.prefix = "socket:test:moved:"
+	const alive, crashed = 9011, 9012
+	defer c.Reset(alive)
+	defer c.Reset(crashed)
+
+	c.Renew(crashed, time.Millisecond*200)
+	c.Renew(alive, time.Second)
+	c.Online(connection.NewFake(1, crashed))
+	c.Join("lobby", connection.NewFake(1, crashed))
+
+	// 节点宕机后客户端在租约过期前使用相同的 ID 连接到其他节点
+	c.Online(connection.NewFake(1, alive))
+	c.Join("lobby", connection.NewFake(1, alive))
+	if n, _ := client.SCard(context.Background(), c.nodeKey(crashed)).Result(); n != 0 {
+		t.Fatalf("moved connection is still in the crashed node: %d", n)
+	}
+
+	// 旧节点的连接集合中仍然残留该连接时，清理也不会删除新的连接
+	client.SAdd(context.Background(), c.nodeKey(crashed), "1")
+	time.Sleep(time.Millisecond * 300)
+	if _, err := c.Renew(alive, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn := c.Find(1); conn == nil || conn.Info().WorkID != alive {
+		t.Fatalf("moved connection is purged: %v", conn)
+	}
+
+	if members, _ := c.Members("lobby"); len(members) != 1 || members[0].WorkID != alive {
+		t.Fatalf("moved connection is removed from rooms: %v", members)
+	}
+
+	if presence, _ := c.Status(1); !presence.Online {
+		t.Fatalf("moved connection is reported offline: %+v", presence)
+	}
+
+	// 旧节点上的连接下线时不影响新的连接
+	c.Offline(connection.NewFake(1, crashed))
+	if conn := c.Find(1); conn == nil || conn.Info().WorkID != alive {
+		t.Fatalf("moved connection is removed by the old node: %v", conn)
+	}
+
+	c.Offline(connection.NewFake(1, alive))
+	if rooms, _ := c.Joined(1); len(rooms) != 0 {
+		t.Fatalf("rooms are not left: %v", rooms)
+	}
+}
+
+func TestMemoryPresence(t *testing.T) {
+	m := NewMemory().(*Memory)
+	ctx, cancel := context.WithCancel(context.Background())
+	events, _ := m.Subscribe(ctx)
+
+	conn := connection.NewFake(1, 2)
+	m.Online(conn)
+	if p := <-events; !p.Online || p.ID != 1 || p.WorkID != 2 {
+		t.Fatalf("unexpected presence %+v", p)
+	}
+
+	m.Offline(conn)
+	offline := <-events
+	if offline.Online || offline.LastSeen.IsZero() {
+		t.Fatalf("unexpected presence %+v", offline)
+	}
+
+	// 连接离线后仍然可以查询最后一次在线的时间
+	if p, _ := m.Status(1); p.Online || !p.LastSeen.Equal(offline.LastSeen) {
+		t.Fatalf("unexpected status %+v", p)
+	}
+
+	if p, _ := m.Status(2); p.Online || !p.LastSeen.IsZero() {
+		t.Fatalf("unexpected status %+v", p)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestStore(t *testing.T) {
+	stores := map[string]Store{"memory": NewMemory().(Store)}
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	defer client.Close()
+
+	if err := client.Ping(context.Background()).Err(); err == nil {
+		c := NewRedis(client).(*Redis)
+		c.prefix = "socket:test:store:"
+		stores["redis"] = c
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			if err := store.Put("job", []byte("value"), time.Millisecond*100); err != nil {
+				t.Fatal(err)
+			}
+
+			if value, err := store.Get("job"); err != nil || string(value) != "value" {
+				t.Fatalf("unexpected value %q %v", value, err)
+			}
+
+			time.Sleep(time.Millisecond * 150)
+			if value, err := store.Get("job"); err != nil || value != nil {
+				t.Fatalf("expired value returned %q %v", value, err)
+			}
+		})
+	}
+}
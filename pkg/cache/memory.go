@@ -1,44 +1,288 @@
 package cache
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/metrics"
 )
 
 // Memory 是一个结构体，包含一个读写锁和一个存储连接的 map
 type Memory struct {
-	lock  sync.RWMutex                     // 读写锁
-	store map[int64]*connection.Connection // 存储连接的 map
+	lock        sync.RWMutex                         // 读写锁
+	store       map[int64]connection.Peer            // 存储连接的 map
+	seen        map[int64]Presence                   // 存储离线连接最后一次在线状态的 map
+	rooms       map[string]map[int64]connection.Info // 房间名称与成员的映射
+	joined      map[int64]map[string]struct{}        // 连接 ID 与加入的房间的映射
+	subscribers map[chan Presence]struct{}           // 在线状态的订阅者
+	values      map[string]stored                    // Store 保存的数据
+}
+
+// stored 结构体表示 Store 保存的数据及其过期时间
+type stored struct {
+	value  []byte
+	expire time.Time
 }
 
 // NewMemory 函数返回一个新的 Memory 实例
 func NewMemory() ICache {
 	return &Memory{
-		store: make(map[int64]*connection.Connection), // 初始化存储连接的 map
+		store:       make(map[int64]connection.Peer), // 初始化存储连接的 map
+		seen:        make(map[int64]Presence),
+		rooms:       make(map[string]map[int64]connection.Info),
+		joined:      make(map[int64]map[string]struct{}),
+		subscribers: make(map[chan Presence]struct{}),
+		values:      make(map[string]stored),
 	}
 }
 
-// Online 方法接受一个连接对象作为参数，将其添加到存储连接的 map 中，并返回 nil
-func (m *Memory) Online(conn *connection.Connection) error {
-	m.lock.Lock()           // 加锁
-	defer m.lock.Unlock()   // 解锁
-	m.store[conn.ID] = conn // 将连接对象添加到存储连接的 map 中
+// Online 方法接受一个连接对象作为参数，将其添加到存储连接的 map 中，发布上线状态，并返回 nil
+func (m *Memory) Online(conn connection.Peer) error {
+	defer metrics.ObserveCache("memory", "online", time.Now())
+	m.lock.Lock()             // 加锁
+	defer m.lock.Unlock()     // 解锁
+	m.store[conn.ID()] = conn // 将连接对象添加到存储连接的 map 中
+	delete(m.seen, conn.ID())
+	m.publish(Presence{ID: conn.ID(), WorkID: conn.Info().WorkID, Online: true, LastSeen: time.Now()})
 	return nil
 }
 
-// Offline 方法接受一个连接对象作为参数，从存储连接的 map 中删除该连接对象，并返回 nil
-func (m *Memory) Offline(conn *connection.Connection) error {
+// Offline 方法接受一个连接对象作为参数，从存储连接的 map 中删除该连接对象，记录并发布离线状态，并返回 nil。
+// 相同 ID 的连接已经在其他节点上重新上线时不做处理。
+func (m *Memory) Offline(conn connection.Peer) error {
+	defer metrics.ObserveCache("memory", "offline", time.Now())
 	m.lock.Lock()         // 加锁
 	defer m.lock.Unlock() // 解锁
 
-	delete(m.store, conn.ID) // 从存储连接的 map 中删除该连接对象
+	if cur, ok := m.store[conn.ID()]; ok && cur.Info().WorkID != conn.Info().WorkID {
+		return nil
+	}
+
+	delete(m.store, conn.ID()) // 从存储连接的 map 中删除该连接对象
+	for room := range m.joined[conn.ID()] {
+		m.leave(room, conn.ID())
+	}
+
+	presence := Presence{ID: conn.ID(), WorkID: conn.Info().WorkID, LastSeen: time.Now()}
+	m.seen[conn.ID()] = presence
+	m.publish(presence)
 	return nil
 }
 
-// Find 方法接受一个整型 id 作为参数，从存储连接的 map 中查找对应的连接对象，并返回该连接对象指针
-func (m *Memory) Find(id int64) *connection.Connection {
+// Subscribe 方法订阅连接的在线状态变化，上下文被取消时关闭返回的通道。
+// 订阅者处理不及时时，新的状态会被丢弃。
+func (m *Memory) Subscribe(ctx context.Context) (<-chan Presence, error) {
+	defer metrics.ObserveCache("memory", "subscribe", time.Now())
+	ch := make(chan Presence, 64)
+	m.lock.Lock()
+	m.subscribers[ch] = struct{}{}
+	m.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.lock.Lock()
+		delete(m.subscribers, ch)
+		close(ch)
+		m.lock.Unlock()
+	}()
+
+	return ch, nil
+}
+
+// Status 方法返回连接的在线状态，连接离线后返回最后一次在线的时间
+func (m *Memory) Status(id int64) (Presence, error) {
+	defer metrics.ObserveCache("memory", "status", time.Now())
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if conn, ok := m.store[id]; ok {
+		return Presence{ID: id, WorkID: conn.Info().WorkID, Online: true, LastSeen: time.Now()}, nil
+	}
+
+	if presence, ok := m.seen[id]; ok {
+		return presence, nil
+	}
+
+	return Presence{ID: id}, nil
+}
+
+// Join 方法将连接加入房间
+func (m *Memory) Join(room string, conn connection.Peer) error {
+	defer metrics.ObserveCache("memory", "join", time.Now())
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.rooms[room] == nil {
+		m.rooms[room] = make(map[int64]connection.Info)
+	}
+
+	if m.joined[conn.ID()] == nil {
+		m.joined[conn.ID()] = make(map[string]struct{})
+	}
+
+	m.rooms[room][conn.ID()] = conn.Info()
+	m.joined[conn.ID()][room] = struct{}{}
+	return nil
+}
+
+// Leave 方法将连接移出房间
+func (m *Memory) Leave(room string, conn connection.Peer) error {
+	defer metrics.ObserveCache("memory", "leave", time.Now())
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.leave(room, conn.ID())
+	return nil
+}
+
+// Members 方法返回房间中的成员，按连接 ID 排序
+func (m *Memory) Members(room string) ([]connection.Info, error) {
+	defer metrics.ObserveCache("memory", "members", time.Now())
+	m.lock.RLock()
+	members := make([]connection.Info, 0, len(m.rooms[room]))
+	for _, info := range m.rooms[room] {
+		members = append(members, info)
+	}
+	m.lock.RUnlock()
+
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members, nil
+}
+
+// Joined 方法返回连接加入的所有房间，按房间名称排序
+func (m *Memory) Joined(id int64) ([]string, error) {
+	defer metrics.ObserveCache("memory", "joined", time.Now())
+	m.lock.RLock()
+	rooms := make([]string, 0, len(m.joined[id]))
+	for room := range m.joined[id] {
+		rooms = append(rooms, room)
+	}
+	m.lock.RUnlock()
+
+	sort.Strings(rooms)
+	return rooms, nil
+}
+
+// leave 方法将连接移出房间，房间或连接没有成员时删除对应的 map,调用时需要持有写锁
+func (m *Memory) leave(room string, id int64) {
+	delete(m.rooms[room], id)
+	if len(m.rooms[room]) == 0 {
+		delete(m.rooms, room)
+	}
+
+	delete(m.joined[id], room)
+	if len(m.joined[id]) == 0 {
+		delete(m.joined, id)
+	}
+}
+
+// publish 方法将在线状态发送给所有订阅者，调用时需要持有写锁
+func (m *Memory) publish(presence Presence) {
+	for ch := range m.subscribers {
+		select {
+		case ch <- presence:
+		default:
+			fmt.Println("memory presence dropped", presence.ID)
+		}
+	}
+}
+
+// Find 方法接受一个整型 id 作为参数，从存储连接的 map 中查找对应的连接对象，并返回该连接对象
+func (m *Memory) Find(id int64) connection.Peer {
+	defer metrics.ObserveCache("memory", "find", time.Now())
 	m.lock.RLock()         // 加读锁
 	defer m.lock.RUnlock() // 解锁
-	return m.store[id]     // 返回存储连接的 map 中对应 id 的连接对象指针
+	return m.store[id]     // 返回存储连接的 map 中对应 id 的连接对象
+}
+
+// Locate 方法返回在线连接的 ID 到所在节点工作 ID 的映射
+func (m *Memory) Locate(ids []int64) (map[int64]int64, error) {
+	defer metrics.ObserveCache("memory", "locate", time.Now())
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	nodes := make(map[int64]int64, len(ids))
+	for _, id := range ids {
+		if conn, ok := m.store[id]; ok {
+			nodes[id] = conn.Info().WorkID
+		}
+	}
+
+	return nodes, nil
+}
+
+// Count 方法返回在线连接数
+func (m *Memory) Count() (int64, error) {
+	defer metrics.ObserveCache("memory", "count", time.Now())
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return int64(len(m.store)), nil
+}
+
+// List 方法按连接 ID 排序，从游标位置开始返回最多 count 个在线连接，返回的游标为 0 时表示已经没有更多连接
+func (m *Memory) List(cursor uint64, count int64) ([]connection.Info, uint64, error) {
+	defer metrics.ObserveCache("memory", "list", time.Now())
+	m.lock.RLock()
+	ids := make([]int64, 0, len(m.store))
+	for id := range m.store {
+		ids = append(ids, id)
+	}
+	m.lock.RUnlock()
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if cursor >= uint64(len(ids)) {
+		return []connection.Info{}, 0, nil
+	}
+
+	end := cursor + uint64(count)
+	if count <= 0 || end > uint64(len(ids)) {
+		end = uint64(len(ids))
+	}
+
+	list := make([]connection.Info, 0, end-cursor)
+	for _, id := range ids[cursor:end] {
+		if conn := m.Find(id); conn != nil {
+			list = append(list, conn.Info())
+		}
+	}
+
+	if end == uint64(len(ids)) {
+		end = 0
+	}
+
+	return list, end, nil
+}
+
+// Put 方法保存数据，ttl 后过期，同时清理已经过期的数据
+func (m *Memory) Put(key string, value []byte, ttl time.Duration) error {
+	defer metrics.ObserveCache("memory", "put", time.Now())
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	for k, v := range m.values {
+		if now.After(v.expire) {
+			delete(m.values, k)
+		}
+	}
+
+	m.values[key] = stored{value: value, expire: now.Add(ttl)}
+	return nil
+}
+
+// Get 方法返回数据，不存在或已经过期时返回 nil
+func (m *Memory) Get(key string) ([]byte, error) {
+	defer metrics.ObserveCache("memory", "get", time.Now())
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	v, ok := m.values[key]
+	if !ok || time.Now().After(v.expire) {
+		return nil, nil
+	}
+
+	return v.value, nil
 }
@@ -3,39 +3,315 @@ package cache
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/metrics"
 )
 
-// Redis 结构体，包含一个上下文和一个 Redis 客户端
+// ownerLua 定义从连接信息的 JSON 中取出所在节点工作 ID 的 Lua 函数，按字符串比较，避免大整数丢失精度
+const ownerLua = `
+local function owner(value)
+	return string.match(value, '"WorkID":(%-?%d+)')
+end
+`
+
+// offlineLua 定义下线连接的 Lua 函数，从节点的集合中删除连接 ID,连接仍然属于该节点时删除连接、
+// 将连接移出仍然属于该节点的房间成员，并记录和发布离线状态，返回是否下线。
+// ARGV[1] 为键名前缀，ARGV[2] 为节点的工作 ID,ARGV[3] 为离线时间。
+const offlineLua = ownerLua + `
+local prefix, node, seen = ARGV[1], ARGV[2], ARGV[3]
+local function offline(field)
+	redis.call('SREM', prefix .. 'node:' .. node, field)
+	local value = redis.call('HGET', KEYS[1], field)
+	if value and owner(value) ~= node then
+		return 0
+	end
+
+	redis.call('HDEL', KEYS[1], field)
+	local joined = prefix .. 'joined:' .. field
+	for _, room in ipairs(redis.call('SMEMBERS', joined)) do
+		local member = redis.call('HGET', prefix .. 'room:' .. room, field)
+		if not member or owner(member) == node then
+			redis.call('HDEL', prefix .. 'room:' .. room, field)
+			redis.call('SREM', joined, room)
+		end
+	end
+
+	local presence = '{"id":' .. field .. ',"workID":' .. node .. ',"online":false,"lastSeen":"' .. seen .. '"}'
+	redis.call('HSET', prefix .. 'seen', field, presence)
+	redis.call('PUBLISH', prefix .. 'events', presence)
+	return 1
+end
+`
+
+// onlineScript 保存连接信息并加入节点的集合，连接之前属于其他节点时从该节点的集合中删除，
+// 避免旧节点的租约过期后清理新的连接。ARGV[1] 为键名前缀，ARGV[2] 为连接 ID,ARGV[3] 为节点的工作 ID,
+// ARGV[4] 为连接信息，ARGV[5] 为在线状态。
+var onlineScript = redis.NewScript(ownerLua + `
+local prefix, field, node = ARGV[1], ARGV[2], ARGV[3]
+local old = redis.call('HGET', KEYS[1], field)
+if old then
+	local prev = owner(old)
+	if prev and prev ~= node then
+		redis.call('SREM', prefix .. 'node:' .. prev, field)
+	end
+end
+
+redis.call('HSET', KEYS[1], field, ARGV[4])
+redis.call('SADD', prefix .. 'node:' .. node, field)
+redis.call('HDEL', prefix .. 'seen', field)
+redis.call('PUBLISH', prefix .. 'events', ARGV[5])
+return 1
+`)
+
+// offlineScript 下线一个连接，ARGV[4] 为连接 ID
+var offlineScript = redis.NewScript(offlineLua + `
+return offline(ARGV[4])
+`)
+
+// resetScript 下线节点集合中仍然属于该节点的连接，并删除节点的集合和租约，返回下线的连接数
+var resetScript = redis.NewScript(offlineLua + `
+local count = 0
+for _, field in ipairs(redis.call('SMEMBERS', prefix .. 'node:' .. node)) do
+	count = count + offline(field)
+end
+
+redis.call('DEL', prefix .. 'node:' .. node, prefix .. 'lease:' .. node)
+redis.call('SREM', prefix .. 'nodes', node)
+return count
+`)
+
+// Redis 结构体，包含一个上下文和一个 Redis 客户端。
+// 所有连接保存在 "connections" 哈希表中，每个节点的连接 ID 另外保存在节点的集合中，
+// 节点的租约过期后，其连接不再视为在线，并在其他节点续约时被清理。
 type Redis struct {
-	ctx   context.Context
-	store *redis.Client
+	ctx    context.Context
+	store  *redis.Client
+	prefix string // 节点租约和连接集合的键名前缀
 }
 
 // NewRedis 函数用于创建一个新的 Redis 实例
 func NewRedis(store *redis.Client) ICache {
 	return &Redis{
-		ctx:   context.Background(),
-		store: store,
+		ctx:    context.Background(),
+		store:  store,
+		prefix: "socket:presence:",
 	}
 }
 
-// Online 方法将连接对象存储到 Redis 中，并返回错误信息
-func (c Redis) Online(conn *connection.Connection) error {
-	field, key := c.makeKey(conn.ID)
-	value, _ := sonic.Marshal(conn)
-	return c.store.HSet(c.ctx, key, field, string(value)).Err()
+// Online 方法将连接对象存储到 Redis 中，发布上线状态，并返回错误信息。
+// 相同 ID 的连接之前在其他节点上时，同时从该节点的集合中删除，该节点的租约过期后不会清理新的连接。
+func (c Redis) Online(conn connection.Peer) error {
+	defer metrics.ObserveCache("redis", "online", time.Now())
+	info := conn.Info()
+	field, key := c.makeKey(info.ID)
+	value, _ := sonic.Marshal(info)
+	presence, _ := sonic.Marshal(Presence{ID: info.ID, WorkID: info.WorkID, Online: true, LastSeen: time.Now()})
+	args := []any{c.prefix, field, strconv.FormatInt(info.WorkID, 10), string(value), string(presence)}
+	return onlineScript.Run(c.ctx, c.store, []string{key}, args...).Err()
+}
+
+// Offline 方法从 Redis 中删除指定的连接对象，将其移出所有房间，记录并发布离线状态，并返回错误信息。
+// 相同 ID 的连接已经在其他节点上重新上线时，只从本节点的集合中删除。
+// 检查和删除在同一个 Lua 脚本中原子地执行，不受其他连接并发上下线的影响。
+func (c Redis) Offline(conn connection.Peer) error {
+	defer metrics.ObserveCache("redis", "offline", time.Now())
+	info := conn.Info()
+	field, key := c.makeKey(info.ID)
+	args := []any{c.prefix, strconv.FormatInt(info.WorkID, 10), time.Now().Format(time.RFC3339Nano), field}
+	return offlineScript.Run(c.ctx, c.store, []string{key}, args...).Err()
 }
 
-// Offline 方法从 Redis 中删除指定的连接对象，并返回错误信息
-func (c Redis) Offline(conn *connection.Connection) error {
-	field, key := c.makeKey(conn.ID)
-	return c.store.HDel(c.ctx, key, field).Err()
+// Join 方法将连接加入房间，房间成员保存在以房间名称命名的哈希表中。
+//
+// 参数：
+// room string:房间名称。
+// conn connection.Peer:连接对象。
+//
+// 返回值：
+// error:返回错误信息。
+func (c Redis) Join(room string, conn connection.Peer) error {
+	defer metrics.ObserveCache("redis", "join", time.Now())
+	info := conn.Info()
+	field, _ := c.makeKey(info.ID)
+	value, _ := sonic.Marshal(info)
+	_, err := c.store.TxPipelined(c.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(c.ctx, c.roomKey(room), field, string(value))
+		pipe.SAdd(c.ctx, c.joinedKey(field), room)
+		return nil
+	})
+
+	return err
+}
+
+// Leave 方法将连接移出房间。
+//
+// 参数：
+// room string:房间名称。
+// conn connection.Peer:连接对象。
+//
+// 返回值：
+// error:返回错误信息。
+func (c Redis) Leave(room string, conn connection.Peer) error {
+	defer metrics.ObserveCache("redis", "leave", time.Now())
+	field, _ := c.makeKey(conn.ID())
+	_, err := c.store.TxPipelined(c.ctx, func(pipe redis.Pipeliner) error {
+		c.leaveAll(pipe, map[string][]string{field: {room}})
+		return nil
+	})
+
+	return err
+}
+
+// Members 方法返回房间中所有节点上的成员，忽略租约已过期的节点上的成员。
+//
+// 参数：
+// room string:房间名称。
+//
+// 返回值：
+// []connection.Info:房间成员列表。
+// error:返回错误信息。
+func (c Redis) Members(room string) ([]connection.Info, error) {
+	defer metrics.ObserveCache("redis", "members", time.Now())
+	values, err := c.store.HGetAll(c.ctx, c.roomKey(room)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	alive := make(map[int64]bool)
+	members := make([]connection.Info, 0, len(values))
+	for _, value := range values {
+		var info connection.Info
+		if err := sonic.UnmarshalString(value, &info); err != nil {
+			continue
+		}
+
+		ok, checked := alive[info.WorkID]
+		if !checked {
+			if ok, err = c.alive(info.WorkID); err != nil {
+				return nil, err
+			}
+
+			alive[info.WorkID] = ok
+		}
+
+		if ok {
+			members = append(members, info)
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members, nil
+}
+
+// Joined 方法返回连接加入的所有房间，按房间名称排序。
+//
+// 参数：
+// id int64:连接的 ID。
+//
+// 返回值：
+// []string:房间名称列表。
+// error:返回错误信息。
+func (c Redis) Joined(id int64) ([]string, error) {
+	defer metrics.ObserveCache("redis", "joined", time.Now())
+	field, _ := c.makeKey(id)
+	rooms, err := c.store.SMembers(c.ctx, c.joinedKey(field)).Result()
+	sort.Strings(rooms)
+	return rooms, err
+}
+
+// leaveAll 方法在管道中将连接移出指定的房间，参数为连接 ID 与房间名称列表的映射。
+func (c Redis) leaveAll(pipe redis.Pipeliner, joined map[string][]string) {
+	for field, rooms := range joined {
+		for _, room := range rooms {
+			pipe.HDel(c.ctx, c.roomKey(room), field)
+			pipe.SRem(c.ctx, c.joinedKey(field), room)
+		}
+	}
+}
+
+// Subscribe 方法订阅所有节点上连接的在线状态变化，上下文被取消时关闭返回的通道。
+//
+// 参数：
+// ctx context.Context:上下文，被取消时取消订阅。
+//
+// 返回值：
+// <-chan Presence:在线状态的通道。
+// error:订阅失败时返回错误信息。
+func (c Redis) Subscribe(ctx context.Context) (<-chan Presence, error) {
+	defer metrics.ObserveCache("redis", "subscribe", time.Now())
+	pubsub := c.store.Subscribe(ctx, c.prefix+"events")
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	ch := make(chan Presence, 64)
+	go func() {
+		defer close(ch)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var presence Presence
+				if err := sonic.UnmarshalString(msg.Payload, &presence); err != nil {
+					fmt.Println("redis presence error", err)
+					continue
+				}
+
+				select {
+				case ch <- presence:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Status 方法返回连接的在线状态。
+//
+// 参数：
+// id int64:连接的 ID。
+//
+// 返回值：
+// Presence:连接在线时 Online 为 true;连接离线后返回最后一次在线的时间；从未上线时 LastSeen 为零值。
+// error:返回错误信息。
+func (c Redis) Status(id int64) (Presence, error) {
+	defer metrics.ObserveCache("redis", "status", time.Now())
+	if conn := c.Find(id); conn != nil {
+		return Presence{ID: id, WorkID: conn.Info().WorkID, Online: true, LastSeen: time.Now()}, nil
+	}
+
+	field, _ := c.makeKey(id)
+	value, err := c.store.HGet(c.ctx, c.prefix+"seen", field).Result()
+	if err == redis.Nil {
+		return Presence{ID: id}, nil
+	}
+
+	if err != nil {
+		return Presence{}, err
+	}
+
+	var presence Presence
+	err = sonic.UnmarshalString(value, &presence)
+	return presence, err
 }
 
 // Find 方法用于在 Redis 中查找指定 ID 的连接对象，并返回该连接对象的指针。
@@ -44,13 +320,11 @@ func (c Redis) Offline(conn *connection.Connection) error {
 // id int64:要查找的连接对象的 ID。
 //
 // 返回值：
-// *connection.Connection:如果找到了指定 ID 的连接对象，则返回该连接对象的指针；否则返回 nil。
-func (c Redis) Find(id int64) *connection.Connection {
+// connection.Peer:如果找到了指定 ID 的连接对象，并且连接所在节点的租约有效，则返回该连接对象；否则返回 nil。
+func (c Redis) Find(id int64) connection.Peer {
+	defer metrics.ObserveCache("redis", "find", time.Now())
 	// 定义一个结构体变量 value,用于存储从 Redis 中获取到的连接对象的信息。
-	var value struct {
-		ID     int64
-		WorkID int64
-	}
+	var value connection.Info
 
 	// 调用 makeKey 方法生成 Redis 中的 key 和 field。
 	field, key := c.makeKey(id)
@@ -65,10 +339,228 @@ func (c Redis) Find(id int64) *connection.Connection {
 	// 将 bytes 数据反序列化为 value 结构体。
 	if err := sonic.Unmarshal(bytes, &value); err != nil {
 		fmt.Println("redis find error[1002]", err)
+		return nil
+	}
+
+	// 连接所在节点的租约已过期，说明节点已经宕机，连接不再视为在线。
+	alive, err := c.alive(value.WorkID)
+	if err != nil {
+		fmt.Println("redis find error[1003]", err)
+		return nil
+	}
+
+	if !alive {
+		return nil
+	}
+
+	// 根据 value 结构体中的信息创建一个只包含 ID 和工作 ID 的连接对象，由 Worker 转换为可以转发消息的远程连接。
+	return connection.NewRemote(value.ID, value.WorkID, nil)
+}
+
+// Renew 方法用于续约节点的租约，并清理租约已过期的节点的连接。
+//
+// 参数：
+// node int64:节点的工作 ID。
+// ttl time.Duration:租约有效期。
+//
+// 返回值：
+// bool:续约前租约是否有效，为 false 时节点的连接可能已经被清理，需要重新上线。
+// error:返回错误信息。
+func (c Redis) Renew(node int64, ttl time.Duration) (bool, error) {
+	defer metrics.ObserveCache("redis", "renew", time.Now())
+	leaseKey := c.leaseKey(node)
+	prev, err := c.store.SetArgs(c.ctx, leaseKey, 1, redis.SetArgs{TTL: ttl, Get: true}).Result()
+	if err != nil && err != redis.Nil {
+		return false, err
+	}
+
+	if err := c.store.SAdd(c.ctx, c.prefix+"nodes", node).Err(); err != nil {
+		return false, err
+	}
+
+	return prev != "", c.purge()
+}
+
+// purge 方法用于清理租约已过期的节点的连接。
+//
+// 返回值：
+// error:返回错误信息。
+func (c Redis) purge() error {
+	nodes, err := c.store.SMembers(c.ctx, c.prefix+"nodes").Result()
+	if err != nil {
+		return err
 	}
 
-	// 根据 value 结构体中的信息创建一个新的 connection.Connection 对象，并返回其指针。
-	return &connection.Connection{ID: value.ID, WorkID: value.WorkID}
+	for _, member := range nodes {
+		node, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		alive, err := c.alive(node)
+		if err != nil {
+			return err
+		}
+
+		if !alive {
+			if err := c.Reset(node); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Reset 方法用于清除节点的所有连接和租约，将这些连接移出所有房间，并发布这些连接的离线状态。
+// 连接已经在其他节点上重新上线时不做处理，检查和删除在同一个 Lua 脚本中原子地执行。
+//
+// 参数：
+// node int64:节点的工作 ID。
+//
+// 返回值：
+// error:返回错误信息。
+func (c Redis) Reset(node int64) error {
+	defer metrics.ObserveCache("redis", "reset", time.Now())
+	_, key := c.makeKey(0)
+	args := []any{c.prefix, strconv.FormatInt(node, 10), time.Now().Format(time.RFC3339Nano)}
+	return resetScript.Run(c.ctx, c.store, []string{key}, args...).Err()
+}
+
+// Count 方法用于返回所有节点上的在线连接数，租约过期的节点的连接在清理前也会被统计。
+//
+// 返回值：
+// int64:在线连接数。
+// error:返回错误信息。
+func (c Redis) Count() (int64, error) {
+	defer metrics.ObserveCache("redis", "count", time.Now())
+	_, key := c.makeKey(0)
+	return c.store.HLen(c.ctx, key).Result()
+}
+
+// List 方法用于分页查询所有节点上的在线连接，忽略租约已过期的节点的连接。
+//
+// 参数：
+// cursor uint64:游标，第一次查询时为 0。
+// count int64:每页的连接数，实际返回的数量可能多于或少于该值。
+//
+// 返回值：
+// []connection.Info:在线连接列表。
+// uint64:下一页的游标，为 0 时表示已经没有更多连接。
+// error:返回错误信息。
+func (c Redis) List(cursor uint64, count int64) ([]connection.Info, uint64, error) {
+	defer metrics.ObserveCache("redis", "list", time.Now())
+	_, key := c.makeKey(0)
+	values, next, err := c.store.HScan(c.ctx, key, cursor, "*", count).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// HSCAN 返回的结果为字段和值交替排列
+	alive := make(map[int64]bool)
+	list := make([]connection.Info, 0, len(values)/2)
+	for i := 1; i < len(values); i += 2 {
+		var info connection.Info
+		if err := sonic.UnmarshalString(values[i], &info); err != nil {
+			continue
+		}
+
+		ok, checked := alive[info.WorkID]
+		if !checked {
+			if ok, err = c.alive(info.WorkID); err != nil {
+				return nil, 0, err
+			}
+
+			alive[info.WorkID] = ok
+		}
+
+		if ok {
+			list = append(list, info)
+		}
+	}
+
+	return list, next, nil
+}
+
+// Locate 方法使用一次 HMGET 查询多个连接所在的节点，忽略租约已过期的节点的连接。
+//
+// 参数：
+// ids []int64:连接的 ID 列表。
+//
+// 返回值：
+// map[int64]int64:在线连接的 ID 到所在节点工作 ID 的映射。
+// error:返回错误信息。
+func (c Redis) Locate(ids []int64) (map[int64]int64, error) {
+	defer metrics.ObserveCache("redis", "locate", time.Now())
+	nodes := make(map[int64]int64, len(ids))
+	if len(ids) == 0 {
+		return nodes, nil
+	}
+
+	_, key := c.makeKey(0)
+	fields := make([]string, len(ids))
+	for i, id := range ids {
+		fields[i], _ = c.makeKey(id)
+	}
+
+	values, err := c.store.HMGet(c.ctx, key, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	alive := make(map[int64]bool)
+	for _, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		var info connection.Info
+		if err := sonic.UnmarshalString(raw, &info); err != nil {
+			continue
+		}
+
+		ok, checked := alive[info.WorkID]
+		if !checked {
+			if ok, err = c.alive(info.WorkID); err != nil {
+				return nil, err
+			}
+
+			alive[info.WorkID] = ok
+		}
+
+		if ok {
+			nodes[info.ID] = info.WorkID
+		}
+	}
+
+	return nodes, nil
+}
+
+// alive 方法用于判断节点的租约是否有效。
+func (c Redis) alive(node int64) (bool, error) {
+	n, err := c.store.Exists(c.ctx, c.leaseKey(node)).Result()
+	return n > 0, err
+}
+
+// leaseKey 方法用于生成节点租约的 key。
+func (c Redis) leaseKey(node int64) string {
+	return c.prefix + "lease:" + strconv.FormatInt(node, 10)
+}
+
+// roomKey 方法用于生成保存房间成员的哈希表的 key。
+func (c Redis) roomKey(room string) string {
+	return c.prefix + "room:" + room
+}
+
+// joinedKey 方法用于生成保存连接加入的房间的集合的 key。
+func (c Redis) joinedKey(field string) string {
+	return c.prefix + "joined:" + field
+}
+
+// nodeKey 方法用于生成保存节点连接 ID 的集合的 key。
+func (c Redis) nodeKey(node int64) string {
+	return c.prefix + "node:" + strconv.FormatInt(node, 10)
 }
 
 // makeKey 方法用于根据给定的 ID 生成 Redis 中的 key 和 field。
@@ -88,3 +580,35 @@ func (c Redis) makeKey(id int64) (string, string) {
 	// 返回生成的 key 和 field。
 	return field, key
 }
+
+// Put 方法保存数据，ttl 后过期，所有节点都可以读取
+//
+// 参数：
+// key string:键名，保存时加上前缀。
+// value []byte:数据。
+// ttl time.Duration:过期时间。
+//
+// 返回值：
+// error:返回错误信息。
+func (c Redis) Put(key string, value []byte, ttl time.Duration) error {
+	defer metrics.ObserveCache("redis", "put", time.Now())
+	return c.store.Set(c.ctx, c.prefix+"store:"+key, value, ttl).Err()
+}
+
+// Get 方法返回数据
+//
+// 参数：
+// key string:键名。
+//
+// 返回值：
+// []byte:数据，不存在或已经过期时返回 nil。
+// error:返回错误信息。
+func (c Redis) Get(key string) ([]byte, error) {
+	defer metrics.ObserveCache("redis", "get", time.Now())
+	value, err := c.store.Get(c.ctx, c.prefix+"store:"+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	return value, err
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"net"
 
@@ -30,21 +31,9 @@ func New(addr string, opts ...connection.Options) (*Client, error) {
 		return nil, err
 	}
 
-	// 创建新的连接对象
-	connectiond := connection.NewConnection(connection.WithConn(conn), connection.WithClient(true))
-	for _, fn := range opts {
-		fn(connectiond)
-	}
-
-	// 初始化 ID 和 WorkID
-	connectiond.ID = 0
-	connectiond.WorkID = 0
-
-	// 监听连接初始化事件，设置 ID 和 WorkID
-	connectiond.On(event.TopicByInitID, func(c *connection.Connection, _ event.Event) {
-		connection.WithID(c.ID)
-		connection.WithWorkID(c.WorkID)
-	})
+	// 创建新的连接对象，选项需要在连接初始化(握手)之前生效，ID 和 WorkID 由服务端下发
+	opts = append([]connection.Options{connection.WithConn(conn), connection.WithClient(true)}, opts...)
+	connectiond := connection.NewConnection(opts...)
 
 	// 创建新的客户端对象
 	client := Client{
@@ -68,6 +57,20 @@ func (c Client) Send(topic string, data any) error {
 	return c.conn.Send(topic, data)
 }
 
+// Request方法用于发送请求，并等待服务端的响应
+//
+// 参数：
+// - ctx 上下文，用于设置等待响应的超时时间
+// - topic 主题
+// - data 数据
+//
+// 返回值
+// - event.Event 响应事件
+// - error
+func (c Client) Request(ctx context.Context, topic string, data any) (event.Event, error) {
+	return c.conn.Request(ctx, topic, data)
+}
+
 // Subscription方法用于订阅主题
 //
 // 参数:
@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// secretKey 是请求元数据中携带共享密钥的键名
+const secretKey = "x-cluster-secret"
+
+// secretCredentials 在每个请求的元数据中携带共享密钥
+type secretCredentials string
+
+// GetRequestMetadata 返回携带共享密钥的元数据
+func (s secretCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{secretKey: string(s)}, nil
+}
+
+// RequireTransportSecurity 共享密钥可以在不加密的连接上使用，集群内网之外应同时使用 TLS
+func (s secretCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// WithSecret 返回连接节点服务时携带共享密钥的选项，传给 NewBroker 使用
+//
+// 参数：
+//   - secret string 共享密钥，为空时不携带
+//
+// 返回值：
+//   - grpc.DialOption 连接选项
+func WithSecret(secret string) grpc.DialOption {
+	if secret == "" {
+		return grpc.EmptyDialOption{}
+	}
+
+	return grpc.WithPerRPCCredentials(secretCredentials(secret))
+}
+
+// ServerSecret 返回校验共享密钥的 gRPC 服务器选项，没有携带正确密钥的请求返回 codes.Unauthenticated。
+// 选项作用于服务器上的所有服务。
+//
+// 参数：
+//   - secret string 共享密钥，为空时不校验
+//
+// 返回值：
+//   - grpc.ServerOption 服务器选项
+func ServerSecret(secret string) grpc.ServerOption {
+	if secret == "" {
+		return grpc.EmptyServerOption{}
+	}
+
+	check := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, value := range md.Get(secretKey) {
+			if subtle.ConstantTimeCompare([]byte(value), []byte(secret)) == 1 {
+				return nil
+			}
+		}
+
+		return status.Error(codes.Unauthenticated, "invalid cluster secret")
+	}
+
+	return grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := check(ctx); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	})
+}
@@ -0,0 +1,198 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/cotton-go/socket/pkg/broker"
+	"github.com/cotton-go/socket/pkg/cluster/pb"
+	"github.com/cotton-go/socket/pkg/worker"
+)
+
+// Broker 结构体，通过节点服务直接将消息发送到连接所在的节点，可以代替 Redis 发布订阅
+type Broker struct {
+	resolver Resolver                    // 节点地址解析器
+	opts     []grpc.DialOption           // 连接节点时使用的选项
+	lock     sync.Mutex                  // 互斥锁，保护 conns
+	conns    map[string]*grpc.ClientConn // 节点地址到 gRPC 连接的映射
+}
+
+// NewBroker 创建一个通过节点服务转发消息的消息代理。
+// 解析器实现了 Watcher 时，节点下线后关闭到该节点的 gRPC 连接。
+//
+// 参数：
+//   - resolver Resolver 节点地址解析器
+//   - opts ...grpc.DialOption 连接节点时使用的选项，例如 WithSecret 和 grpc.WithTransportCredentials,
+//     没有设置传输凭证时使用不加密的连接
+//
+// 返回值：
+//   - *Broker 消息代理
+func NewBroker(resolver Resolver, opts ...grpc.DialOption) *Broker {
+	// 后面的传输凭证覆盖默认的不加密连接
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	b := &Broker{resolver: resolver, opts: opts, conns: make(map[string]*grpc.ClientConn)}
+	if watcher, ok := resolver.(Watcher); ok {
+		watcher.OnRemove(b.forget)
+	}
+
+	return b
+}
+
+// forget 关闭并移除到指定地址的 gRPC 连接
+func (b *Broker) forget(addr string) {
+	b.lock.Lock()
+	conn, ok := b.conns[addr]
+	delete(b.conns, addr)
+	b.lock.Unlock()
+
+	if ok {
+		conn.Close()
+	}
+}
+
+// Client 返回指定节点的节点服务客户端，同一个地址的 gRPC 连接会被复用
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node int64 节点的工作 ID
+//
+// 返回值：
+//   - pb.ClusterClient 节点服务客户端
+//   - error 找不到节点或连接失败时返回错误信息
+func (b *Broker) Client(ctx context.Context, node int64) (pb.ClusterClient, error) {
+	addr, err := b.resolver.Resolve(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	conn, ok := b.conns[addr]
+	if !ok {
+		if conn, err = grpc.Dial(addr, b.opts...); err != nil {
+			return nil, err
+		}
+
+		b.conns[addr] = conn
+	}
+
+	return pb.NewClusterClient(conn), nil
+}
+
+// Publish 调用指定节点的 Send 方法发送消息
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node int64 节点的工作 ID
+//   - msg broker.Message 消息
+//
+// 返回值：
+//   - error 连接不在该节点上时返回 worker.ErrNotFound
+func (b *Broker) Publish(ctx context.Context, node int64, msg broker.Message) error {
+	client, err := b.Client(ctx, node)
+	if err != nil {
+		return err
+	}
+
+	data, err := marshalData(msg.Data)
+	if err != nil {
+		return err
+	}
+
+	if msg.Kick {
+		reason, _ := msg.Data.(string)
+		_, err = client.Kick(ctx, &pb.KickRequest{Id: msg.To, Reason: reason})
+		if status.Code(err) == codes.NotFound {
+			return worker.ErrNotFound
+		}
+
+		return err
+	}
+
+	// 没有指定连接时向节点广播，节点只会发送给其本地的连接、房间成员或指定的多个连接
+	if msg.To == 0 {
+		_, err = client.Broadcast(ctx, &pb.BroadcastRequest{Topic: msg.Topic, Data: data, Room: msg.Room, Ids: msg.Targets})
+		return err
+	}
+
+	_, err = client.Send(ctx, &pb.SendRequest{Id: msg.To, Topic: msg.Topic, Data: data})
+	if status.Code(err) == codes.NotFound {
+		return worker.ErrNotFound
+	}
+
+	return err
+}
+
+// Request 调用指定节点的 Call 方法向连接发送请求事件，并等待客户端的响应
+//
+// 参数：
+//   - ctx context.Context 上下文，截止时间会传递给连接所在的节点
+//   - node int64 节点的工作 ID
+//   - msg broker.Message 请求消息，To 为连接 ID
+//
+// 返回值：
+//   - any 客户端的响应数据
+//   - error 连接不在该节点上时返回 worker.ErrNotFound,超时时返回 context.DeadlineExceeded
+func (b *Broker) Request(ctx context.Context, node int64, msg broker.Message) (any, error) {
+	client, err := b.Client(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := marshalData(msg.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := client.Call(ctx, &pb.CallRequest{Id: msg.To, Topic: msg.Topic, Data: data})
+	switch status.Code(err) {
+	case codes.OK:
+		return unmarshalData(reply.GetData())
+	case codes.NotFound:
+		return nil, worker.ErrNotFound
+	case codes.DeadlineExceeded:
+		return nil, context.DeadlineExceeded
+	default:
+		return nil, err
+	}
+}
+
+// Subscribe 节点服务会直接将消息发送给本节点上的连接，不需要订阅，阻塞直到上下文被取消
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node int64 节点的工作 ID
+//   - fn func(broker.Message) 收到消息时调用的函数，不会被调用
+//
+// 返回值：
+//   - error 上下文被取消时返回 nil
+func (b *Broker) Subscribe(ctx context.Context, node int64, fn func(broker.Message)) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Close 关闭所有节点的 gRPC 连接
+//
+// 返回值：
+//   - error 返回最后一个关闭失败的错误信息
+func (b *Broker) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var err error
+	for addr, conn := range b.conns {
+		if e := conn.Close(); e != nil {
+			err = e
+		}
+
+		delete(b.conns, addr)
+	}
+
+	return err
+}
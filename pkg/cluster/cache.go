@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cotton-go/socket/pkg/cache"
+	"github.com/cotton-go/socket/pkg/cluster/pb"
+	"github.com/cotton-go/socket/pkg/connection"
+)
+
+// Cache 结构体，在没有 Redis 等共享缓存时使用，在线连接保存在本节点的缓存中，
+// 本节点上找不到连接时通过节点服务的 Presence 方法询问注册中心中的所有节点
+type Cache struct {
+	cache.ICache
+	discovery *Discovery
+	broker    *Broker
+	timeout   time.Duration // 询问其他节点的超时时间
+}
+
+// NewCache 创建一个通过节点服务查询其他节点上的连接的缓存
+//
+// 参数：
+//   - local cache.ICache 本节点的缓存，为 nil 时使用 cache.NewMemory
+//   - discovery *Discovery 从注册中心发现的节点
+//   - broker *Broker 节点服务的客户端
+//
+// 返回值：
+//   - *Cache 缓存
+func NewCache(local cache.ICache, discovery *Discovery, broker *Broker) *Cache {
+	if local == nil {
+		local = cache.NewMemory()
+	}
+
+	return &Cache{ICache: local, discovery: discovery, broker: broker, timeout: time.Second * 2}
+}
+
+// Find 先在本节点的缓存中查找连接，找不到时并发询问所有节点，返回第一个持有该连接的节点上的远程连接
+//
+// 参数：
+//   - id int64 连接 ID
+//
+// 返回值：
+//   - connection.Peer 连接不在线时返回 nil
+func (c *Cache) Find(id int64) connection.Peer {
+	if conn := c.ICache.Find(id); conn != nil {
+		return conn
+	}
+
+	nodes := c.discovery.Nodes()
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	found := make(chan int64, len(nodes))
+	for _, node := range nodes {
+		go func(node int64) {
+			client, err := c.broker.Client(ctx, node)
+			if err != nil {
+				found <- 0
+				return
+			}
+
+			reply, err := client.Presence(ctx, &pb.PresenceRequest{Ids: []int64{id}})
+			if err != nil {
+				fmt.Println("cluster presence error", err, node)
+				found <- 0
+				return
+			}
+
+			if len(reply.GetOnline()) > 0 {
+				found <- node
+				return
+			}
+
+			found <- 0
+		}(node.ID)
+	}
+
+	for range nodes {
+		if node := <-found; node != 0 {
+			return connection.NewRemote(id, node, nil)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,576 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cotton-go/socket/pkg/broker"
+	"github.com/cotton-go/socket/pkg/cache"
+	"github.com/cotton-go/socket/pkg/cluster/pb"
+	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/registry"
+	"github.com/cotton-go/socket/pkg/worker"
+)
+
+// node 表示测试中的一个节点
+type node struct {
+	work   *worker.Worker
+	broker *Broker
+}
+
+// newCluster 在同一个进程中启动多个节点，节点之间共享缓存，通过节点服务转发消息
+func newCluster(t *testing.T, ctx context.Context, size int) []node {
+	var (
+		nodes   = make([]node, size)
+		addrs   = make(Static)
+		cachex  = cache.NewMemory()
+		servers []*grpc.Server
+	)
+
+	listeners := make([]net.Listener, size)
+	for i := range listeners {
+		li, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		listeners[i] = li
+		addrs[int64(i+1)] = li.Addr().String()
+	}
+
+	for i := range nodes {
+		nodes[i].broker = NewBroker(addrs)
+		nodes[i].work = worker.NewWorker(
+			worker.WithID(int64(i+1)),
+			worker.WithContext(ctx),
+			worker.WithCache(cachex),
+			worker.WithBroker(nodes[i].broker),
+		)
+
+		server := grpc.NewServer()
+		NewService(nodes[i].work).Register(server)
+		go server.Serve(listeners[i])
+		servers = append(servers, server)
+	}
+
+	t.Cleanup(func() {
+		for i, server := range servers {
+			nodes[i].broker.Close()
+			server.Stop()
+		}
+	})
+
+	// 等待 Worker 的选项生效
+	time.Sleep(time.Millisecond * 100)
+	return nodes
+}
+
+// dial 在指定节点上建立一个连接，返回服务端连接和客户端收到的事件
+func dial(t *testing.T, ctx context.Context, n node) (*connection.Connection, chan event.Event) {
+	received := make(chan event.Event, 10)
+	server, client := net.Pipe()
+	connection.NewConnection(
+		connection.WithConn(client),
+		connection.WithClient(true),
+		connection.WithContext(ctx),
+		connection.WithHandle(func(_ connection.Peer, e event.Event) {
+			if e.Topic != event.TopicByClose {
+				received <- e
+			}
+		}),
+	)
+
+	conn := n.work.Connection(server)
+	deadline := time.Now().Add(time.Second * 2)
+	for n.work.Local(conn.ID()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not online")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	return conn, received
+}
+
+// receive 等待客户端收到指定主题的事件
+func receive(t *testing.T, received chan event.Event, topic string) event.Event {
+	timeout := time.After(time.Second * 2)
+	for {
+		select {
+		case e := <-received:
+			if e.Topic == topic {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("event %q not received", topic)
+		}
+	}
+}
+
+func TestCluster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes := newCluster(t, ctx, 3)
+	owner, received := dial(t, ctx, nodes[1])
+	_, other := dial(t, ctx, nodes[2])
+
+	t.Run("send", func(t *testing.T) {
+		// 连接位于节点 2,通过节点 1 发送
+		if err := nodes[0].work.Send(owner.ID(), "hello", map[string]any{"name": "xxx"}); err != nil {
+			t.Fatal(err)
+		}
+
+		e := receive(t, received, "hello")
+		if data, _ := e.Data.(map[string]any); data["name"] != "xxx" {
+			t.Fatalf("unexpected data %v", e.Data)
+		}
+
+		msg := broker.Message{To: owner.ID(), Topic: "hello"}
+		if err := nodes[0].broker.Publish(ctx, 3, msg); !errors.Is(err, worker.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("broadcast", func(t *testing.T) {
+		var total int64
+		for i := range nodes {
+			client, err := nodes[0].broker.Client(ctx, int64(i+1))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reply, err := client.Broadcast(ctx, &pb.BroadcastRequest{Topic: "notice", Data: []byte(`"hi"`)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			total += reply.GetCount()
+		}
+
+		if total != 2 {
+			t.Fatalf("expected 2 connections, got %d", total)
+		}
+
+		receive(t, received, "notice")
+		receive(t, other, "notice")
+	})
+
+	t.Run("presence", func(t *testing.T) {
+		client, err := nodes[0].broker.Client(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		presence, err := client.Presence(ctx, &pb.PresenceRequest{Ids: []int64{owner.ID(), 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if online := presence.GetOnline(); len(online) != 1 || online[0] != owner.ID() {
+			t.Fatalf("unexpected presence %v", online)
+		}
+
+		stats, err := client.Stats(ctx, &pb.StatsRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stats.GetWorkId() != 2 || stats.GetConnections() != 1 {
+			t.Fatalf("unexpected stats %v", stats)
+		}
+	})
+
+	t.Run("kick", func(t *testing.T) {
+		client, err := nodes[0].broker.Client(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.Kick(ctx, &pb.KickRequest{Id: owner.ID()}); err != nil {
+			t.Fatal(err)
+		}
+
+		// 等待节点处理断开连接事件
+		deadline := time.Now().Add(time.Second * 2)
+		for nodes[1].work.Local(owner.ID()) != nil {
+			if time.Now().After(deadline) {
+				t.Fatal("connection is not offline")
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		if _, err := client.Kick(ctx, &pb.KickRequest{Id: owner.ID()}); status.Code(err) != codes.NotFound {
+			t.Fatalf("expected NotFound, got %v", err)
+		}
+	})
+}
+
+func TestRooms(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes := newCluster(t, ctx, 3)
+	a, receivedA := dial(t, ctx, nodes[1])
+	b, receivedB := dial(t, ctx, nodes[1])
+	c, receivedC := dial(t, ctx, nodes[2])
+	_, outsider := dial(t, ctx, nodes[0])
+
+	for _, conn := range []*connection.Connection{a, b, c} {
+		if err := nodes[0].work.Join("lobby", conn.ID()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := nodes[0].work.BroadcastRoom("lobby", "notice", "hi"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每个成员只收到一次，房间外的连接收不到
+	for _, received := range []chan event.Event{receivedA, receivedB, receivedC} {
+		receive(t, received, "notice")
+	}
+
+	time.Sleep(time.Millisecond * 100)
+	for _, received := range []chan event.Event{receivedA, receivedB, receivedC, outsider} {
+		if len(received) != 0 {
+			t.Fatalf("unexpected event %v", <-received)
+		}
+	}
+
+	if rooms, _ := nodes[2].work.Rooms(c.ID()); len(rooms) != 1 || rooms[0] != "lobby" {
+		t.Fatalf("unexpected rooms %v", rooms)
+	}
+
+	// 成员离线后离开所有房间
+	if err := nodes[2].work.Kick(c.ID(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second * 2)
+	for {
+		members, err := nodes[0].work.Members("lobby")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(members) == 2 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected members %v", members)
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if err := nodes[0].work.Leave("lobby", a.ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	if members, _ := nodes[0].work.Members("lobby"); len(members) != 1 || members[0].ID != b.ID() {
+		t.Fatalf("unexpected members %v", members)
+	}
+}
+
+func TestMulticast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes := newCluster(t, ctx, 3)
+	a, receivedA := dial(t, ctx, nodes[0])
+	b, receivedB := dial(t, ctx, nodes[1])
+	c, receivedC := dial(t, ctx, nodes[1])
+	_, outsider := dial(t, ctx, nodes[2])
+
+	results := nodes[0].work.Multicast(
+		[]int64{a.ID(), b.ID(), c.ID(), 404, b.ID()},
+		worker.Payload{Topic: "first", Data: 1},
+		worker.Payload{Topic: "second", Data: 2},
+	)
+
+	expected := []worker.DeliveryStatus{worker.StatusDelivered, worker.StatusRouted, worker.StatusRouted, worker.StatusOffline}
+	if len(results) != len(expected) {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	for i, result := range results {
+		if result.Status != expected[i] {
+			t.Fatalf("unexpected result %d %+v", i, result)
+		}
+	}
+
+	// 每个连接按顺序收到所有事件，其他连接收不到
+	for _, received := range []chan event.Event{receivedA, receivedB, receivedC} {
+		receive(t, received, "first")
+		receive(t, received, "second")
+	}
+
+	time.Sleep(time.Millisecond * 100)
+	if len(outsider) != 0 {
+		t.Fatalf("unexpected event %v", <-outsider)
+	}
+}
+
+func TestRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes := newCluster(t, ctx, 2)
+	server, client := net.Pipe()
+	connection.NewConnection(
+		connection.WithConn(client),
+		connection.WithClient(true),
+		connection.WithContext(ctx),
+		connection.WithHandle(func(c connection.Peer, e event.Event) {
+			if e.Topic == "config" && e.Seq != 0 {
+				c.Reply(e, e.Data)
+			}
+		}),
+	)
+
+	owner := nodes[1].work.Connection(server)
+	deadline := time.Now().Add(time.Second * 2)
+	for nodes[0].work.Find(owner.ID()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not online")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// 连接位于其他节点时通过节点服务的 Call 方法请求
+	reqCtx, reqCancel := context.WithTimeout(ctx, time.Second*2)
+	defer reqCancel()
+	reply, err := nodes[0].work.Request(reqCtx, owner.ID(), "config", "v1")
+	if err != nil || reply != "v1" {
+		t.Fatalf("unexpected reply %v %v", reply, err)
+	}
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, time.Millisecond*200)
+	defer timeoutCancel()
+	if _, err := nodes[0].work.Request(timeoutCtx, owner.ID(), "ignored", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if _, err := nodes[0].broker.Request(ctx, 2, broker.Message{To: 404, Topic: "config"}); !errors.Is(err, worker.ErrNotFound) {
+		t.Fatalf("expected worker.ErrNotFound, got %v", err)
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		nodes  = make([]node, 2)
+		cachex = cache.NewMemory()
+		r      = registry.NewStatic(nil, registry.WithInterval(time.Millisecond*10))
+	)
+
+	discovery, err := NewDiscovery(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range nodes {
+		li, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nodes[i].broker = NewBroker(discovery)
+		nodes[i].work = worker.NewWorker(
+			worker.WithID(int64(i+1)),
+			worker.WithContext(ctx),
+			worker.WithCache(cachex),
+			worker.WithBroker(nodes[i].broker),
+			worker.WithRegistry(r, registry.Node{GRPC: li.Addr().String()}, time.Second),
+		)
+
+		server := grpc.NewServer()
+		NewService(nodes[i].work).Register(server)
+		go server.Serve(li)
+		defer server.Stop()
+		defer nodes[i].broker.Close()
+	}
+
+	// 等待节点注册并被发现
+	deadline := time.Now().Add(time.Second * 2)
+	for len(discovery.Nodes()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("nodes are not discovered: %+v", discovery.Nodes())
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	owner, received := dial(t, ctx, nodes[1])
+	if err := nodes[0].work.Send(owner.ID(), "hello", "world"); err != nil {
+		t.Fatal(err)
+	}
+
+	if e := receive(t, received, "hello"); e.Data != "world" {
+		t.Fatalf("unexpected data %v", e.Data)
+	}
+
+	// 节点的负载随续约更新
+	deadline = time.Now().Add(time.Second * 2)
+	for discovery.Nodes()[1].Load != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("load is not updated: %+v", discovery.Nodes())
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if _, err := discovery.Resolve(ctx, 3); !errors.Is(err, ErrUnknownNode) {
+		t.Fatalf("expected ErrUnknownNode, got %v", err)
+	}
+}
+
+func TestGossip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		nodes = make([]node, 2)
+		seeds []string
+	)
+
+	for i := range nodes {
+		li, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 每个节点使用独立的注册中心和缓存，不依赖 Redis
+		r, err := registry.NewGossip("127.0.0.1:0", []byte("0123456789abcdef"), seeds, registry.WithProbe(time.Millisecond*50), registry.WithInterval(time.Millisecond*10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		seeds = []string{r.Addr()}
+
+		discovery, err := NewDiscovery(ctx, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nodes[i].broker = NewBroker(discovery)
+		nodes[i].work = worker.NewWorker(
+			worker.WithID(int64(i+1)),
+			worker.WithContext(ctx),
+			worker.WithCache(NewCache(nil, discovery, nodes[i].broker)),
+			worker.WithBroker(nodes[i].broker),
+			worker.WithRegistry(r, registry.Node{GRPC: li.Addr().String()}, time.Second),
+		)
+
+		server := grpc.NewServer()
+		NewService(nodes[i].work).Register(server)
+		go server.Serve(li)
+		defer server.Stop()
+		defer nodes[i].broker.Close()
+
+		// 等待节点注册并被发现
+		deadline := time.Now().Add(time.Second * 3)
+		for len(discovery.Nodes()) != i+1 {
+			if time.Now().After(deadline) {
+				t.Fatalf("nodes are not discovered: %+v", discovery.Nodes())
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+	}
+
+	owner, received := dial(t, ctx, nodes[1])
+	if err := nodes[0].work.Send(owner.ID(), "hello", "world"); err != nil {
+		t.Fatal(err)
+	}
+
+	if e := receive(t, received, "hello"); e.Data != "world" {
+		t.Fatalf("unexpected data %v", e.Data)
+	}
+
+	if err := nodes[0].work.Send(12345, "hello", "world"); !errors.Is(err, worker.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	li, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	work := worker.NewWorker(worker.WithID(1), worker.WithContext(ctx))
+	server := grpc.NewServer(ServerSecret("secret"))
+	NewService(work).Register(server)
+	go server.Serve(li)
+	defer server.Stop()
+
+	addrs := Static{1: li.Addr().String()}
+	for secret, code := range map[string]codes.Code{"": codes.Unauthenticated, "wrong": codes.Unauthenticated, "secret": codes.OK} {
+		b := NewBroker(addrs, WithSecret(secret))
+		client, err := b.Client(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.Stats(ctx, &pb.StatsRequest{}); status.Code(err) != code {
+			t.Fatalf("secret %q: expected %s, got %v", secret, code, err)
+		}
+
+		b.Close()
+	}
+}
+
+func TestForget(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := registry.NewStatic(nil, registry.WithInterval(time.Millisecond*10))
+	r.Register(ctx, registry.Node{ID: 1, GRPC: "127.0.0.1:1"}, time.Minute)
+	discovery, err := NewDiscovery(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBroker(discovery)
+	defer b.Close()
+	if _, err := b.Client(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 节点下线后关闭到该节点的 gRPC 连接
+	r.Deregister(ctx, 1)
+	deadline := time.Now().Add(time.Second * 2)
+	for {
+		b.lock.Lock()
+		n := len(b.conns)
+		b.lock.Unlock()
+		if n == 0 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not closed")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
@@ -0,0 +1,4 @@
+// Package pb 定义了节点之间的 gRPC 服务，修改 cluster.proto 后需要重新生成代码
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative cluster.proto
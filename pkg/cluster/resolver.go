@@ -0,0 +1,155 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/cotton-go/socket/pkg/registry"
+)
+
+// ErrUnknownNode 表示找不到节点的地址
+var ErrUnknownNode = errors.New("unknown node")
+
+// Resolver 接口，用于根据节点的工作 ID 查找节点的 gRPC 地址
+type Resolver interface {
+	// Resolve 返回节点的 gRPC 地址，找不到节点时返回 ErrUnknownNode
+	Resolve(ctx context.Context, node int64) (string, error)
+}
+
+// Watcher 接口由节点列表会变化的解析器实现，节点下线时通知使用者释放到该节点的连接
+type Watcher interface {
+	// OnRemove 添加节点下线时调用的函数，参数为下线节点的 gRPC 地址
+	OnRemove(fn func(addr string))
+}
+
+// Static 是一个固定的节点地址表，键为节点的工作 ID,值为节点的 gRPC 地址
+type Static map[int64]string
+
+// Resolve 从地址表中查找节点的 gRPC 地址
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node int64 节点的工作 ID
+//
+// 返回值：
+//   - string 节点的 gRPC 地址
+//   - error 找不到节点时返回 ErrUnknownNode
+func (s Static) Resolve(ctx context.Context, node int64) (string, error) {
+	addr, ok := s[node]
+	if !ok {
+		return "", ErrUnknownNode
+	}
+
+	return addr, nil
+}
+
+// Discovery 结构体，通过注册中心查找节点的 gRPC 地址，节点列表变化时自动更新
+type Discovery struct {
+	lock    sync.RWMutex
+	nodes   map[int64]registry.Node // 节点 ID 到节点的映射
+	removes []func(addr string)     // 节点下线时调用的函数
+}
+
+// NewDiscovery 创建一个通过注册中心查找节点的解析器，上下文被取消前持续监听节点变化
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - r registry.Registry 注册中心
+//
+// 返回值：
+//   - *Discovery 节点地址解析器
+//   - error 第一次获取节点列表失败时返回错误信息
+func NewDiscovery(ctx context.Context, r registry.Registry) (*Discovery, error) {
+	ch, err := r.Watch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Discovery{}
+	d.update(<-ch)
+	go func() {
+		for nodes := range ch {
+			d.update(nodes)
+		}
+	}()
+
+	return d, nil
+}
+
+// OnRemove 添加节点下线时调用的函数，参数为下线节点的 gRPC 地址
+//
+// 参数：
+//   - fn func(addr string) 节点下线时调用的函数
+func (d *Discovery) OnRemove(fn func(addr string)) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.removes = append(d.removes, fn)
+}
+
+// update 更新节点列表，通知已经不在列表中的节点地址
+func (d *Discovery) update(nodes []registry.Node) {
+	m := make(map[int64]registry.Node, len(nodes))
+	addrs := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		m[node.ID] = node
+		addrs[node.GRPC] = true
+	}
+
+	d.lock.Lock()
+	var removed []string
+	for _, node := range d.nodes {
+		if node.GRPC != "" && !addrs[node.GRPC] {
+			removed = append(removed, node.GRPC)
+		}
+	}
+
+	d.nodes = m
+	removes := d.removes
+	d.lock.Unlock()
+
+	for _, addr := range removed {
+		for _, fn := range removes {
+			fn(addr)
+		}
+	}
+}
+
+// Nodes 返回当前在线的节点列表，按 ID 排序
+func (d *Discovery) Nodes() []registry.Node {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	nodes := make([]registry.Node, 0, len(d.nodes))
+	for _, node := range d.nodes {
+		nodes = append(nodes, node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+
+	return nodes
+}
+
+// Resolve 返回节点的 gRPC 地址
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node int64 节点的工作 ID
+//
+// 返回值：
+//   - string 节点的 gRPC 地址
+//   - error 节点不在线或没有 gRPC 地址时返回 ErrUnknownNode
+func (d *Discovery) Resolve(ctx context.Context, node int64) (string, error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	n, ok := d.nodes[node]
+	if !ok || n.GRPC == "" {
+		return "", ErrUnknownNode
+	}
+
+	return n.GRPC, nil
+}
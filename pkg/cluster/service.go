@@ -0,0 +1,153 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bytedance/sonic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cotton-go/socket/pkg/cluster/pb"
+	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/worker"
+)
+
+// Service 结构体，基于 Worker 实现节点之间的 gRPC 服务，只处理本节点上的连接
+type Service struct {
+	pb.UnimplementedClusterServer
+	work *worker.Worker // 本节点的 Worker
+}
+
+// NewService 创建一个节点服务
+//
+// 参数：
+//   - work *worker.Worker 本节点的 Worker
+//
+// 返回值：
+//   - *Service 节点服务
+func NewService(work *worker.Worker) *Service {
+	return &Service{work: work}
+}
+
+// Register 将节点服务注册到 gRPC 服务器
+//
+// 参数：
+//   - server grpc.ServiceRegistrar gRPC 服务器
+func (s *Service) Register(server grpc.ServiceRegistrar) {
+	pb.RegisterClusterServer(server, s)
+}
+
+// Send 向本节点上的连接发送事件
+func (s *Service) Send(ctx context.Context, req *pb.SendRequest) (*pb.SendReply, error) {
+	data, err := unmarshalData(req.GetData())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	conn := s.work.Local(req.GetId())
+	if conn == nil {
+		return nil, status.Error(codes.NotFound, worker.ErrNotFound.Error())
+	}
+
+	if err := conn.Send(req.GetTopic(), data); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	return &pb.SendReply{}, nil
+}
+
+// Broadcast 向本节点上的所有连接发送事件，指定连接 ID 时只发送给本节点上的这些连接
+func (s *Service) Broadcast(ctx context.Context, req *pb.BroadcastRequest) (*pb.BroadcastReply, error) {
+	data, err := unmarshalData(req.GetData())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if ids := req.GetIds(); len(ids) > 0 {
+		return &pb.BroadcastReply{Count: int64(s.work.MulticastLocal(ids, req.GetTopic(), data))}, nil
+	}
+
+	return &pb.BroadcastReply{Count: int64(s.work.BroadcastLocal(req.GetRoom(), req.GetTopic(), data))}, nil
+}
+
+// Kick 关闭本节点上的连接
+func (s *Service) Kick(ctx context.Context, req *pb.KickRequest) (*pb.KickReply, error) {
+	if err := s.work.Kick(req.GetId(), req.GetReason()); err != nil {
+		if errors.Is(err, worker.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	return &pb.KickReply{}, nil
+}
+
+// Presence 返回指定连接中在本节点上的连接
+func (s *Service) Presence(ctx context.Context, req *pb.PresenceRequest) (*pb.PresenceReply, error) {
+	reply := &pb.PresenceReply{}
+	for _, id := range req.GetIds() {
+		if s.work.Local(id) != nil {
+			reply.Online = append(reply.Online, id)
+		}
+	}
+
+	return reply, nil
+}
+
+// Stats 返回本节点的统计信息
+func (s *Service) Stats(ctx context.Context, req *pb.StatsRequest) (*pb.StatsReply, error) {
+	return &pb.StatsReply{WorkId: s.work.ID(), Connections: s.work.Count()}, nil
+}
+
+// Call 向本节点上的连接发送请求事件，并在调用方的截止时间内等待客户端的响应
+func (s *Service) Call(ctx context.Context, req *pb.CallRequest) (*pb.CallReply, error) {
+	data, err := unmarshalData(req.GetData())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	conn := s.work.Local(req.GetId())
+	if conn == nil {
+		return nil, status.Error(codes.NotFound, worker.ErrNotFound.Error())
+	}
+
+	e, err := conn.Request(ctx, req.GetTopic(), data)
+	if err != nil {
+		if errors.Is(err, connection.ErrClosed) {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+
+		// 超时和取消分别返回 DEADLINE_EXCEEDED 和 CANCELED
+		return nil, status.FromContextError(err).Err()
+	}
+
+	reply, err := marshalData(e.Data)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.CallReply{Data: reply}, nil
+}
+
+// marshalData 使用 JSON 编码事件数据
+func marshalData(data any) ([]byte, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	return sonic.Marshal(data)
+}
+
+// unmarshalData 解码 JSON 编码的事件数据，数据为空时返回 nil
+func unmarshalData(b []byte) (any, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	var data any
+	err := sonic.Unmarshal(b, &data)
+	return data, err
+}
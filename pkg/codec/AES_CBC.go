@@ -1,17 +1,14 @@
 package codec
 
 import (
-	"encoding/base64"
-
 	"github.com/bytedance/sonic"
 	"github.com/forgoer/openssl"
 	"github.com/pkg/errors"
 )
 
-// AESCBC 结构体，用于存储 AES-CBC 加密算法的密钥和初始向量
+// AESCBC 结构体，用于存储 AES-CBC 加密算法的密钥环，初始向量与密钥相同
 type AESCBC struct {
-	key []byte // 密钥
-	iv  []byte // 初始向量
+	ring *Keyring // 密钥环
 }
 
 // NewAESCBC 函数，用于创建一个新的 AES-CBC 编码器实例
@@ -28,7 +25,18 @@ func NewAESCBC(key string) ICodec {
 	}
 
 	// 你可能需要处理 key 的长度和格式，这里简单地使用 key 的字节数组
-	return &AESCBC{key: []byte(key), iv: []byte(key)} // 返回一个包含密钥和初始向量的 AESCBC 结构体实例
+	return &AESCBC{ring: newSingleKeyring([]byte(key))} // 返回一个包含密钥和初始向量的 AESCBC 结构体实例
+}
+
+// NewAESCBCWithKeyring 函数，用于创建一个使用密钥环的 AES-CBC 编码器实例，密文中携带密钥 ID
+//
+// 参数：
+//   - ring *Keyring 密钥环
+//
+// 返回值：
+//   - ICodec 返回一个实现了 ICodec 接口的 AESCBC 结构体实例
+func NewAESCBCWithKeyring(ring *Keyring) ICodec {
+	return &AESCBC{ring: ring}
 }
 
 // encrypt 函数，用于对给定的字节数组进行 AES-CBC 加密
@@ -40,9 +48,14 @@ func NewAESCBC(key string) ICodec {
 //   - string 返回经过 Base64 编码后的加密结果字符串
 //   - error 返回错误信息，如果加密过程中出现异常则返回该异常
 func (sc AESCBC) encrypt(src []byte) (string, error) {
-	// 使用 OpenSSL 库中的 AesCBCEncrypt 函数进行加密，并将结果转换为 Base64 编码的字符串
-	dst, _ := openssl.AesCBCEncrypt(src, sc.key, sc.iv, openssl.PKCS7_PADDING)
-	return base64.StdEncoding.EncodeToString(dst), nil
+	// 使用 OpenSSL 库中的 AesCBCEncrypt 函数进行加密，并将结果转换为携带密钥 ID 的 Base64 编码字符串
+	id, key := sc.ring.Active()
+	dst, err := openssl.AesCBCEncrypt(src, key, key, openssl.PKCS7_PADDING)
+	if err != nil {
+		return "", err
+	}
+
+	return sc.ring.seal(id, dst), nil
 }
 
 // decrypt 是 AESCBC 结构体中的一个方法，用于对输入的密文进行解密。
@@ -54,14 +67,56 @@ func (sc AESCBC) encrypt(src []byte) (string, error) {
 // - []byte: 解密后的明文，类型为字节切片。
 // - error: 返回错误信息，如果解密过程中出现错误，则返回相应的错误信息。
 func (sc AESCBC) decrypt(src string) ([]byte, error) {
-	// 对输入的密文进行 base64 解码
-	dst, err := base64.StdEncoding.DecodeString(src)
+	// 根据密文中的密钥 ID 查找密钥，并对密文进行 base64 解码
+	key, dst, err := sc.ring.open(src)
 	if err != nil {
 		return nil, err
 	}
 
 	// 使用 OpenSSL 库的 AesCBCDecrypt 方法对解码后的密文进行 AES-CBC 解密
-	return openssl.AesCBCDecrypt(dst, sc.key, sc.iv, openssl.PKCS7_PADDING)
+	return openssl.AesCBCDecrypt(dst, key, key, openssl.PKCS7_PADDING)
+}
+
+// EncodeBytes 对数据进行 AES-CBC 加密，并将 "<密钥ID长度><密钥ID><密文>" 追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 需要加密的数据
+//
+// 返回值：
+//   - []byte 追加密文后的字节数组
+//   - error 返回错误信息
+func (sc AESCBC) EncodeBytes(dst, src []byte) ([]byte, error) {
+	id, key := sc.ring.Active()
+	body, err := openssl.AesCBCEncrypt(src, key, key, openssl.PKCS7_PADDING)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(sc.ring.appendID(dst, id), body...), nil
+}
+
+// DecodeBytes 根据密文中的密钥 ID 查找密钥进行 AES-CBC 解密，并将明文追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 由 EncodeBytes 生成的密文
+//
+// 返回值：
+//   - []byte 追加明文后的字节数组
+//   - error 如果密钥 ID 不存在或解密失败，则返回相应的错误信息
+func (sc AESCBC) DecodeBytes(dst, src []byte) ([]byte, error) {
+	key, body, err := sc.ring.openBytes(src)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := openssl.AesCBCDecrypt(body, key, key, openssl.PKCS7_PADDING)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dst, data...), nil
 }
 
 // Encode 是 AESCBC 结构体中的一个方法，用于对输入的值进行编码。
@@ -2,7 +2,6 @@ package codec
 
 import (
 	"crypto/rand"
-	"encoding/base64"
 
 	"github.com/bytedance/sonic"
 	"github.com/forgoer/openssl"
@@ -11,7 +10,7 @@ import (
 
 // AESECB 结构体
 type AESECB struct {
-	key []byte
+	ring *Keyring // 密钥环
 }
 
 // NewAESECB 创建一个新的 AES ECB 编码器实例
@@ -28,7 +27,18 @@ func NewAESECB(key string) ICodec {
 	}
 
 	// 你可能需要处理 key 的长度和格式，这里简单地使用 key 的字节数组
-	return &AESECB{key: []byte(key)}
+	return &AESECB{ring: newSingleKeyring([]byte(key))}
+}
+
+// NewAESECBWithKeyring 创建一个使用密钥环的 AES ECB 编码器实例，密文中携带密钥 ID
+//
+// 参数：
+// - ring *Keyring: 密钥环。
+//
+// 返回值：
+// - ICodec: 一个实现了 ICodec 接口的 AES ECB 编码器实例。
+func NewAESECBWithKeyring(ring *Keyring) ICodec {
+	return &AESECB{ring: ring}
 }
 
 // generateRandomKey 生成一个指定长度的随机密钥
@@ -57,8 +67,13 @@ func generateRandomKey(length int) ([]byte, error) {
 // 返回值：
 // - string: 加密后的 Base64 字符串。如果加密过程中出现错误，则返回相应的错误信息。
 func (sc *AESECB) encrypt(src []byte) (string, error) {
-	dst, _ := openssl.AesECBEncrypt(src, sc.key, openssl.PKCS7_PADDING)
-	return base64.StdEncoding.EncodeToString(dst), nil
+	id, key := sc.ring.Active()
+	dst, err := openssl.AesECBEncrypt(src, key, openssl.PKCS7_PADDING)
+	if err != nil {
+		return "", err
+	}
+
+	return sc.ring.seal(id, dst), nil
 }
 
 // decrypt 对输入的 Base64 字符串进行解码，然后进行 ECB 模式解密，最后返回解密后的数据。
@@ -69,12 +84,54 @@ func (sc *AESECB) encrypt(src []byte) (string, error) {
 // 返回值：
 // - []byte: 解密后的数据。如果解密过程中出现错误，则返回相应的错误信息。
 func (sc *AESECB) decrypt(src string) ([]byte, error) {
-	dst, err := base64.StdEncoding.DecodeString(src)
+	key, dst, err := sc.ring.open(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return openssl.AesECBDecrypt(dst, key, openssl.PKCS7_PADDING)
+}
+
+// EncodeBytes 对数据进行 AES-ECB 加密，并将 "<密钥ID长度><密钥ID><密文>" 追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 需要加密的数据
+//
+// 返回值：
+//   - []byte 追加密文后的字节数组
+//   - error 返回错误信息
+func (sc AESECB) EncodeBytes(dst, src []byte) ([]byte, error) {
+	id, key := sc.ring.Active()
+	body, err := openssl.AesECBEncrypt(src, key, openssl.PKCS7_PADDING)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(sc.ring.appendID(dst, id), body...), nil
+}
+
+// DecodeBytes 根据密文中的密钥 ID 查找密钥进行 AES-ECB 解密，并将明文追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 由 EncodeBytes 生成的密文
+//
+// 返回值：
+//   - []byte 追加明文后的字节数组
+//   - error 如果密钥 ID 不存在或解密失败，则返回相应的错误信息
+func (sc AESECB) DecodeBytes(dst, src []byte) ([]byte, error) {
+	key, body, err := sc.ring.openBytes(src)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := openssl.AesECBDecrypt(body, key, openssl.PKCS7_PADDING)
 	if err != nil {
 		return nil, err
 	}
 
-	return openssl.AesECBDecrypt(dst, sc.key, openssl.PKCS7_PADDING)
+	return append(dst, data...), nil
 }
 
 // Encode 是 AESECB 类型的一个方法，用于对输入的值进行编码。
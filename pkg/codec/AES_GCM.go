@@ -0,0 +1,283 @@
+package codec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+	"sync"
+
+	"github.com/bytedance/sonic"
+	"github.com/pkg/errors"
+)
+
+// AESGCM 结构体，使用 AES-GCM 认证加密算法对数据进行编解码
+type AESGCM struct {
+	ring  *Keyring   // 密钥环
+	aeads *aeadCache // 密钥与 AES-GCM 认证加密实例的缓存，避免每条消息重新创建
+}
+
+// aeadCache 结构体缓存密钥对应的 AES-GCM 认证加密实例，密钥环重新加载时清空，
+// 已经移除的密钥不会继续保留在内存中
+type aeadCache struct {
+	lock  sync.RWMutex
+	store map[string]cipher.AEAD
+}
+
+// newAEADCache 创建认证加密实例的缓存，密钥环重新加载时清空缓存
+func newAEADCache(ring *Keyring) *aeadCache {
+	c := &aeadCache{store: make(map[string]cipher.AEAD)}
+	ring.onReload(c.reset)
+	return c
+}
+
+// reset 清空缓存
+func (c *aeadCache) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.store = make(map[string]cipher.AEAD)
+}
+
+// NewAESGCM 创建一个新的 AES-GCM 编码器实例
+//
+// 参数：
+//   - key string 密钥，长度为 16、24 或 32 字节，如果为空则生成一个随机密钥
+//
+// 返回值：
+//   - ICodec 返回一个实现了 ICodec 接口的 AESGCM 结构体实例
+func NewAESGCM(key string) ICodec {
+	if key == "" {
+		newKey, _ := generateRandomKey(32)
+		key = string(newKey)
+	}
+
+	ring := newSingleKeyring([]byte(key))
+	return &AESGCM{ring: ring, aeads: newAEADCache(ring)}
+}
+
+// NewAESGCMWithKeyring 创建一个使用密钥环的 AES-GCM 编码器实例，密文中携带密钥 ID
+//
+// 参数：
+//   - ring *Keyring 密钥环
+//
+// 返回值：
+//   - ICodec 返回一个实现了 ICodec 接口的 AESGCM 结构体实例
+func NewAESGCMWithKeyring(ring *Keyring) ICodec {
+	return &AESGCM{ring: ring, aeads: newAEADCache(ring)}
+}
+
+// newGCM 使用给定的密钥创建 AES-GCM 认证加密实例
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// aead 返回给定密钥的 AES-GCM 认证加密实例，同一个密钥只创建一次
+//
+// 参数：
+//   - key []byte 密钥
+//
+// 返回值：
+//   - cipher.AEAD 认证加密实例，可以并发使用
+//   - error 如果密钥长度不合法，则返回错误信息
+func (sc AESGCM) aead(key []byte) (cipher.AEAD, error) {
+	sc.aeads.lock.RLock()
+	aead, ok := sc.aeads.store[string(key)]
+	sc.aeads.lock.RUnlock()
+	if ok {
+		return aead, nil
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.aeads.lock.Lock()
+	defer sc.aeads.lock.Unlock()
+	if cached, ok := sc.aeads.store[string(key)]; ok {
+		return cached, nil
+	}
+
+	sc.aeads.store[string(key)] = aead
+	return aead, nil
+}
+
+// gcmSeal 使用给定的认证加密实例对数据进行 AES-GCM 加密，并将随机数和密文追加到 dst 中
+//
+// 参数：
+//   - aead cipher.AEAD 认证加密实例
+//   - dst []byte 目标字节数组
+//   - src []byte 需要加密的数据
+//
+// 返回值：
+//   - []byte 追加随机数和密文后的字节数组
+//   - error 返回错误信息，如果加密过程中出现异常则返回该异常
+func gcmSeal(aead cipher.AEAD, dst, src []byte) ([]byte, error) {
+	offset := len(dst)
+	dst = append(dst, make([]byte, aead.NonceSize())...)
+	nonce := dst[offset:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(dst, nonce, src, nil), nil
+}
+
+// gcmOpen 使用给定的认证加密实例对随机数和密文进行 AES-GCM 解密和认证，并将明文追加到 dst 中
+//
+// 参数：
+//   - aead cipher.AEAD 认证加密实例
+//   - dst []byte 目标字节数组
+//   - src []byte 随机数和密文
+//
+// 返回值：
+//   - []byte 追加明文后的字节数组
+//   - error 如果解密或认证失败，则返回相应的错误信息
+func gcmOpen(aead cipher.AEAD, dst, src []byte) ([]byte, error) {
+	size := aead.NonceSize()
+	if len(src) < size {
+		return nil, errors.New("密文长度不足")
+	}
+
+	return aead.Open(dst, src[:size], src[size:], nil)
+}
+
+// encrypt 对输入的数据进行 AES-GCM 加密，随机数放在密文之前，并返回携带密钥 ID 的 Base64 编码字符串
+//
+// 参数：
+//   - src []byte 需要加密的数据
+//
+// 返回值：
+//   - string 加密后的 Base64 字符串
+//   - error 返回错误信息，如果加密过程中出现异常则返回该异常
+func (sc AESGCM) encrypt(src []byte) (string, error) {
+	id, key := sc.ring.Active()
+	aead, err := sc.aead(key)
+	if err != nil {
+		return "", err
+	}
+
+	dst, err := gcmSeal(aead, nil, src)
+	if err != nil {
+		return "", err
+	}
+
+	return sc.ring.seal(id, dst), nil
+}
+
+// decrypt 根据密钥 ID 查找密钥，对输入的 Base64 字符串进行解码，然后进行 AES-GCM 解密和认证
+//
+// 参数：
+//   - src string 需要解密的 Base64 字符串
+//
+// 返回值：
+//   - []byte 解密后的数据
+//   - error 如果解码、解密或认证失败，则返回相应的错误信息
+func (sc AESGCM) decrypt(src string) ([]byte, error) {
+	key, dst, err := sc.ring.open(src)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := sc.aead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcmOpen(aead, nil, dst)
+}
+
+// EncodeBytes 对数据进行 AES-GCM 加密，并将 "<密钥ID长度><密钥ID><随机数><密文>" 追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 需要加密的数据
+//
+// 返回值：
+//   - []byte 追加密文后的字节数组
+//   - error 返回错误信息
+func (sc AESGCM) EncodeBytes(dst, src []byte) ([]byte, error) {
+	id, key := sc.ring.Active()
+	aead, err := sc.aead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcmSeal(aead, sc.ring.appendID(dst, id), src)
+}
+
+// DecodeBytes 根据密文中的密钥 ID 查找密钥，进行 AES-GCM 解密和认证，并将明文追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 由 EncodeBytes 生成的密文
+//
+// 返回值：
+//   - []byte 追加明文后的字节数组
+//   - error 如果密钥 ID 不存在、解密或认证失败，则返回相应的错误信息
+func (sc AESGCM) DecodeBytes(dst, src []byte) ([]byte, error) {
+	key, body, err := sc.ring.openBytes(src)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := sc.aead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcmOpen(aead, dst, body)
+}
+
+// Encode 是 AESGCM 结构体中的一个方法，用于对输入的值进行编码。
+//
+// 参数：
+// - value any: 需要编码的值，类型为任意类型。
+//
+// 返回值：
+// - any: 编码后的值，类型为任意类型。
+// - error: 返回错误信息，如果编码过程中出现错误，则返回相应的错误信息。
+func (sc AESGCM) Encode(value any) (any, error) {
+	// 使用 sonic.Marshal 方法将输入的值编码为 Event 结构体
+	b, err := sonic.Marshal(Event{Value: value})
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化失败")
+	}
+
+	// 调用 encrypt 方法对编码后的数据进行加密，并返回加密后的结果
+	return sc.encrypt(b)
+}
+
+// Decode 是一个解密函数，用于解密输入的值并返回解密后的值和错误信息。
+//
+// 参数：
+// - value any: 需要解密的值，类型为任意类型。
+//
+// 返回值：
+// - any: 解密后的值，类型为任意类型。
+// - error: 返回错误信息，如果解密或解析过程中出现错误，则返回相应的错误信息。
+func (sc AESGCM) Decode(value any) (any, error) {
+	src, ok := value.(string)
+	if !ok {
+		return nil, errors.New("解密失败[1001]: 数据类型错误")
+	}
+
+	// 使用 sc.decrypt 方法对输入的值进行解密和认证
+	data, err := sc.decrypt(src)
+	if err != nil {
+		return nil, errors.Wrap(err, "解密失败[1001]")
+	}
+
+	var event Event
+	// 使用 sonic.Unmarshal 方法将解密后的数据解析为 Event 结构体
+	if err := sonic.Unmarshal(data, &event); err != nil {
+		return nil, errors.Wrap(err, "解析失败[1002]")
+	}
+
+	return event.Value, nil
+}
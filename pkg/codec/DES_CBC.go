@@ -1,8 +1,6 @@
 package codec
 
 import (
-	"encoding/base64"
-
 	"github.com/bytedance/sonic"
 	"github.com/forgoer/openssl"
 	"github.com/pkg/errors"
@@ -10,8 +8,7 @@ import (
 
 // DESCBC 是一个DES加密算法的CBC模式实现
 type DESCBC struct {
-	key []byte
-	iv  []byte
+	ring *Keyring // 密钥环，初始向量与密钥相同
 }
 
 // NewDESCBC 创建一个新的 DESCBC 实例
@@ -22,12 +19,22 @@ type DESCBC struct {
 //   - ICodec 返回一个 DESCBC 实例
 func NewDESCBC(key string) ICodec {
 	if key == "" {
-		newKey, _ := generateRandomKey(32)
+		newKey, _ := generateRandomKey(8)
 		key = string(newKey)
 	}
 
 	// 你可能需要处理 key 的长度和格式，这里简单地使用 key 的字节数组
-	return &DESCBC{key: []byte(key), iv: []byte(key)}
+	return &DESCBC{ring: newSingleKeyring([]byte(key))}
+}
+
+// NewDESCBCWithKeyring 创建一个使用密钥环的 DESCBC 实例，密文中携带密钥 ID
+// 参数：
+//   - ring *Keyring 密钥环
+//
+// 返回值：
+//   - ICodec 返回一个 DESCBC 实例
+func NewDESCBCWithKeyring(ring *Keyring) ICodec {
+	return &DESCBC{ring: ring}
 }
 
 // encrypt 使用给定的密钥和初始化向量对输入的字节切片进行加密，并返回加密后的base64编码字符串和错误信息。
@@ -40,8 +47,13 @@ func NewDESCBC(key string) ICodec {
 // - error 如果在加密过程中发生错误，则返回错误信息。
 func (sc DESCBC) encrypt(src []byte) (string, error) {
 	// 实现加密逻辑
-	dst, _ := openssl.DesCBCEncrypt(src, sc.key, sc.iv, openssl.PKCS7_PADDING)
-	return base64.StdEncoding.EncodeToString(dst), nil
+	id, key := sc.ring.Active()
+	dst, err := openssl.DesCBCEncrypt(src, key, key, openssl.PKCS7_PADDING)
+	if err != nil {
+		return "", err
+	}
+
+	return sc.ring.seal(id, dst), nil
 }
 
 // decrypt 使用给定的密钥和初始化向量对输入的base64编码字符串进行解密，并返回解密后的字节切片和错误信息。
@@ -54,12 +66,54 @@ func (sc DESCBC) encrypt(src []byte) (string, error) {
 // - error 如果在解密过程中发生错误，则返回错误信息。
 func (sc DESCBC) decrypt(src string) ([]byte, error) {
 	// 实现解密逻辑
-	dst, err := base64.StdEncoding.DecodeString(src)
+	key, dst, err := sc.ring.open(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return openssl.DesCBCDecrypt(dst, key, key, openssl.PKCS7_PADDING)
+}
+
+// EncodeBytes 对数据进行 DES-CBC 加密，并将 "<密钥ID长度><密钥ID><密文>" 追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 需要加密的数据
+//
+// 返回值：
+//   - []byte 追加密文后的字节数组
+//   - error 返回错误信息
+func (sc DESCBC) EncodeBytes(dst, src []byte) ([]byte, error) {
+	id, key := sc.ring.Active()
+	body, err := openssl.DesCBCEncrypt(src, key, key, openssl.PKCS7_PADDING)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(sc.ring.appendID(dst, id), body...), nil
+}
+
+// DecodeBytes 根据密文中的密钥 ID 查找密钥进行 DES-CBC 解密，并将明文追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 由 EncodeBytes 生成的密文
+//
+// 返回值：
+//   - []byte 追加明文后的字节数组
+//   - error 如果密钥 ID 不存在或解密失败，则返回相应的错误信息
+func (sc DESCBC) DecodeBytes(dst, src []byte) ([]byte, error) {
+	key, body, err := sc.ring.openBytes(src)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := openssl.DesCBCDecrypt(body, key, key, openssl.PKCS7_PADDING)
 	if err != nil {
 		return nil, err
 	}
 
-	return openssl.DesCBCDecrypt(dst, sc.key, sc.iv, openssl.PKCS7_PADDING)
+	return append(dst, data...), nil
 }
 
 // Encode 将输入的任意类型数据使用给定的密钥和初始化向量进行加密后，再进行base64编码，并返回编码后的数据和错误信息。
@@ -1,8 +1,6 @@
 package codec
 
 import (
-	"encoding/base64"
-
 	"github.com/bytedance/sonic"
 	"github.com/forgoer/openssl"
 	"github.com/pkg/errors"
@@ -10,7 +8,7 @@ import (
 
 // DESECB 是一个使用 DES ECB 模式的解密器
 type DESECB struct {
-	key []byte // 密钥
+	ring *Keyring // 密钥环
 }
 
 // NewDESECB 创建一个新的 DESECB 实例
@@ -21,13 +19,24 @@ type DESECB struct {
 // 返回值：
 // - ICodec 返回一个 DESECB 实例
 func NewDESECB(key string) ICodec {
-	encode := &DESECB{key: []byte(key)}
+	raw := []byte(key)
 	if key == "" {
-		encode.key, _ = generateRandomKey(32)
+		raw, _ = generateRandomKey(8)
 	}
 
 	// 你可能需要处理 key 的长度和格式，这里简单地使用 key 的字节数组
-	return encode
+	return &DESECB{ring: newSingleKeyring(raw)}
+}
+
+// NewDESECBWithKeyring 创建一个使用密钥环的 DESECB 实例，密文中携带密钥 ID
+//
+// 参数：
+// - ring *Keyring 密钥环
+//
+// 返回值：
+// - ICodec 返回一个 DESECB 实例
+func NewDESECBWithKeyring(ring *Keyring) ICodec {
+	return &DESECB{ring: ring}
 }
 
 // encrypt 使用 DES ECB 模式加密数据
@@ -40,8 +49,13 @@ func NewDESECB(key string) ICodec {
 // - error 返回错误信息，如果加密失败则返回 nil
 func (sc DESECB) encrypt(src []byte) (string, error) {
 	// 实现 DES ECB 加密逻辑
-	dst, _ := openssl.DesECBEncrypt(src, []byte(sc.key), openssl.PKCS7_PADDING)
-	return base64.StdEncoding.EncodeToString(dst), nil
+	id, key := sc.ring.Active()
+	dst, err := openssl.DesECBEncrypt(src, key, openssl.PKCS7_PADDING)
+	if err != nil {
+		return "", err
+	}
+
+	return sc.ring.seal(id, dst), nil
 }
 
 // decrypt 使用 DES ECB 模式解密数据
@@ -54,12 +68,54 @@ func (sc DESECB) encrypt(src []byte) (string, error) {
 // - error 返回错误信息，如果解密失败则返回 err
 func (sc DESECB) decrypt(src string) ([]byte, error) {
 	// 实现 DES ECB 解密逻辑
-	dst, err := base64.StdEncoding.DecodeString(src)
+	key, dst, err := sc.ring.open(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return openssl.DesECBDecrypt(dst, key, openssl.PKCS7_PADDING)
+}
+
+// EncodeBytes 对数据进行 DES-ECB 加密，并将 "<密钥ID长度><密钥ID><密文>" 追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 需要加密的数据
+//
+// 返回值：
+//   - []byte 追加密文后的字节数组
+//   - error 返回错误信息
+func (sc DESECB) EncodeBytes(dst, src []byte) ([]byte, error) {
+	id, key := sc.ring.Active()
+	body, err := openssl.DesECBEncrypt(src, key, openssl.PKCS7_PADDING)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(sc.ring.appendID(dst, id), body...), nil
+}
+
+// DecodeBytes 根据密文中的密钥 ID 查找密钥进行 DES-ECB 解密，并将明文追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 由 EncodeBytes 生成的密文
+//
+// 返回值：
+//   - []byte 追加明文后的字节数组
+//   - error 如果密钥 ID 不存在或解密失败，则返回相应的错误信息
+func (sc DESECB) DecodeBytes(dst, src []byte) ([]byte, error) {
+	key, body, err := sc.ring.openBytes(src)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := openssl.DesECBDecrypt(body, key, openssl.PKCS7_PADDING)
 	if err != nil {
 		return nil, err
 	}
 
-	return openssl.DesECBDecrypt(dst, []byte(sc.key), openssl.PKCS7_PADDING)
+	return append(dst, data...), nil
 }
 
 // Encode 将任意类型的数据进行加密并返回加密后的字符串和错误信息
@@ -0,0 +1,48 @@
+package codec
+
+import "sync"
+
+// BytesCodec 接口定义了以字节数组为单位的编解码方法，避免 ICodec 中序列化、Base64 编码和字符串转换带来的内存分配。
+// 方法采用追加的方式写入 dst,调用方可以传入 GetBuffer 获取的缓冲区复用内存。
+//
+// 内置的加密和压缩编解码器都实现了该接口，Default 没有实现，连接上的数据保持原始值。
+type BytesCodec interface {
+	// EncodeBytes 对 src 进行编码，并将结果追加到 dst 中返回，src 和 dst 不能重叠。
+	EncodeBytes(dst, src []byte) ([]byte, error)
+
+	// DecodeBytes 对 src 进行解码，并将结果追加到 dst 中返回，src 和 dst 不能重叠。
+	DecodeBytes(dst, src []byte) ([]byte, error)
+}
+
+// maxPooledBuffer 是放回缓冲池的缓冲区的最大容量，过大的缓冲区直接丢弃，避免长期占用内存
+const maxPooledBuffer = 64 << 10
+
+// buffers 字节数组缓冲池
+var buffers = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+// GetBuffer 从缓冲池中获取一个长度为 0 的缓冲区，使用完毕后需要调用 PutBuffer 放回
+//
+// 返回值：
+//   - *[]byte 缓冲区
+func GetBuffer() *[]byte {
+	b := buffers.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// PutBuffer 将缓冲区放回缓冲池，放回后不能再使用缓冲区中的数据
+//
+// 参数：
+//   - b *[]byte 由 GetBuffer 获取的缓冲区，追加数据后需要将新的切片赋值回 *b
+func PutBuffer(b *[]byte) {
+	if b == nil || cap(*b) > maxPooledBuffer {
+		return
+	}
+
+	buffers.Put(b)
+}
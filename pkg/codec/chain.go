@@ -0,0 +1,220 @@
+package codec
+
+import "github.com/pkg/errors"
+
+// chain 由多个编解码器组成的编解码管道
+type chain []ICodec
+
+// bytesChain 所有编解码器都实现了 BytesCodec 的编解码管道
+type bytesChain struct {
+	chain
+}
+
+// Chain 将多个编解码器组合为一个编解码器。
+// 编码时按顺序依次调用，解码时按相反顺序依次调用，例如 Chain(NewGzip(1024, 0), NewAESGCM(key)) 表示先压缩再加密。
+// 所有编解码器都实现了 BytesCodec 时，返回的编解码器也实现 BytesCodec。
+//
+// 参数：
+//   - codecs ...ICodec 需要组合的编解码器
+//
+// 返回值：
+//   - ICodec 组合后的编解码器
+func Chain(codecs ...ICodec) ICodec {
+	for _, icodec := range codecs {
+		if _, ok := icodec.(BytesCodec); !ok {
+			return chain(codecs)
+		}
+	}
+
+	return bytesChain{chain(codecs)}
+}
+
+// NewPipeline 根据配置列表创建编解码管道
+//
+// 参数：
+//   - confs []Config 编解码器配置，按编码顺序排列
+//   - ring *Keyring 密钥环，未单独配置密钥环的编解码器使用该密钥环，可以为 nil
+//
+// 返回值：
+//   - ICodec 组合后的编解码器
+//   - error 如果任意一个编解码器创建失败，则返回错误信息
+func NewPipeline(confs []Config, ring *Keyring) (ICodec, error) {
+	codecs := make([]ICodec, 0, len(confs))
+	for _, conf := range confs {
+		if conf.Keyring == nil {
+			conf.Keyring = ring
+		}
+
+		icodec, err := Build(conf)
+		if err != nil {
+			return nil, err
+		}
+
+		codecs = append(codecs, icodec)
+	}
+
+	return Chain(codecs...), nil
+}
+
+// Encode 按顺序调用每个编解码器对值进行编码
+//
+// 参数：
+//   - value any 需要编码的值
+//
+// 返回值：
+//   - any 编码后的值
+//   - error 返回错误信息
+func (c chain) Encode(value any) (any, error) {
+	var err error
+	for i, icodec := range c {
+		if value, err = icodec.Encode(value); err != nil {
+			return nil, errors.Wrapf(err, "编码失败[%d]", i)
+		}
+	}
+
+	return value, nil
+}
+
+// Decode 按相反顺序调用每个编解码器对值进行解码
+//
+// 参数：
+//   - value any 需要解码的值
+//
+// 返回值：
+//   - any 解码后的值
+//   - error 返回错误信息
+func (c chain) Decode(value any) (any, error) {
+	var err error
+	for i := len(c) - 1; i >= 0; i-- {
+		if value, err = c[i].Decode(value); err != nil {
+			return nil, errors.Wrapf(err, "解码失败[%d]", i)
+		}
+	}
+
+	return value, nil
+}
+
+// EncodeEvent 按顺序调用每个编解码器对事件数据进行编码，实现了 EventCodec 的编解码器可以获取事件的元数据
+//
+// 参数：
+//   - meta Meta 事件的元数据
+//   - value any 需要编码的值
+//
+// 返回值：
+//   - any 编码后的值
+//   - error 返回错误信息
+func (c chain) EncodeEvent(meta Meta, value any) (any, error) {
+	var err error
+	for i, icodec := range c {
+		if ec, ok := icodec.(EventCodec); ok {
+			value, err = ec.EncodeEvent(meta, value)
+		} else {
+			value, err = icodec.Encode(value)
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "编码失败[%d]", i)
+		}
+	}
+
+	return value, nil
+}
+
+// DecodeEvent 按相反顺序调用每个编解码器对事件数据进行解码，实现了 EventCodec 的编解码器可以获取事件的元数据
+//
+// 参数：
+//   - meta Meta 事件的元数据
+//   - value any 需要解码的值
+//
+// 返回值：
+//   - any 解码后的值
+//   - error 返回错误信息
+func (c chain) DecodeEvent(meta Meta, value any) (any, error) {
+	var err error
+	for i := len(c) - 1; i >= 0; i-- {
+		if ec, ok := c[i].(EventCodec); ok {
+			value, err = ec.DecodeEvent(meta, value)
+		} else {
+			value, err = c[i].Decode(value)
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "解码失败[%d]", i)
+		}
+	}
+
+	return value, nil
+}
+
+// EncodeBytes 按顺序调用每个编解码器对数据进行编码，中间结果使用缓冲池中的缓冲区
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 需要编码的数据
+//
+// 返回值：
+//   - []byte 追加编码结果后的字节数组
+//   - error 返回错误信息
+func (c bytesChain) EncodeBytes(dst, src []byte) ([]byte, error) {
+	return c.apply(dst, src, func(i int) (int, func(dst, src []byte) ([]byte, error)) {
+		return i, c.chain[i].(BytesCodec).EncodeBytes
+	})
+}
+
+// DecodeBytes 按相反顺序调用每个编解码器对数据进行解码，中间结果使用缓冲池中的缓冲区
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 需要解码的数据
+//
+// 返回值：
+//   - []byte 追加解码结果后的字节数组
+//   - error 返回错误信息
+func (c bytesChain) DecodeBytes(dst, src []byte) ([]byte, error) {
+	return c.apply(dst, src, func(i int) (int, func(dst, src []byte) ([]byte, error)) {
+		i = len(c.chain) - 1 - i
+		return i, c.chain[i].(BytesCodec).DecodeBytes
+	})
+}
+
+// apply 依次执行每一步编解码，最后一步的结果追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 需要处理的数据
+//   - step func(int) (int, func(dst, src []byte) ([]byte, error)) 根据步骤返回编解码器下标和处理函数
+//
+// 返回值：
+//   - []byte 追加处理结果后的字节数组
+//   - error 返回错误信息
+func (c bytesChain) apply(dst, src []byte, step func(int) (int, func(dst, src []byte) ([]byte, error))) ([]byte, error) {
+	if len(c.chain) == 0 {
+		return append(dst, src...), nil
+	}
+
+	// prev 保存上一步的结果，在下一步处理完成后放回缓冲池
+	var prev *[]byte
+	defer func() { PutBuffer(prev) }()
+
+	for n := 0; ; n++ {
+		i, fn := step(n)
+		if n == len(c.chain)-1 {
+			out, err := fn(dst, src)
+			if err != nil {
+				return nil, errors.Wrapf(err, "编解码失败[%d]", i)
+			}
+
+			return out, nil
+		}
+
+		buf := GetBuffer()
+		out, err := fn(*buf, src)
+		PutBuffer(prev)
+		prev = buf
+		if err != nil {
+			return nil, errors.Wrapf(err, "编解码失败[%d]", i)
+		}
+
+		*buf, src = out, out
+	}
+}
@@ -0,0 +1,52 @@
+package codec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	icodec, err := NewPipeline([]Config{
+		{Name: "GZIP", Threshold: 64},
+		{Name: "AESGCM", Secret: "1234567890123456"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range []any{"hello", strings.Repeat("hello", 100)} {
+		encoded, err := icodec.Encode(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decoded, err := icodec.Decode(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded != value {
+			t.Fatalf("unexpected value %v", decoded)
+		}
+
+		t.Log("raw", len(value.(string)), "encoded", len(encoded.(string)))
+	}
+}
+
+func TestGzipLimit(t *testing.T) {
+	bc := NewGzipLimit(0, 0, 1024).(BytesCodec)
+	encoded, err := bc.EncodeBytes(nil, make([]byte, 1025))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 解压后超过最大字节数的数据被拒绝
+	if _, err := bc.DecodeBytes(nil, encoded); err == nil {
+		t.Fatal("expected limit error")
+	}
+
+	encoded, _ = bc.EncodeBytes(nil, make([]byte, 1024))
+	if decoded, err := bc.DecodeBytes(nil, encoded); err != nil || len(decoded) != 1024 {
+		t.Fatalf("unexpected decoded %d %v", len(decoded), err)
+	}
+}
@@ -1,7 +1,5 @@
 package codec
 
-import "strings"
-
 // ICodec 接口定义了编码和解码方法，用于对任意类型的数据进行编解码操作。
 type ICodec interface {
 	// Encode 方法接受一个任意类型的参数 value,返回一个 any 类型的结果和一个 error 类型的错误信息。
@@ -14,25 +12,25 @@ type ICodec interface {
 // New 返回一个基于提供的类型和密钥的新 ICodec 实现。
 //
 // 参数：
-// - typec: 一个表示编解码器类型的字符串。
+// - typec: 一个表示编解码器类型的字符串，需要通过 Register 注册，为空时使用 Default。
 // - secret: 一个表示加密密钥的字符串。
 //
 // 返回值：
-// - resp: 一个根据提供的类型确定的 ICodec 实例。
-func New(typec, secret string) ICodec {
-	var resp ICodec
-	switch strings.ToUpper(typec) {
-	case "AESCBC":
-		resp = NewAESCBC(secret)
-	case "AESECB":
-		resp = NewAESECB(secret)
-	case "DESCBC":
-		resp = NewDESCBC(secret)
-	case "DESECB":
-		resp = NewDESECB(secret)
-	default:
-		resp = NewDefault()
-	}
+// - ICodec: 一个根据提供的类型确定的 ICodec 实例。
+// - error: 如果类型未注册或密钥长度不合法，则返回错误信息。
+func New(typec, secret string) (ICodec, error) {
+	return Build(Config{Name: typec, Secret: secret})
+}
 
-	return resp
+// NewWithKeyring 返回一个基于提供的类型和密钥环的新 ICodec 实现，密文中携带密钥 ID 以支持密钥轮换。
+//
+// 参数：
+// - typec: 一个表示编解码器类型的字符串，需要通过 Register 注册。
+// - ring: 密钥环。
+//
+// 返回值：
+// - ICodec: 一个根据提供的类型确定的 ICodec 实例。
+// - error: 如果类型未注册或密钥环中的密钥长度不合法，则返回错误信息。
+func NewWithKeyring(typec string, ring *Keyring) (ICodec, error) {
+	return Build(Config{Name: typec, Keyring: ring})
 }
@@ -0,0 +1,193 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"sync"
+
+	"github.com/bytedance/sonic"
+	"github.com/pkg/errors"
+)
+
+const (
+	flagRaw  byte = 0 // 数据未压缩
+	flagGzip byte = 1 // 数据使用 gzip 压缩
+)
+
+// DefaultGzipLimit 是默认允许的解压后的最大字节数
+const DefaultGzipLimit = 16 << 20
+
+// Gzip 结构体，对序列化后超过阈值的数据进行 gzip 压缩
+type Gzip struct {
+	threshold int       // 压缩阈值(字节),小于该值的数据不压缩
+	level     int       // 压缩级别
+	limit     int       // 解压后的最大字节数，防止压缩炸弹
+	writers   sync.Pool // gzip.Writer 复用池
+}
+
+// NewGzip 创建一个新的 gzip 压缩编解码器
+//
+// 参数：
+//   - threshold int 压缩阈值(字节),序列化后小于该值的数据不压缩，小于 1 时全部压缩
+//   - level int 压缩级别，为 0 时使用 gzip.DefaultCompression
+//
+// 返回值：
+//   - ICodec 返回一个实现了 ICodec 接口的 Gzip 结构体实例
+func NewGzip(threshold, level int) ICodec {
+	return NewGzipLimit(threshold, level, 0)
+}
+
+// NewGzipLimit 创建一个限制解压后大小的 gzip 压缩编解码器
+//
+// 参数：
+//   - threshold int 压缩阈值(字节),序列化后小于该值的数据不压缩，小于 1 时全部压缩
+//   - level int 压缩级别，为 0 时使用 gzip.DefaultCompression
+//   - limit int 解压后的最大字节数，超过时解码失败，小于 1 时使用 DefaultGzipLimit
+//
+// 返回值：
+//   - ICodec 返回一个实现了 ICodec 接口的 Gzip 结构体实例
+func NewGzipLimit(threshold, level, limit int) ICodec {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	if limit < 1 {
+		limit = DefaultGzipLimit
+	}
+
+	return &Gzip{threshold: threshold, level: level, limit: limit}
+}
+
+// EncodeBytes 按阈值决定是否压缩数据，并将 "<压缩标记><数据>" 追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 需要压缩的数据
+//
+// 返回值：
+//   - []byte 追加压缩结果后的字节数组
+//   - error 返回错误信息
+func (sc *Gzip) EncodeBytes(dst, src []byte) ([]byte, error) {
+	if len(src) < sc.threshold {
+		return append(append(dst, flagRaw), src...), nil
+	}
+
+	buf := bytes.NewBuffer(append(dst, flagGzip))
+	w, ok := sc.writers.Get().(*gzip.Writer)
+	if ok {
+		w.Reset(buf)
+	} else {
+		var err error
+		if w, err = gzip.NewWriterLevel(buf, sc.level); err != nil {
+			return nil, err
+		}
+	}
+	defer sc.writers.Put(w)
+
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecodeBytes 按压缩标记解压数据，并将结果追加到 dst 中
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - src []byte 由 EncodeBytes 生成的数据
+//
+// 返回值：
+//   - []byte 追加解压结果后的字节数组
+//   - error 如果数据为空、压缩标记未知、解压失败或解压后超过最大字节数，则返回错误信息
+func (sc *Gzip) DecodeBytes(dst, src []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, errors.New("数据为空")
+	}
+
+	body := src[1:]
+	switch src[0] {
+	case flagRaw:
+		return append(dst, body...), nil
+	case flagGzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		// 多读取一个字节，判断解压后的数据是否超过最大字节数
+		buf := bytes.NewBuffer(dst)
+		n, err := io.Copy(buf, io.LimitReader(r, int64(sc.limit)+1))
+		if err != nil {
+			return nil, err
+		}
+
+		if n > int64(sc.limit) {
+			return nil, errors.Errorf("解压后的数据超过 %d 字节", sc.limit)
+		}
+
+		return buf.Bytes(), nil
+	default:
+		return nil, errors.Errorf("未知的压缩标记 %d", src[0])
+	}
+}
+
+// Encode 将值序列化后按阈值决定是否压缩，并返回带压缩标记的 Base64 字符串
+//
+// 参数：
+//   - value any 需要编码的值
+//
+// 返回值：
+//   - any 编码后的 Base64 字符串
+//   - error 返回错误信息
+func (sc *Gzip) Encode(value any) (any, error) {
+	b, err := sonic.Marshal(Event{Value: value})
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化失败")
+	}
+
+	dst, err := sc.EncodeBytes(nil, b)
+	if err != nil {
+		return nil, errors.Wrap(err, "压缩失败")
+	}
+
+	return base64.StdEncoding.EncodeToString(dst), nil
+}
+
+// Decode 对 Base64 字符串进行解码，按压缩标记解压后解析为原始值
+//
+// 参数：
+//   - value any 需要解码的 Base64 字符串
+//
+// 返回值：
+//   - any 解码后的值
+//   - error 返回错误信息
+func (sc *Gzip) Decode(value any) (any, error) {
+	src, ok := value.(string)
+	if !ok {
+		return nil, errors.New("解压失败[1001]: 数据类型错误")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(src)
+	if err != nil {
+		return nil, errors.Wrap(err, "解压失败[1001]")
+	}
+
+	body, err := sc.DecodeBytes(nil, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "解压失败[1001]")
+	}
+
+	var event Event
+	if err := sonic.Unmarshal(body, &event); err != nil {
+		return nil, errors.Wrap(err, "解析失败[1002]")
+	}
+
+	return event.Value, nil
+}
@@ -0,0 +1,254 @@
+package codec
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/pkg/errors"
+)
+
+const (
+	hmacNonceSize = 16               // 随机数长度(字节)
+	hmacTimeSize  = 8                // 时间戳长度(字节)
+	hmacWindow    = time.Second * 30 // 默认允许的时钟偏差
+)
+
+var (
+	// ErrSignature 表示签名校验失败，消息可能被篡改
+	ErrSignature = errors.New("签名校验失败")
+
+	// ErrExpired 表示消息的时间戳超出了允许的时钟偏差
+	ErrExpired = errors.New("消息已过期")
+
+	// ErrReplay 表示消息的随机数在时间窗口内已经出现过，消息可能被重放
+	ErrReplay = errors.New("消息重复")
+)
+
+// Meta 表示编解码时事件数据之外的元数据，签名时一并绑定，避免消息被转移到其他主题、请求或方向
+type Meta struct {
+	Topic  string // 事件主题
+	Seq    int64  // 请求序号
+	Reply  int64  // 响应对应的请求序号
+	Client bool   // 事件是否由客户端发送
+}
+
+// EventCodec 是编解码器的可选接口，实现该接口的编解码器在编解码时可以获取事件的元数据，
+// 连接会优先调用这两个方法，使签名等操作能够绑定事件主题、请求序号和发送方向。
+type EventCodec interface {
+	// EncodeEvent 对事件数据进行编码
+	EncodeEvent(meta Meta, value any) (any, error)
+
+	// DecodeEvent 对事件数据进行解码
+	DecodeEvent(meta Meta, value any) (any, error)
+}
+
+// HMAC 结构体，使用 HMAC-SHA256 对事件签名，只保证完整性，不加密数据。
+// 签名覆盖事件主题、请求序号、响应序号、发送方向、数据、时间戳和随机数，解码时拒绝超出时间窗口或随机数重复的消息。
+// 双方使用相同的密钥，发送方向使一方签名的消息不能被反射回它自己。
+type HMAC struct {
+	ring   *Keyring             // 密钥环
+	window time.Duration        // 允许的时钟偏差
+	lock   sync.Mutex           // 互斥锁，保护 seen
+	seen   map[string]time.Time // 时间窗口内出现过的随机数及其过期时间
+	prune  time.Time            // 下次清理过期随机数的时间
+	now    func() time.Time     // 当前时间，便于测试
+}
+
+// NewHMAC 创建一个新的 HMAC-SHA256 签名编解码器
+//
+// 参数：
+//   - key string 签名密钥，长度不少于 16 字节，如果为空则生成一个随机密钥
+//   - window time.Duration 允许的时钟偏差，小于等于 0 时为 30 秒
+//
+// 返回值：
+//   - ICodec 返回一个实现了 ICodec 和 EventCodec 接口的 HMAC 结构体实例
+func NewHMAC(key string, window time.Duration) ICodec {
+	if key == "" {
+		newKey, _ := generateRandomKey(32)
+		key = string(newKey)
+	}
+
+	return NewHMACWithKeyring(newSingleKeyring([]byte(key)), window)
+}
+
+// NewHMACWithKeyring 创建一个使用密钥环的 HMAC-SHA256 签名编解码器，签名中携带密钥 ID
+//
+// 参数：
+//   - ring *Keyring 密钥环
+//   - window time.Duration 允许的时钟偏差，小于等于 0 时为 30 秒
+//
+// 返回值：
+//   - ICodec 返回一个实现了 ICodec 和 EventCodec 接口的 HMAC 结构体实例
+func NewHMACWithKeyring(ring *Keyring, window time.Duration) ICodec {
+	if window <= 0 {
+		window = hmacWindow
+	}
+
+	return &HMAC{ring: ring, window: window, seen: make(map[string]time.Time), now: time.Now}
+}
+
+// sign 计算发送方向、事件主题、请求序号、响应序号、随机数、时间戳和数据的 HMAC-SHA256 签名
+//
+// 参数：
+//   - key []byte 签名密钥
+//   - meta Meta 事件的元数据
+//   - header []byte 随机数和时间戳
+//   - body []byte 序列化后的数据
+//
+// 返回值：
+//   - []byte 签名
+func (sc *HMAC) sign(key []byte, meta Meta, header, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	var fixed [21]byte
+	if meta.Client {
+		fixed[0] = 1
+	}
+
+	binary.BigEndian.PutUint32(fixed[1:], uint32(len(meta.Topic)))
+	binary.BigEndian.PutUint64(fixed[5:], uint64(meta.Seq))
+	binary.BigEndian.PutUint64(fixed[13:], uint64(meta.Reply))
+	mac.Write(fixed[:])
+	mac.Write([]byte(meta.Topic))
+	mac.Write(header)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// remember 记录随机数，如果随机数在时间窗口内已经出现过则返回 ErrReplay
+//
+// 参数：
+//   - nonce []byte 随机数
+//   - expire time.Time 随机数的过期时间，过期后消息会因超出时间窗口被拒绝，无需继续记录
+//   - now time.Time 当前时间
+//
+// 返回值：
+//   - error 随机数重复时返回 ErrReplay
+func (sc *HMAC) remember(nonce []byte, expire, now time.Time) error {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	// 定期清理过期的随机数，避免记录无限增长
+	if now.After(sc.prune) {
+		for key, t := range sc.seen {
+			if now.After(t) {
+				delete(sc.seen, key)
+			}
+		}
+
+		sc.prune = now.Add(sc.window)
+	}
+
+	key := string(nonce)
+	if t, ok := sc.seen[key]; ok && !now.After(t) {
+		return ErrReplay
+	}
+
+	sc.seen[key] = expire
+	return nil
+}
+
+// EncodeEvent 对事件数据签名，并返回 "<密钥ID>.<Base64>" 格式的字符串，
+// Base64 部分为 "<随机数><时间戳><数据><签名>"。
+//
+// 参数：
+//   - meta Meta 事件的元数据，Client 为发送方是否为客户端
+//   - value any 需要签名的值
+//
+// 返回值：
+//   - any 签名后的字符串
+//   - error 返回错误信息
+func (sc *HMAC) EncodeEvent(meta Meta, value any) (any, error) {
+	body, err := sonic.Marshal(Event{Value: value})
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化失败")
+	}
+
+	header := make([]byte, hmacNonceSize+hmacTimeSize, hmacNonceSize+hmacTimeSize+len(body)+sha256.Size)
+	if _, err := io.ReadFull(rand.Reader, header[:hmacNonceSize]); err != nil {
+		return nil, errors.Wrap(err, "生成随机数失败")
+	}
+
+	binary.BigEndian.PutUint64(header[hmacNonceSize:], uint64(sc.now().UnixMilli()))
+	id, key := sc.ring.Active()
+	dst := append(header, body...)
+	dst = append(dst, sc.sign(key, meta, header, body)...)
+	return sc.ring.seal(id, dst), nil
+}
+
+// DecodeEvent 校验事件数据的签名、时间戳和随机数，并返回原始值
+//
+// 参数：
+//   - meta Meta 事件的元数据，与签名时的元数据不一致时校验失败，Client 为发送方是否为客户端
+//   - value any 签名后的字符串
+//
+// 返回值：
+//   - any 原始值
+//   - error 签名错误、超出时间窗口或随机数重复时，返回的错误可以通过 errors.Is 判断为 ErrSignature、ErrExpired 或 ErrReplay
+func (sc *HMAC) DecodeEvent(meta Meta, value any) (any, error) {
+	src, ok := value.(string)
+	if !ok {
+		return nil, errors.Wrap(ErrSignature, "校验失败[1001]: 数据类型错误")
+	}
+
+	key, data, err := sc.ring.open(src)
+	if err != nil {
+		return nil, errors.Wrapf(ErrSignature, "校验失败[1001]: %v", err)
+	}
+
+	size := hmacNonceSize + hmacTimeSize
+	if len(data) < size+sha256.Size {
+		return nil, errors.Wrap(ErrSignature, "校验失败[1001]: 数据长度不足")
+	}
+
+	header, body, sum := data[:size], data[size:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if !hmac.Equal(sum, sc.sign(key, meta, header, body)) {
+		return nil, errors.Wrap(ErrSignature, "校验失败[1001]")
+	}
+
+	now := sc.now()
+	sent := time.UnixMilli(int64(binary.BigEndian.Uint64(header[hmacNonceSize:])))
+	if sent.Before(now.Add(-sc.window)) || sent.After(now.Add(sc.window)) {
+		return nil, errors.Wrapf(ErrExpired, "校验失败[1002]: 时间偏差 %v", now.Sub(sent))
+	}
+
+	if err := sc.remember(header[:hmacNonceSize], sent.Add(sc.window), now); err != nil {
+		return nil, errors.Wrap(err, "校验失败[1003]")
+	}
+
+	var event Event
+	if err := sonic.Unmarshal(body, &event); err != nil {
+		return nil, errors.Wrap(err, "解析失败[1004]")
+	}
+
+	return event.Value, nil
+}
+
+// Encode 对值签名，不绑定事件的元数据
+//
+// 参数：
+//   - value any 需要签名的值
+//
+// 返回值：
+//   - any 签名后的字符串
+//   - error 返回错误信息
+func (sc *HMAC) Encode(value any) (any, error) {
+	return sc.EncodeEvent(Meta{}, value)
+}
+
+// Decode 校验不绑定事件元数据的签名，并返回原始值
+//
+// 参数：
+//   - value any 签名后的字符串
+//
+// 返回值：
+//   - any 原始值
+//   - error 返回错误信息
+func (sc *HMAC) Decode(value any) (any, error) {
+	return sc.DecodeEvent(Meta{}, value)
+}
@@ -0,0 +1,129 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestHMAC(t *testing.T) {
+	sc := NewHMAC("1234567890123456", time.Second*30).(*HMAC)
+
+	encoded, err := sc.EncodeEvent(Meta{Topic: "msg"}, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		decoded, err := sc.DecodeEvent(Meta{Topic: "msg"}, encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded != "hello" {
+			t.Fatalf("unexpected value %v", decoded)
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		if _, err := sc.DecodeEvent(Meta{Topic: "msg"}, encoded); !errors.Is(err, ErrReplay) {
+			t.Fatalf("expected ErrReplay, got %v", err)
+		}
+	})
+
+	t.Run("topic", func(t *testing.T) {
+		encoded, _ := sc.EncodeEvent(Meta{Topic: "msg"}, "hello")
+		if _, err := sc.DecodeEvent(Meta{Topic: "other"}, encoded); !errors.Is(err, ErrSignature) {
+			t.Fatalf("expected ErrSignature, got %v", err)
+		}
+	})
+
+	// 签名绑定响应对应的请求序号，响应不能被转移给其他等待中的请求
+	t.Run("reply", func(t *testing.T) {
+		encoded, _ := sc.EncodeEvent(Meta{Topic: "msg", Reply: 1}, "hello")
+		if _, err := sc.DecodeEvent(Meta{Topic: "msg", Reply: 2}, encoded); !errors.Is(err, ErrSignature) {
+			t.Fatalf("expected ErrSignature, got %v", err)
+		}
+
+		encoded, _ = sc.EncodeEvent(Meta{Topic: "msg", Seq: 1}, "hello")
+		if _, err := sc.DecodeEvent(Meta{Topic: "msg", Seq: 2}, encoded); !errors.Is(err, ErrSignature) {
+			t.Fatalf("expected ErrSignature, got %v", err)
+		}
+	})
+
+	// 双方使用相同的密钥，服务端发送的消息被反射回服务端时，发送方向不一致，校验失败
+	t.Run("reflect", func(t *testing.T) {
+		server := NewHMAC("1234567890123456", time.Second*30).(*HMAC)
+		client := NewHMAC("1234567890123456", time.Second*30).(*HMAC)
+		encoded, _ := server.EncodeEvent(Meta{Topic: "msg"}, "hello")
+		if _, err := server.DecodeEvent(Meta{Topic: "msg", Client: true}, encoded); !errors.Is(err, ErrSignature) {
+			t.Fatalf("expected ErrSignature, got %v", err)
+		}
+
+		if _, err := client.DecodeEvent(Meta{Topic: "msg"}, encoded); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("tamper", func(t *testing.T) {
+		encoded, _ := sc.EncodeEvent(Meta{Topic: "msg"}, "hello")
+		b := []byte(encoded.(string))
+		b[len(b)/2] ^= 1
+		if _, err := sc.DecodeEvent(Meta{Topic: "msg"}, string(b)); !errors.Is(err, ErrSignature) {
+			t.Fatalf("expected ErrSignature, got %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		sender := NewHMAC("1234567890123456", time.Second*30).(*HMAC)
+		sender.now = func() time.Time { return time.Now().Add(-time.Minute) }
+		encoded, _ := sender.EncodeEvent(Meta{Topic: "msg"}, "hello")
+		if _, err := sc.DecodeEvent(Meta{Topic: "msg"}, encoded); !errors.Is(err, ErrExpired) {
+			t.Fatalf("expected ErrExpired, got %v", err)
+		}
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		now := time.Now().Add(time.Minute * 5)
+		if err := sc.remember([]byte("nonce"), now.Add(time.Second), now); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(sc.seen) != 1 {
+			t.Fatalf("expired nonces are not pruned: %d", len(sc.seen))
+		}
+	})
+}
+
+func TestHMACConfig(t *testing.T) {
+	var conf Config
+	if err := yaml.Unmarshal([]byte("Name: HMAC\nSecret: \"1234567890123456\"\nWindow: 5s\n"), &conf); err != nil {
+		t.Fatal(err)
+	}
+
+	icodec, err := Build(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if window := icodec.(*HMAC).window; window != time.Second*5 {
+		t.Fatalf("unexpected window %v", window)
+	}
+
+	if _, err := Build(Config{Name: "HMAC", Secret: "short"}); err == nil {
+		t.Fatal("expected key length error")
+	}
+
+	// 管道中的签名编解码器同样绑定事件主题
+	pipeline := Chain(NewGzip(0, 0), NewHMAC("1234567890123456", 0)).(EventCodec)
+	encoded, err := pipeline.EncodeEvent(Meta{Topic: "msg"}, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pipeline.DecodeEvent(Meta{Topic: "other"}, encoded); !errors.Is(err, ErrSignature) {
+		t.Fatalf("expected ErrSignature, got %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+package codec
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// keySeparator 是密文中密钥 ID 与 Base64 数据之间的分隔符，不属于 Base64 字符集
+const keySeparator = "."
+
+// maxKeyID 是密钥 ID 的最大长度，字节数组格式的密文使用一个字节记录密钥 ID 的长度
+const maxKeyID = 255
+
+// KeyFile 表示密钥文件的内容
+type KeyFile struct {
+	Active string            `yaml:"Active"` // 用于加密的活动密钥 ID
+	Keys   map[string]string `yaml:"Keys"`   // 所有可用于解密的密钥，键为密钥 ID
+}
+
+// Keyring 密钥环，包含一个用于加密的活动密钥和多个仍可用于解密的密钥，支持运行时重新加载
+type Keyring struct {
+	lock   sync.RWMutex      // 读写锁
+	active string            // 活动密钥 ID
+	keys   map[string][]byte // 密钥 ID 与密钥的映射
+	checks []func(int) error // 使用密钥环的编解码器的密钥长度校验函数，重新加载时对新的密钥执行
+	hooks  []func()          // 重新加载成功后调用的函数，编解码器用于清理旧密钥的缓存
+}
+
+// NewKeyring 创建一个新的密钥环
+//
+// 参数：
+//   - active string 用于加密的活动密钥 ID,必须存在于 keys 中
+//   - keys map[string]string 密钥 ID 与密钥的映射，密钥 ID 为空字符串时密文不携带密钥 ID
+//
+// 返回值：
+//   - *Keyring 密钥环实例
+//   - error 如果活动密钥不存在或密钥 ID 不合法，则返回错误信息
+func NewKeyring(active string, keys map[string]string) (*Keyring, error) {
+	ring := &Keyring{}
+	if err := ring.Reload(active, keys); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// newSingleKeyring 创建只包含一个无 ID 密钥的密钥环，密文格式与未使用密钥环时一致
+func newSingleKeyring(key []byte) *Keyring {
+	return &Keyring{keys: map[string][]byte{"": key}}
+}
+
+// LoadKeyring 从 YAML 文件中加载密钥环
+//
+// 参数：
+//   - path string 密钥文件路径
+//
+// 返回值：
+//   - *Keyring 密钥环实例
+//   - error 返回错误信息
+func LoadKeyring(path string) (*Keyring, error) {
+	ring := &Keyring{}
+	if err := ring.Load(path); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// Reload 替换密钥环中的密钥，正在进行的编解码不受影响。
+// 新的密钥需要通过使用该密钥环的编解码器创建时的长度校验，例如 AES 密钥必须为 16、24 或 32 字节。
+//
+// 参数：
+//   - active string 用于加密的活动密钥 ID
+//   - keys map[string]string 密钥 ID 与密钥的映射
+//
+// 返回值：
+//   - error 如果活动密钥不存在、密钥 ID 不合法或密钥长度不合法，则返回错误信息，此时密钥环保持不变
+func (k *Keyring) Reload(active string, keys map[string]string) error {
+	store := make(map[string][]byte, len(keys))
+	for id, key := range keys {
+		if strings.Contains(id, keySeparator) {
+			return errors.Errorf("密钥 ID %q 不能包含 %q", id, keySeparator)
+		}
+
+		if len(id) > maxKeyID {
+			return errors.Errorf("密钥 ID %q 长度不能超过 %d", id, maxKeyID)
+		}
+
+		store[id] = []byte(key)
+	}
+
+	if _, ok := store[active]; !ok {
+		return errors.Errorf("活动密钥 %q 不存在", active)
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	for _, fn := range k.checks {
+		if err := checkKeys(store, fn); err != nil {
+			return err
+		}
+	}
+
+	k.active = active
+	k.keys = store
+	for _, fn := range k.hooks {
+		fn()
+	}
+
+	return nil
+}
+
+// Load 从 YAML 文件中重新加载密钥环
+//
+// 参数：
+//   - path string 密钥文件路径
+//
+// 返回值：
+//   - error 返回错误信息，此时密钥环保持不变
+func (k *Keyring) Load(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "读取密钥文件失败")
+	}
+
+	var file KeyFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return errors.Wrap(err, "解析密钥文件失败")
+	}
+
+	return k.Reload(file.Active, file.Keys)
+}
+
+// Watch 定期检查密钥文件的修改时间，文件变化时重新加载密钥环，直到上下文被取消
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - path string 密钥文件路径
+//   - interval time.Duration 检查间隔
+//   - onError func(error) 重新加载失败时的回调函数，可以为 nil
+func (k *Keyring) Watch(ctx context.Context, path string, interval time.Duration, onError func(error)) {
+	var modified time.Time
+	if info, err := os.Stat(path); err == nil {
+		modified = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(modified) {
+				continue
+			}
+
+			modified = info.ModTime()
+			if err := k.Load(path); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Active 返回当前用于加密的密钥 ID 和密钥
+func (k *Keyring) Active() (string, []byte) {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.active, k.keys[k.active]
+}
+
+// Get 根据密钥 ID 查找用于解密的密钥
+//
+// 参数：
+//   - id string 密钥 ID
+//
+// 返回值：
+//   - []byte 密钥
+//   - bool 密钥是否存在
+func (k *Keyring) Get(id string) ([]byte, bool) {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+// check 使用给定的函数校验密钥环中所有密钥的长度，校验通过后记录该函数，重新加载时同样校验新的密钥
+func (k *Keyring) check(fn func(int) error) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if err := checkKeys(k.keys, fn); err != nil {
+		return err
+	}
+
+	k.checks = append(k.checks, fn)
+	return nil
+}
+
+// onReload 记录重新加载成功后调用的函数
+func (k *Keyring) onReload(fn func()) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.hooks = append(k.hooks, fn)
+}
+
+// checkKeys 使用给定的函数校验所有密钥的长度
+func checkKeys(keys map[string][]byte, fn func(int) error) error {
+	for id, key := range keys {
+		if err := fn(len(key)); err != nil {
+			return errors.Wrapf(err, "密钥 %q", id)
+		}
+	}
+
+	return nil
+}
+
+// seal 将加密后的数据编码为 Base64 字符串，并在前面加上密钥 ID
+//
+// 参数：
+//   - id string 加密使用的密钥 ID
+//   - dst []byte 加密后的数据
+//
+// 返回值：
+//   - string 格式为 "<密钥ID>.<Base64>",密钥 ID 为空时只有 Base64 部分
+func (k *Keyring) seal(id string, dst []byte) string {
+	body := base64.StdEncoding.EncodeToString(dst)
+	if id == "" {
+		return body
+	}
+
+	return id + keySeparator + body
+}
+
+// open 解析密文中的密钥 ID,并返回对应的密钥和解码后的数据
+//
+// 参数：
+//   - src string 由 seal 生成的字符串
+//
+// 返回值：
+//   - []byte 密钥
+//   - []byte Base64 解码后的数据
+//   - error 如果密钥 ID 不存在或 Base64 解码失败，则返回错误信息
+func (k *Keyring) open(src string) ([]byte, []byte, error) {
+	id, body := "", src
+	if i := strings.Index(src, keySeparator); i >= 0 {
+		id, body = src[:i], src[i+1:]
+	}
+
+	key, ok := k.Get(id)
+	if !ok {
+		return nil, nil, errors.Errorf("未知的密钥 ID %q", id)
+	}
+
+	dst, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, dst, nil
+}
+
+// appendID 将密钥 ID 追加到字节数组格式的密文之前，格式为 "<长度><密钥ID>"
+//
+// 参数：
+//   - dst []byte 目标字节数组
+//   - id string 加密使用的密钥 ID
+//
+// 返回值：
+//   - []byte 追加密钥 ID 后的字节数组
+func (k *Keyring) appendID(dst []byte, id string) []byte {
+	dst = append(dst, byte(len(id)))
+	return append(dst, id...)
+}
+
+// openBytes 解析字节数组格式的密文中的密钥 ID,并返回对应的密钥和密文
+//
+// 参数：
+//   - src []byte 由 appendID 生成的字节数组
+//
+// 返回值：
+//   - []byte 密钥
+//   - []byte 去掉密钥 ID 后的密文
+//   - error 如果格式错误或密钥 ID 不存在，则返回错误信息
+func (k *Keyring) openBytes(src []byte) ([]byte, []byte, error) {
+	if len(src) == 0 || len(src) < int(src[0])+1 {
+		return nil, nil, errors.New("密文长度不足")
+	}
+
+	size := int(src[0]) + 1
+	id := string(src[1:size])
+	key, ok := k.Get(id)
+	if !ok {
+		return nil, nil, errors.Errorf("未知的密钥 ID %q", id)
+	}
+
+	return key, src[size:], nil
+}
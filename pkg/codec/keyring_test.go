@@ -0,0 +1,124 @@
+package codec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyring(t *testing.T) {
+	ring, err := NewKeyring("v1", map[string]string{"v1": "1234567890123456"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"AESCBC", "AESECB", "AESGCM"} {
+		t.Run(name, func(t *testing.T) {
+			if err := ring.Reload("v1", map[string]string{"v1": "1234567890123456"}); err != nil {
+				t.Fatal(err)
+			}
+
+			icodec, err := NewWithKeyring(name, ring)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			old, err := icodec.Encode("hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasPrefix(old.(string), "v1.") {
+				t.Fatalf("missing key id: %v", old)
+			}
+
+			// 轮换密钥后旧密文仍然可以解密，新密文使用新的密钥 ID
+			if err := ring.Reload("v2", map[string]string{"v1": "1234567890123456", "v2": "6543210987654321"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if value, err := icodec.Decode(old); err != nil || value != "hello" {
+				t.Fatalf("decode old: %v %v", value, err)
+			}
+
+			current, err := icodec.Encode("hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasPrefix(current.(string), "v2.") {
+				t.Fatalf("missing key id: %v", current)
+			}
+
+			// 移除旧密钥后旧密文不再被接受
+			if err := ring.Reload("v2", map[string]string{"v2": "6543210987654321"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := icodec.Decode(old); err == nil {
+				t.Fatal("expected unknown key id error")
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := NewKeyring("v3", map[string]string{"v1": "1234567890123456"}); err == nil {
+			t.Fatal("expected missing active key error")
+		}
+
+		if _, err := NewKeyring("v.1", map[string]string{"v.1": "1234567890123456"}); err == nil {
+			t.Fatal("expected invalid key id error")
+		}
+
+		// 重新加载长度不合法的密钥时返回错误，密钥环保持不变
+		ring, _ := NewKeyring("v1", map[string]string{"v1": "1234567890123456"})
+		icodec, err := NewWithKeyring("AESGCM", ring)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ring.Reload("v2", map[string]string{"v2": "short"}); err == nil {
+			t.Fatal("expected invalid key length error")
+		}
+
+		if value, err := icodec.Encode("hello"); err != nil || !strings.HasPrefix(value.(string), "v1.") {
+			t.Fatalf("encode after failed reload: %v %v", value, err)
+		}
+	})
+
+	// 重新加载后清空认证加密实例的缓存，移除的密钥不再保留在内存中
+	t.Run("cache", func(t *testing.T) {
+		ring, _ := NewKeyring("v1", map[string]string{"v1": "1234567890123456"})
+		icodec := NewAESGCMWithKeyring(ring).(*AESGCM)
+		if _, err := icodec.Encode("hello"); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(icodec.aeads.store) != 1 {
+			t.Fatalf("unexpected cache size %d", len(icodec.aeads.store))
+		}
+
+		if err := ring.Reload("v2", map[string]string{"v2": "6543210987654321"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := icodec.aeads.store["1234567890123456"]; ok {
+			t.Fatal("removed key is still cached")
+		}
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		value, err := NewAESECB("1234567890123456").Encode("hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(value.(string), keySeparator) {
+			t.Fatalf("unexpected key id: %v", value)
+		}
+
+		ring, _ := NewKeyring("v1", map[string]string{"": "1234567890123456", "v1": "6543210987654321"})
+		if resp, err := NewAESECBWithKeyring(ring).Decode(value); err != nil || resp != "hello" {
+			t.Fatalf("decode legacy: %v %v", resp, err)
+		}
+	})
+}
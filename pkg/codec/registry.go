@@ -0,0 +1,169 @@
+package codec
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Config 表示编解码器的配置
+type Config struct {
+	Name      string `yaml:"Name"`      // 编解码器类型，如 GZIP、AESGCM
+	Secret    string `yaml:"Secret"`    // 加密密钥，为空且配置了密钥环时使用密钥环
+	Threshold int    `yaml:"Threshold"` // 压缩阈值(字节),仅对压缩编解码器生效
+	Level     int    `yaml:"Level"`     // 压缩级别，仅对压缩编解码器生效
+	Limit     int    `yaml:"Limit"`     // 解压后的最大字节数，为 0 时为 DefaultGzipLimit,仅对压缩编解码器生效
+
+	Window time.Duration `yaml:"Window"` // 允许的时钟偏差，如 30s,仅对 HMAC 生效
+
+	Keyring *Keyring `yaml:"-"` // 密钥环，Secret 为空时对称加密编解码器使用密钥环
+}
+
+// Factory 是编解码器的工厂函数，根据配置创建编解码器实例
+type Factory func(conf Config) (ICodec, error)
+
+var (
+	lock      sync.RWMutex               // 读写锁
+	factories = make(map[string]Factory) // 已注册的编解码器工厂，键为大写的名称
+)
+
+// init 注册内置的编解码器
+func init() {
+	Register("DEFAULT", func(Config) (ICodec, error) { return NewDefault(), nil })
+	Register("AESCBC", cipherFactory(checkAESKey, NewAESCBC, NewAESCBCWithKeyring))
+	Register("AESECB", cipherFactory(checkAESKey, NewAESECB, NewAESECBWithKeyring))
+	Register("AESGCM", cipherFactory(checkAESKey, NewAESGCM, NewAESGCMWithKeyring))
+	Register("DESCBC", cipherFactory(checkDESKey, NewDESCBC, NewDESCBCWithKeyring))
+	Register("DESECB", cipherFactory(checkDESKey, NewDESECB, NewDESECBWithKeyring))
+	Register("GZIP", func(conf Config) (ICodec, error) { return NewGzipLimit(conf.Threshold, conf.Level, conf.Limit), nil })
+	Register("HMAC", func(conf Config) (ICodec, error) {
+		return cipherFactory(checkHMACKey,
+			func(key string) ICodec { return NewHMAC(key, conf.Window) },
+			func(ring *Keyring) ICodec { return NewHMACWithKeyring(ring, conf.Window) },
+		)(conf)
+	})
+}
+
+// Register 注册一个编解码器，注册后可以在配置文件中通过名称选择。
+// 名称不区分大小写，重复注册或工厂函数为 nil 时会 panic。
+//
+// 参数：
+//   - name string 编解码器名称
+//   - factory Factory 编解码器工厂函数
+func Register(name string, factory Factory) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	name = strings.ToUpper(name)
+	if factory == nil {
+		panic("codec: Register factory is nil for " + name)
+	}
+
+	if _, ok := factories[name]; ok {
+		panic("codec: Register called twice for " + name)
+	}
+
+	factories[name] = factory
+}
+
+// Names 返回所有已注册的编解码器名称
+func Names() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// Build 根据配置创建编解码器
+//
+// 参数：
+//   - conf Config 编解码器配置，名称为空时使用 Default
+//
+// 返回值：
+//   - ICodec 编解码器实例
+//   - error 如果名称未注册或工厂函数返回错误，则返回错误信息
+func Build(conf Config) (ICodec, error) {
+	name := strings.ToUpper(conf.Name)
+	if name == "" {
+		name = "DEFAULT"
+	}
+
+	lock.RLock()
+	factory, ok := factories[name]
+	lock.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("未知的编解码器 %q", conf.Name)
+	}
+
+	icodec, err := factory(conf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "创建编解码器 %s 失败", name)
+	}
+
+	return icodec, nil
+}
+
+// cipherFactory 创建对称加密编解码器的工厂函数，配置了密钥时使用该密钥，否则使用密钥环
+//
+// 参数：
+//   - check func(int) error 校验密钥长度的函数
+//   - single func(string) ICodec 使用单个密钥创建编解码器的函数
+//   - keyed func(*Keyring) ICodec 使用密钥环创建编解码器的函数
+//
+// 返回值：
+//   - Factory 编解码器工厂函数
+func cipherFactory(check func(int) error, single func(string) ICodec, keyed func(*Keyring) ICodec) Factory {
+	return func(conf Config) (ICodec, error) {
+		if conf.Secret == "" && conf.Keyring != nil {
+			if err := conf.Keyring.check(check); err != nil {
+				return nil, err
+			}
+
+			return keyed(conf.Keyring), nil
+		}
+
+		// 密钥为空时由编解码器生成随机密钥
+		if conf.Secret != "" {
+			if err := check(len(conf.Secret)); err != nil {
+				return nil, err
+			}
+		}
+
+		return single(conf.Secret), nil
+	}
+}
+
+// checkAESKey 校验 AES 密钥长度，必须为 16、24 或 32 字节
+func checkAESKey(size int) error {
+	switch size {
+	case 16, 24, 32:
+		return nil
+	}
+
+	return errors.Errorf("AES 密钥长度必须为 16、24 或 32 字节，当前为 %d 字节", size)
+}
+
+// checkDESKey 校验 DES 密钥长度，必须为 8 字节
+func checkDESKey(size int) error {
+	if size != 8 {
+		return errors.Errorf("DES 密钥长度必须为 8 字节，当前为 %d 字节", size)
+	}
+
+	return nil
+}
+
+// checkHMACKey 校验 HMAC 密钥长度，不能少于 16 字节
+func checkHMACKey(size int) error {
+	if size < 16 {
+		return errors.Errorf("HMAC 密钥长度不能少于 16 字节，当前为 %d 字节", size)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package codec
+
+import "testing"
+
+type upper struct{ Default }
+
+func TestRegistry(t *testing.T) {
+	t.Run("unknown", func(t *testing.T) {
+		if _, err := New("AESGCB", "1234567890123456"); err == nil {
+			t.Fatal("expected unknown codec error")
+		}
+	})
+
+	t.Run("key length", func(t *testing.T) {
+		if _, err := New("DESECB", "1234567890123456"); err == nil {
+			t.Fatal("expected invalid DES key error")
+		}
+
+		if _, err := New("AESCBC", "12345678"); err == nil {
+			t.Fatal("expected invalid AES key error")
+		}
+
+		if _, err := New("desecb", "12345678"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		icodec, err := New("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := icodec.(*Default); !ok {
+			t.Fatalf("unexpected codec %T", icodec)
+		}
+	})
+
+	t.Run("register", func(t *testing.T) {
+		Register("upper", func(Config) (ICodec, error) { return upper{}, nil })
+		icodec, err := New("UPPER", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := icodec.(upper); !ok {
+			t.Fatalf("unexpected codec %T", icodec)
+		}
+	})
+}
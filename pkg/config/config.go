@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/cotton-go/socket/pkg/log"
 	"github.com/cotton-go/socket/pkg/server/grpc"
 	"github.com/cotton-go/socket/pkg/server/http"
@@ -9,8 +11,9 @@ import (
 
 type Config struct {
 	Env    string
-	Logger log.Config  `yaml:"Logger"`
-	HTTP   http.Config `yaml:"HTTP"`
-	GRPC   grpc.Config `yaml:"GRPC"`
-	TCP    tcp.Config  `yaml:"TCP"`
+	Logger log.Config    `yaml:"Logger"`
+	HTTP   http.Config   `yaml:"HTTP"`
+	GRPC   grpc.Config   `yaml:"GRPC"`
+	TCP    tcp.Config    `yaml:"TCP"`
+	Drain  time.Duration `yaml:"Drain"` // 收到终止信号后，停止服务器前保持未就绪状态的时间
 }
@@ -0,0 +1,160 @@
+package connection
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cotton-go/socket/pkg/event"
+)
+
+// Fake 结构体是一个在内存中记录事件的连接，用于测试处理函数，不需要网络连接
+type Fake struct {
+	info    Info                           // 连接信息
+	attrs   Attributes                     // 连接属性
+	lock    sync.Mutex                     // 互斥锁，保护以下字段
+	seq     int64                          // 请求序号
+	sent    []event.Event                  // 发送的事件
+	closed  bool                           // 连接是否关闭
+	respond func(event.Event) (any, error) // 请求处理函数
+}
+
+// NewFake 创建一个在内存中记录事件的连接
+//
+// 参数：
+//   - id int64 连接ID
+//   - workID int64 工作ID
+//
+// 返回值：
+//   - *Fake 返回一个指向 Fake 类型的指针
+func NewFake(id, workID int64) *Fake {
+	return &Fake{info: Info{ID: id, WorkID: workID}}
+}
+
+// Respond 设置请求处理函数，Request 会使用它的返回值作为响应数据
+//
+// 参数：
+//   - fn func(event.Event) (any, error) 请求处理函数，为 nil 时 Request 返回 ErrUnsupported
+func (f *Fake) Respond(fn func(event.Event) (any, error)) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.respond = fn
+}
+
+// Sent 返回连接发送的所有事件，包括请求和响应
+func (f *Fake) Sent() []event.Event {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return append([]event.Event{}, f.sent...)
+}
+
+// Closed 返回连接是否关闭
+func (f *Fake) Closed() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.closed
+}
+
+// ID 返回连接ID
+func (f *Fake) ID() int64 {
+	return f.info.ID
+}
+
+// push 记录发送的事件
+func (f *Fake) push(e event.Event) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return ErrClosed
+	}
+
+	f.sent = append(f.sent, e)
+	return nil
+}
+
+// Send 记录发送的事件
+//
+// 参数：
+//   - topic string 主题名称
+//   - data any 任意类型的数据
+//
+// 返回值：
+//   - error 连接已关闭时返回 ErrClosed
+func (f *Fake) Send(topic string, data any) error {
+	return f.push(event.Event{Topic: topic, Data: data})
+}
+
+// Request 记录请求事件，并使用请求处理函数的返回值作为响应
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - topic string 主题名称
+//   - data any 任意类型的数据
+//
+// 返回值：
+//   - event.Event 响应事件
+//   - error 返回错误信息
+func (f *Fake) Request(ctx context.Context, topic string, data any) (event.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return event.Event{}, err
+	}
+
+	f.lock.Lock()
+	f.seq++
+	e := event.Event{Topic: topic, Data: data, Seq: f.seq}
+	respond := f.respond
+	f.lock.Unlock()
+
+	if respond == nil {
+		return event.Event{}, ErrUnsupported
+	}
+
+	if err := f.push(e); err != nil {
+		return event.Event{}, err
+	}
+
+	value, err := respond(e)
+	if err != nil {
+		return event.Event{}, err
+	}
+
+	return event.Event{Topic: topic, Data: value, Reply: e.Seq}, nil
+}
+
+// Reply 记录响应事件
+//
+// 参数：
+//   - e event.Event 请求事件
+//   - data any 响应数据
+//
+// 返回值：
+//   - error 连接已关闭时返回 ErrClosed
+func (f *Fake) Reply(e event.Event, data any) error {
+	return f.push(event.Event{Topic: e.Topic, Data: data, Reply: e.Seq})
+}
+
+// Close 关闭连接
+func (f *Fake) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return ErrClosed
+	}
+
+	f.closed = true
+	return nil
+}
+
+// Info 返回连接的基本信息
+func (f *Fake) Info() Info {
+	return f.info
+}
+
+// Attributes 返回连接的属性
+func (f *Fake) Attributes() *Attributes {
+	return &f.attrs
+}
@@ -0,0 +1,45 @@
+package connection
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrFrameTooLarge 表示单个帧超过了协商的最大帧大小
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// frameReader 统计解码单个事件时从连接读取的字节数，超过最大帧大小时返回错误
+type frameReader struct {
+	r     io.Reader // 网络连接
+	limit int       // 最大帧大小(字节),0 表示不限制
+	n     int       // 本次解码已读取的字节数
+}
+
+// Read 从连接中读取数据，已读取的字节数超过最大帧大小时返回 ErrFrameTooLarge
+func (f *frameReader) Read(p []byte) (int, error) {
+	if f.limit > 0 && f.n > f.limit {
+		return 0, ErrFrameTooLarge
+	}
+
+	n, err := f.r.Read(p)
+	f.n += n
+	return n, err
+}
+
+// reset 在解码下一个事件前重置已读取的字节数
+func (f *frameReader) reset() {
+	f.n = 0
+}
+
+// countWriter 统计写入连接的字节数，写入事件前后的差值即为该事件的大小
+type countWriter struct {
+	w io.Writer // 网络连接
+	n int       // 已写入的字节数
+}
+
+// Write 将数据写入连接，并累加写入的字节数
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
@@ -2,9 +2,9 @@ package connection
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/base64"
-	"encoding/gob"
 	"errors"
 	"fmt"
 	"net"
@@ -14,32 +14,69 @@ import (
 	"github.com/bytedance/sonic"
 
 	"github.com/cotton-go/socket/pkg/codec"
+	"github.com/cotton-go/socket/pkg/encoding"
 	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/handshake"
+	"github.com/cotton-go/socket/pkg/metrics"
 )
 
 // EventHandle 是一个函数类型，用于处理事件
-type EventHandle func(*Connection, event.Event)
+type EventHandle func(Peer, event.Event)
 
-// Connection 结构体表示一个连接
+// SecurityHandle 是一个函数类型，用于处理被编解码器拒绝的事件，例如签名错误、超出时间窗口或重放的消息
+type SecurityHandle func(Peer, event.Event, error)
+
+// IdentifyHandle 是一个函数类型，服务端收到客户端的身份凭证后调用，返回连接的 ID,返回错误时关闭连接
+type IdentifyHandle func(*Connection, Credentials) (int64, error)
+
+// Credentials 结构体表示客户端在连接建立后发送的身份凭证
+type Credentials struct {
+	Identity string `json:"identity"`        // 客户端声明的身份，例如用户 ID
+	Token    string `json:"token,omitempty"` // 用于校验身份的令牌
+}
+
+// Connection 结构体表示一个本节点的连接，实现了 Peer 接口
 type Connection struct {
-	ID        int64                    // 连接ID
-	WorkID    int64                    // 工作ID
-	closed    bool                     // 连接是否关闭
-	conn      net.Conn                 // 网络连接
-	ctx       context.Context          // 上下文对象
-	cancel    context.CancelFunc       // 取消函数
-	events    map[string][]EventHandle // 事件处理函数列表
-	writeBuf  chan event.Event         // 写缓冲区
-	encBuf    *bufio.Writer            // 编码缓冲区
-	enc       *gob.Encoder             // 编码器
-	dec       *gob.Decoder             // 解码器
-	codec     codec.ICodec             // 编解码器接口
-	handle    EventHandle              // 事件处理函数
-	isClient  bool                     // 是否为客户端连接
-	heartbeat *time.Ticker             // 心跳间隔
-	mutex     sync.Mutex
+	id         int64                      // 连接ID
+	workID     int64                      // 工作ID
+	closed     bool                       // 连接是否关闭
+	conn       net.Conn                   // 网络连接
+	ctx        context.Context            // 上下文对象
+	cancel     context.CancelFunc         // 取消函数
+	events     map[string][]EventHandle   // 事件处理函数列表
+	writeBuf   chan event.Event           // 写缓冲区
+	encBuf     *bufio.Writer              // 编码缓冲区
+	written    *countWriter               // 统计写入连接的字节数
+	enc        encoding.Encoder           // 编码器
+	frameBuf   bytes.Buffer               // 有最大帧大小时事件先编码到这里，测量大小后再写入连接
+	frameEnc   encoding.Encoder           // 写入 frameBuf 的编码器
+	dec        encoding.Decoder           // 解码器
+	serializer encoding.Serializer        // 事件在连接上的序列化格式
+	frame      *frameReader               // 限制单个帧大小的读取器
+	maxFrame   int                        // 协商的最大帧大小(字节),0 表示不限制
+	codec      codec.ICodec               // 编解码器接口
+	binary     bool                       // 编解码器实现了 codec.BytesCodec 时是否使用字节数组格式，双方需要一致
+	handle     EventHandle                // 事件处理函数
+	security   SecurityHandle             // 安全事件处理函数，事件被编解码器拒绝时调用
+	attrs      Attributes                 // 连接属性
+	seq        int64                      // 请求序号
+	pending    map[int64]chan event.Event // 等待响应的请求
+	isClient   bool                       // 是否为客户端连接
+	heartbeat  *time.Ticker               // 心跳间隔
+	accept     *handshake.Server          // 服务端握手配置，为空时不进行密钥交换
+	dial       *handshake.Client          // 客户端握手配置，为空时不进行密钥交换
+	creds      *Credentials               // 客户端的身份凭证，为空时不发送
+	identify   IdentifyHandle             // 服务端校验身份凭证并分配连接 ID 的函数，为空时不等待身份凭证
+	ready      func(*Connection)          // 服务端连接初始化完成、开始读写前调用的函数
+	mutex      sync.Mutex
 }
 
+// handshakeTimeout 表示密钥交换的超时时间
+const handshakeTimeout = time.Second * 10
+
+// kickTimeout 表示踢出连接时等待踢出事件写入的最长时间
+const kickTimeout = time.Second
+
 // NewConnection 创建一个新的连接对象，并返回该对象的指针
 //
 // 参数：
@@ -52,6 +89,7 @@ func NewConnection(opts ...Options) *Connection {
 	conn := &Connection{
 		writeBuf:  make(chan event.Event, 100),
 		events:    make(map[string][]EventHandle),
+		pending:   make(map[int64]chan event.Event),
 		heartbeat: time.NewTicker(time.Second * 50),
 	}
 
@@ -63,6 +101,31 @@ func NewConnection(opts ...Options) *Connection {
 	return conn
 }
 
+// ID 返回连接ID
+func (c *Connection) ID() int64 {
+	return c.id
+}
+
+// WorkID 返回工作ID
+func (c *Connection) WorkID() int64 {
+	return c.workID
+}
+
+// Info 返回连接的基本信息
+func (c *Connection) Info() Info {
+	info := Info{ID: c.id, WorkID: c.workID}
+	if c.conn != nil {
+		info.Addr = c.conn.RemoteAddr().String()
+	}
+
+	return info
+}
+
+// Attributes 返回连接的属性
+func (c *Connection) Attributes() *Attributes {
+	return &c.attrs
+}
+
 // makeOption 根据传入的选项参数设置连接对象的属性
 func (w *Connection) applyOptions(opts ...Options) {
 	// 定义默认选项
@@ -70,6 +133,7 @@ func (w *Connection) applyOptions(opts ...Options) {
 		WithID(0),
 		WithCodec(nil),
 		WithHandle(nil),
+		WithSerializer(nil),
 		WithContext(context.Background()),
 	}
 
@@ -79,6 +143,21 @@ func (w *Connection) applyOptions(opts ...Options) {
 	for _, opt := range option {
 		opt(w)
 	}
+
+	// 所有选项生效后，根据序列化器创建编解码器
+	w.bind()
+}
+
+// bind 根据连接的序列化器创建读写事件使用的编码器和解码器
+func (c *Connection) bind() {
+	if c.encBuf != nil {
+		c.enc = c.serializer.NewEncoder(c.encBuf)
+		c.frameEnc = c.serializer.NewEncoder(&c.frameBuf)
+	}
+
+	if c.frame != nil {
+		c.dec = c.serializer.NewDecoder(c.frame)
+	}
 }
 
 // init 初始化连接
@@ -89,32 +168,65 @@ func (c *Connection) init() {
 	// 打印连接 ID
 	// fmt.Println("Connection init id=", c.ID)
 
+	// 客户端的 ID 和工作 ID 由服务端下发
+	if c.isClient {
+		c.id = 0
+		c.workID = 0
+	}
+
+	// 在启动读写协程前完成密钥交换，失败时关闭连接
+	if err := c.handshake(); err != nil {
+		fmt.Println("on connection handshake error", err)
+		if !c.isClient {
+			metrics.Rejects.WithLabelValues("handshake").Inc()
+		}
+
+		c.Close()
+		return
+	}
+
 	// 如果是客户端，则注册连接初始化事件的回调函数
 	if c.isClient {
-		c.On(event.TopicByInitID, func(_ *Connection, e event.Event) {
-			// 对传入的数据进行 base64 解码
-			b, err := base64.StdEncoding.DecodeString(e.Data.(string))
-			if err != nil {
+		c.On(event.TopicByInitID, func(_ Peer, e event.Event) {
+			// 将传入的数据反序列化为连接信息
+			var info Info
+			if err := unmarshalData(e.Data, &info); err != nil {
 				fmt.Println("on connection init error[1001]", err)
 				return
 			}
 
-			// 将解码后的数据反序列化为 Connection 对象
-			var conn Connection
-			if err := sonic.Unmarshal(b, &conn); err != nil {
-				fmt.Println("on connection init error[1002]", err)
-				return
-			}
-
 			// 将新连接的 ID 和工作 ID 赋值给当前连接对象
-			c.ID = conn.ID
-			c.WorkID = conn.WorkID
+			c.id = info.ID
+			c.workID = info.WorkID
 		})
+
+		// 身份凭证在密钥交换之后发送，会经过协商的编解码器
+		if c.creds != nil {
+			b, _ := sonic.Marshal(c.creds)
+			c.Send(event.TopicByAuth, b)
+		}
+
 		go c.onHeartbeat()
 	} else {
-		// 如果是服务端，则将当前连接对象序列化为字节数组，并发送给客户端
-		b, _ := sonic.Marshal(c)
-		c.Send(event.TopicByInitID, b)
+		// 等待客户端的身份凭证，由服务端根据身份凭证分配连接 ID
+		if c.identify != nil {
+			if err := c.acceptIdentity(); err != nil {
+				fmt.Println("on connection identify error", err)
+				metrics.Rejects.WithLabelValues("identify").Inc()
+				c.Close()
+				return
+			}
+		}
+
+		// 未启用握手或连接 ID 在校验身份凭证后才确定时，将当前连接信息序列化为字节数组，并发送给客户端
+		if c.accept == nil || c.identify != nil {
+			b, _ := sonic.Marshal(Info{ID: c.id, WorkID: c.workID})
+			c.Send(event.TopicByInitID, b)
+		}
+
+		if c.ready != nil {
+			c.ready(c)
+		}
 	}
 
 	// 启动写入数据协程
@@ -123,6 +235,195 @@ func (c *Connection) init() {
 	go c.read()
 }
 
+// handshake 根据连接的握手配置执行密钥交换。
+//
+// 握手消息不经过编解码器，成功后连接的编解码器会被替换为使用会话密钥的编解码器。
+//
+// 返回值：
+//   - error 返回错误信息，如果未配置握手或握手成功则返回 nil
+func (c *Connection) handshake() error {
+	if c.isClient && c.dial != nil {
+		return c.dialHandshake()
+	}
+
+	if !c.isClient && c.accept != nil {
+		return c.acceptHandshake()
+	}
+
+	return nil
+}
+
+// acceptHandshake 服务端等待客户端的握手请求，派生会话密钥后通过初始化事件返回服务端公钥。
+//
+// 返回值：
+//   - error 返回错误信息
+func (c *Connection) acceptHandshake() error {
+	c.conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	var e event.Event
+	if err := c.dec.Decode(&e); err != nil {
+		return fmt.Errorf("read hello: %w", err)
+	}
+
+	if e.Topic != event.TopicByHandshake {
+		return fmt.Errorf("unexpected topic %q", e.Topic)
+	}
+
+	var hello handshake.Hello
+	if err := unmarshalData(e.Data, &hello); err != nil {
+		return fmt.Errorf("parse hello: %w", err)
+	}
+
+	// 协商失败时仍然需要将拒绝原因返回给客户端
+	reply, icodec, err := c.accept.Accept(hello)
+	if err != nil && reply.Error == "" {
+		return err
+	}
+
+	// 初始化事件同时携带连接 ID 和工作 ID,与未启用握手时的格式兼容
+	reply.ID, reply.WorkID = c.id, c.workID
+	b, _ := sonic.Marshal(reply)
+	if err := c.enc.Encode(event.Event{Topic: event.TopicByInitID, Data: b}); err != nil {
+		return fmt.Errorf("write reply: %w", err)
+	}
+
+	if err := c.encBuf.Flush(); err != nil {
+		return fmt.Errorf("write reply: %w", err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.codec = icodec
+	c.negotiate(reply.Selected)
+	return nil
+}
+
+// acceptIdentity 服务端等待客户端的身份凭证，并根据身份凭证设置连接 ID。
+//
+// 返回值：
+//   - error 读取身份凭证失败或身份凭证被拒绝时返回错误信息
+func (c *Connection) acceptIdentity() error {
+	c.conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	var e event.Event
+	c.frame.reset()
+	if err := c.dec.Decode(&e); err != nil {
+		return fmt.Errorf("read credentials: %w", err)
+	}
+
+	if err := c.decode(&e); err != nil {
+		return fmt.Errorf("decode credentials: %w", err)
+	}
+
+	if e.Topic != event.TopicByAuth {
+		return fmt.Errorf("unexpected topic %q", e.Topic)
+	}
+
+	var creds Credentials
+	if err := unmarshalData(e.Data, &creds); err != nil {
+		return fmt.Errorf("parse credentials: %w", err)
+	}
+
+	id, err := c.identify(c, creds)
+	if err != nil {
+		return err
+	}
+
+	c.id = id
+	return nil
+}
+
+// negotiate 应用握手协商的结果，协议版本不低于 handshake.BinaryVersion 时使用字节数组格式
+func (c *Connection) negotiate(selected handshake.Selected) {
+	c.binary = c.binary || selected.Version >= handshake.BinaryVersion
+	c.setMaxFrame(selected.MaxFrame)
+}
+
+// setMaxFrame 设置协商的最大帧大小
+func (c *Connection) setMaxFrame(value int) {
+	c.maxFrame = value
+	if c.frame != nil {
+		c.frame.limit = value
+	}
+}
+
+// dialHandshake 客户端发送临时公钥，并等待服务端的初始化事件完成握手。
+//
+// 返回值：
+//   - error 返回错误信息，如果服务端身份校验失败也会返回错误
+func (c *Connection) dialHandshake() error {
+	c.conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	state, err := c.dial.Begin()
+	if err != nil {
+		return err
+	}
+
+	b, _ := sonic.Marshal(state.Hello())
+	if err := c.enc.Encode(event.Event{Topic: event.TopicByHandshake, Data: b}); err != nil {
+		return fmt.Errorf("write hello: %w", err)
+	}
+
+	if err := c.encBuf.Flush(); err != nil {
+		return fmt.Errorf("write hello: %w", err)
+	}
+
+	var e event.Event
+	if err := c.dec.Decode(&e); err != nil {
+		return fmt.Errorf("read reply: %w", err)
+	}
+
+	if e.Topic != event.TopicByInitID {
+		return fmt.Errorf("unexpected topic %q", e.Topic)
+	}
+
+	var reply handshake.Reply
+	if err := unmarshalData(e.Data, &reply); err != nil {
+		return fmt.Errorf("parse reply: %w", err)
+	}
+
+	icodec, err := state.Finish(reply)
+	if err != nil {
+		return err
+	}
+
+	c.id = reply.ID
+	c.workID = reply.WorkID
+	c.codec = icodec
+	c.negotiate(reply.Selected)
+	return nil
+}
+
+// unmarshalData 将事件中以字节数组发送的 JSON 数据解析到 value 中。
+//
+// 参数：
+//   - data any 事件数据，JSON 序列化器会将字节数组编码为 base64 字符串，其他序列化器保留字节数组
+//   - value any 接收解析结果的指针
+//
+// 返回值：
+//   - error 返回错误信息
+func unmarshalData(data any, value any) error {
+	var b []byte
+	switch v := data.(type) {
+	case []byte:
+		b = v
+	case string:
+		var err error
+		if b, err = base64.StdEncoding.DecodeString(v); err != nil {
+			return err
+		}
+	default:
+		return errors.New("invalid data type")
+	}
+
+	return sonic.Unmarshal(b, value)
+}
+
 // On 注册事件处理函数
 //
 // 参数：
@@ -137,7 +438,7 @@ func (c *Connection) On(event string, fn EventHandle) error {
 
 	// 如果连接已关闭，则返回错误
 	if c.closed {
-		return errors.New("is closed")
+		return ErrClosed
 	}
 
 	// 将事件处理函数添加到对应事件的回调函数列表中
@@ -158,6 +459,7 @@ func (c *Connection) Emit(topic string, data event.Event) {
 		for _, fn := range handles {
 			go func(fn EventHandle) {
 				defer c.recover("connection emit")
+				defer metrics.ObserveHandler(data.Topic, time.Now())
 				fn(c, data)
 			}(fn)
 		}
@@ -183,7 +485,9 @@ func (c *Connection) Emit(topic string, data event.Event) {
 	default:
 		// 如果存在自定义的事件处理函数，并且连接未关闭，则执行该函数
 		if c.handle != nil && !c.closed {
+			start := time.Now()
 			c.handle(c, data)
+			metrics.ObserveHandler(data.Topic, start)
 		}
 	}
 }
@@ -215,7 +519,8 @@ func (c *Connection) read() {
 			}
 
 			var e event.Event
-			// 从连接中解码事件数据
+			// 从连接中解码事件数据，单个帧超过最大帧大小时返回错误
+			c.frame.reset()
 			err := c.dec.Decode(&e)
 			if err != nil {
 				fmt.Println("read faild", err)
@@ -224,9 +529,27 @@ func (c *Connection) read() {
 			}
 
 			// 对事件数据进行编解码
-			e.Data, err = c.codec.Decode(e.Data)
-			if err != nil {
+			if err := c.decode(&e); err != nil {
 				fmt.Println("err", err)
+				// 没有通过校验的事件主题由客户端任意指定，不作为标签记录，避免占满主题标签
+				metrics.MessagesIn.WithLabelValues(metrics.OtherTopic).Inc()
+				metrics.BytesIn.WithLabelValues(metrics.OtherTopic).Add(float64(c.frame.n))
+				metrics.CodecErrors.WithLabelValues("decode").Inc()
+				if c.security != nil {
+					c.security(c, e, err)
+				}
+
+				continue
+			}
+
+			// 解码器会预读数据，按解码该事件时从连接读取的字节数统计
+			topic := metrics.Topic(e.Topic)
+			metrics.MessagesIn.WithLabelValues(topic).Inc()
+			metrics.BytesIn.WithLabelValues(topic).Add(float64(c.frame.n))
+
+			// 响应事件交给等待响应的请求，不触发事件处理函数
+			if e.Reply != 0 {
+				c.resolve(e)
 				continue
 			}
 
@@ -250,24 +573,206 @@ func (c *Connection) write() {
 			// 当上下文被取消时，返回。
 			return
 		case buffer := <-c.writeBuf:
+			metrics.SendQueue.Dec()
 			// 如果连接已关闭，则无法发送数据。
 			if c.closed {
 				fmt.Println("is closed not can send")
+				metrics.Dropped.WithLabelValues("closed").Inc()
 				return
 			}
 
 			// 对数据进行编解码。
-			buffer.Data, _ = c.codec.Encode(buffer.Data)
-			// 如果编码失败，则返回错误信息。
-			if err := c.enc.Encode(buffer); err != nil {
+			data, buf, err := c.encode(buffer)
+			if err != nil {
+				fmt.Println("write faild", err, "topic", buffer.Topic)
+				metrics.CodecErrors.WithLabelValues("encode").Inc()
+				metrics.Dropped.WithLabelValues("encode").Inc()
+				continue
+			}
+
+			buffer.Data = data
+			if err := c.writeEvent(buffer); err != nil {
 				fmt.Println("write faild", err)
+				metrics.Dropped.WithLabelValues("write").Inc()
+				codec.PutBuffer(buf)
 				return
 			}
 
-			// 将缓冲区中的数据写入连接。
-			c.encBuf.Flush()
+			// 事件已写入连接，编码结果的缓冲区可以复用。
+			codec.PutBuffer(buf)
+
+			// 踢出事件写入后关闭连接，保证对端在断开前收到原因
+			if buffer.Topic == event.TopicByKick {
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+// writeEvent 将编码后的事件写入连接，超过协商的最大帧大小的事件对端无法接收，直接丢弃。
+// 有最大帧大小时事件只编码一次，先编码到帧缓冲区测量大小，再将缓冲区写入连接。
+//
+// 参数：
+//   - e event.Event 编码后的事件
+//
+// 返回值：
+//   - error 写入连接失败时返回错误信息
+func (c *Connection) writeEvent(e event.Event) error {
+	// 如果编码失败，则返回错误信息。
+	written := c.written.n
+	if c.maxFrame > 0 {
+		c.frameBuf.Reset()
+		if err := c.frameEnc.Encode(e); err != nil {
+			return err
+		}
+
+		if size := c.frameBuf.Len(); size > c.maxFrame {
+			fmt.Println("write faild", ErrFrameTooLarge, "topic", e.Topic, "size", size)
+			metrics.Dropped.WithLabelValues("frame_too_large").Inc()
+			return nil
+		}
+
+		if _, err := c.encBuf.Write(c.frameBuf.Bytes()); err != nil {
+			return err
+		}
+	} else if err := c.enc.Encode(e); err != nil {
+		return err
+	}
+
+	// 将缓冲区中的数据写入连接。
+	if err := c.encBuf.Flush(); err != nil {
+		return err
+	}
+
+	topic := metrics.Topic(e.Topic)
+	metrics.MessagesOut.WithLabelValues(topic).Inc()
+	metrics.BytesOut.WithLabelValues(topic).Add(float64(c.written.n - written))
+	return nil
+}
+
+// encode 对事件数据进行编码。
+// 启用了字节数组格式并且编解码器实现了 codec.BytesCodec 时，先使用序列化器序列化数据，再直接对字节数组进行加密或压缩，
+// 避免 ICodec 中的二次序列化和 Base64 字符串；否则使用与旧版本客户端兼容的 ICodec 格式。
+//
+// 参数：
+//   - e event.Event 需要发送的事件，编解码器实现了 codec.EventCodec 时签名绑定事件的主题、请求序号和发送方向
+//
+// 返回值：
+//   - any 编码后的数据
+//   - *[]byte 存放编码结果的缓冲区，写入连接后需要调用 codec.PutBuffer 放回，未使用缓冲区时为 nil
+//   - error 返回错误信息
+func (c *Connection) encode(e event.Event) (any, *[]byte, error) {
+	bc, ok := c.bytesCodec()
+	if !ok {
+		if ec, ok := c.codec.(codec.EventCodec); ok {
+			value, err := ec.EncodeEvent(c.meta(e, c.isClient), e.Data)
+			return value, nil, err
+		}
+
+		value, err := c.codec.Encode(e.Data)
+		return value, nil, err
+	}
+
+	src, err := c.serializer.Marshal(e.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf := codec.GetBuffer()
+	if *buf, err = bc.EncodeBytes(*buf, src); err != nil {
+		codec.PutBuffer(buf)
+		return nil, nil, err
+	}
+
+	return *buf, buf, nil
+}
+
+// decode 对事件数据进行解码，与 encode 对应。
+// 使用字节数组格式时，解码后的字节数组会保留为事件的原始数据，Scan 可以直接从中解析。
+//
+// 参数：
+//   - e *event.Event 从连接中读取的事件
+//
+// 返回值：
+//   - error 返回错误信息
+func (c *Connection) decode(e *event.Event) error {
+	bc, ok := c.bytesCodec()
+	if !ok {
+		var (
+			data any
+			err  error
+		)
+
+		// 收到的事件由对端发送，发送方向与本端相反
+		if ec, ok := c.codec.(codec.EventCodec); ok {
+			data, err = ec.DecodeEvent(c.meta(*e, !c.isClient), e.Data)
+		} else {
+			data, err = c.codec.Decode(e.Data)
 		}
+
+		if err != nil {
+			return err
+		}
+
+		e.Data = data
+		// 经过加密或压缩的数据，原始表示与解码后的数据不一致，Scan 需要使用解码后的数据
+		if _, ok := c.codec.(*codec.Default); !ok {
+			e.SetRaw(nil, nil)
+		}
+
+		return nil
+	}
+
+	var src []byte
+	switch v := e.Data.(type) {
+	case []byte:
+		src = v
+	case string:
+		// JSON 序列化器会将字节数组编码为 base64 字符串
+		var err error
+		if src, err = base64.StdEncoding.DecodeString(v); err != nil {
+			return err
+		}
+	default:
+		return errors.New("invalid data type")
 	}
+
+	raw, err := bc.DecodeBytes(nil, src)
+	if err != nil {
+		return err
+	}
+
+	var data any
+	if err := c.serializer.Unmarshal(raw, &data); err != nil {
+		return err
+	}
+
+	e.Data = data
+	e.SetRaw(raw, c.serializer.Unmarshal)
+	return nil
+}
+
+// meta 返回编解码时绑定的事件元数据
+//
+// 参数：
+//   - e event.Event 事件
+//   - client bool 事件是否由客户端发送
+//
+// 返回值：
+//   - codec.Meta 事件的元数据
+func (c *Connection) meta(e event.Event, client bool) codec.Meta {
+	return codec.Meta{Topic: e.Topic, Seq: e.Seq, Reply: e.Reply, Client: client}
+}
+
+// bytesCodec 返回字节数组格式使用的编解码器，没有启用字节数组格式或编解码器没有实现 codec.BytesCodec 时返回 false
+func (c *Connection) bytesCodec() (codec.BytesCodec, bool) {
+	if !c.binary {
+		return nil, false
+	}
+
+	bc, ok := c.codec.(codec.BytesCodec)
+	return bc, ok
 }
 
 // Send 函数用于向指定主题发送数据。
@@ -277,18 +782,115 @@ func (c *Connection) write() {
 //   - data any 任意类型的数据
 //
 // 返回值：
-//   - error 返回错误信息，如果连接已关闭则返回 "is closed" 错误
+//   - error 返回错误信息，如果连接已关闭则返回 ErrClosed 错误
 func (c *Connection) Send(topic string, data any) error {
+	return c.push(event.Event{Topic: topic, Data: data})
+}
+
+// push 将事件写入写缓冲区，写缓冲区已满时丢弃事件而不是阻塞，避免读取缓慢的对端阻塞发送方。
+//
+// 参数：
+//   - e event.Event 未编码的事件
+//
+// 返回值：
+//   - error 连接已关闭时返回 ErrClosed,没有网络连接时返回 ErrNotConnected,写缓冲区已满时返回 ErrQueueFull
+func (c *Connection) push(e event.Event) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
 	if c.closed {
-		return errors.New("is closed")
+		return ErrClosed
+	}
+
+	// 没有网络连接的连接对象无法发送数据，避免向空的写缓冲区发送时阻塞
+	if c.writeBuf == nil {
+		return ErrNotConnected
 	}
 
 	// 将事件写入缓冲区并返回 nil。
-	c.writeBuf <- event.Event{Topic: topic, Data: data}
-	return nil
+	metrics.SendQueue.Inc()
+	select {
+	case c.writeBuf <- e:
+		return nil
+	default:
+		metrics.SendQueue.Dec()
+		metrics.Dropped.WithLabelValues("queue_full").Inc()
+		return ErrQueueFull
+	}
+}
+
+// Request 函数用于向对端发送请求事件，并等待对端通过 Reply 返回的响应事件。
+//
+// 参数：
+//   - ctx context.Context 上下文，用于设置等待响应的超时时间
+//   - topic string 主题名称
+//   - data any 任意类型的数据
+//
+// 返回值：
+//   - event.Event 响应事件
+//   - error 返回错误信息，上下文被取消时返回上下文的错误，连接关闭时返回 ErrClosed
+func (c *Connection) Request(ctx context.Context, topic string, data any) (event.Event, error) {
+	ch := make(chan event.Event, 1)
+	c.mutex.Lock()
+	c.seq++
+	seq := c.seq
+	c.pending[seq] = ch
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, seq)
+		c.mutex.Unlock()
+	}()
+
+	if err := c.push(event.Event{Topic: topic, Data: data, Seq: seq}); err != nil {
+		return event.Event{}, err
+	}
+
+	select {
+	case e := <-ch:
+		return e, nil
+	case <-ctx.Done():
+		return event.Event{}, ctx.Err()
+	case <-c.ctx.Done():
+		return event.Event{}, ErrClosed
+	}
+}
+
+// Reply 函数用于响应对端通过 Request 发送的请求事件。
+//
+// 参数：
+//   - e event.Event 请求事件
+//   - data any 响应数据
+//
+// 返回值：
+//   - error 返回错误信息，请求事件没有请求序号时返回错误
+func (c *Connection) Reply(e event.Event, data any) error {
+	if e.Seq == 0 {
+		return errors.New("not a request")
+	}
+
+	return c.push(event.Event{Topic: e.Topic, Data: data, Reply: e.Seq})
+}
+
+// resolve 函数用于将响应事件交给等待响应的请求，请求已超时或客户端重复响应时丢弃响应。
+//
+// 参数：
+//   - e event.Event 响应事件
+func (c *Connection) resolve(e event.Event) {
+	c.mutex.Lock()
+	ch, ok := c.pending[e.Reply]
+	c.mutex.Unlock()
+	if ok {
+		select {
+		case ch <- e:
+			return
+		default:
+		}
+	}
+
+	fmt.Println("connection reply dropped", "topic", e.Topic, "seq", e.Reply)
+	metrics.Dropped.WithLabelValues("reply").Inc()
 }
 
 // onHeartbeat 处理心跳事件
@@ -307,6 +909,28 @@ func (c *Connection) onHeartbeat() {
 	}
 }
 
+// Kick 函数用于通知对端被踢出的原因后关闭连接，踢出事件写入连接或超过 kickTimeout 后关闭。
+//
+// 参数：
+//   - reason string 踢出的原因，作为 event.TopicByKick 事件的数据发送给对端
+//
+// 返回值：
+//   - error 返回错误信息，如果连接已关闭则返回 ErrClosed 错误
+func (c *Connection) Kick(reason string) error {
+	if err := c.push(event.Event{Topic: event.TopicByKick, Data: reason}); err != nil {
+		// 写缓冲区已满时无法通知对端，直接关闭连接
+		if errors.Is(err, ErrQueueFull) {
+			return c.Close()
+		}
+
+		return err
+	}
+
+	// 写入连接阻塞时不再等待踢出事件写入
+	time.AfterFunc(kickTimeout, func() { c.Close() })
+	return nil
+}
+
 // Close 函数用于关闭连接。
 //
 // 参数：无
@@ -318,14 +942,29 @@ func (c *Connection) Close() error {
 	defer c.mutex.Unlock()
 
 	if c.closed {
-		return errors.New("is close")
+		return ErrClosed
+	}
+
+	// 没有网络连接的连接对象无法关闭
+	if c.conn == nil {
+		return ErrNotConnected
 	}
 
 	c.handle(c, event.Event{Topic: event.TopicByClose, Data: nil})
 	c.closed = true
 	c.cancel()
 	c.conn.Close()
-	return nil
+
+	// 写缓冲区中还没有写入的事件不会再写入
+	for {
+		select {
+		case <-c.writeBuf:
+			metrics.SendQueue.Dec()
+			metrics.Dropped.WithLabelValues("closed").Inc()
+		default:
+			return nil
+		}
+	}
 }
 
 // recover 函数用于在发生错误时进行恢复操作。
@@ -3,11 +3,12 @@ package connection
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"net"
 
 	"github.com/cotton-go/socket/pkg/codec"
+	"github.com/cotton-go/socket/pkg/encoding"
 	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/handshake"
 	"github.com/cotton-go/socket/pkg/snowflake"
 )
 
@@ -29,8 +30,8 @@ func WithID(value int64) Options {
 			value = snowflake.Next()
 		}
 
-		// 将value赋值给w的ID属性
-		w.ID = value
+		// 将value赋值给w的id属性
+		w.id = value
 	}
 }
 
@@ -49,8 +50,8 @@ func WithWorkID(value int64) Options {
 			value = snowflake.Next()
 		}
 
-		// 将value赋值给w的WorkID属性
-		w.WorkID = value
+		// 将value赋值给w的workID属性
+		w.workID = value
 	}
 }
 
@@ -64,12 +65,13 @@ func WithWorkID(value int64) Options {
 func WithConn(conn net.Conn) Options {
 	// 返回一个新的Options函数
 	return func(c *Connection) {
-		// 为c的encBuf属性分配一个新的bufio.Writer实例，并将conn作为参数传入
-		c.encBuf = bufio.NewWriter(conn)
-		// 为c的enc属性分配一个新的gob.Encoder实例，并将c的encBuf属性作为参数传入
-		c.enc = json.NewEncoder(c.encBuf)
-		// 为c的dec属性分配一个新的gob.Decoder实例，并将conn作为参数传入
-		c.dec = json.NewDecoder(conn)
+		// 保存网络连接，用于设置读写超时和关闭连接
+		c.conn = conn
+		// 为c的encBuf属性分配一个新的bufio.Writer实例，通过written统计写入连接的字节数
+		c.written = &countWriter{w: conn}
+		c.encBuf = bufio.NewWriter(c.written)
+		// 通过frame限制单个帧的大小，编码器和解码器在所有选项生效后根据序列化器创建
+		c.frame = &frameReader{r: conn}
 	}
 }
 
@@ -93,6 +95,37 @@ func WithCodec(value codec.ICodec) Options {
 	}
 }
 
+// WithSerializer函数，用于设置Connection在连接上序列化事件的格式，连接双方需要使用相同的序列化器。
+//
+// 参数：
+//   - value encoding.Serializer 序列化器实例，为 nil 时使用 encoding.JSON
+//
+// 返回值：
+//   - Options 一个函数，该函数接收一个*Connection类型的参数c,并对其进行操作。
+func WithSerializer(value encoding.Serializer) Options {
+	return func(c *Connection) {
+		if value == nil {
+			value = encoding.JSON
+		}
+
+		c.serializer = value
+	}
+}
+
+// WithBinary函数，用于启用字节数组格式，编解码器实现了 codec.BytesCodec 时直接加密序列化后的字节数组。
+// 连接双方需要一致，默认使用与旧版本客户端兼容的格式；握手协商的协议版本不低于 handshake.BinaryVersion 时自动启用。
+//
+// 参数：
+//   - value bool 是否启用字节数组格式
+//
+// 返回值：
+//   - Options 一个函数，该函数接收一个*Connection类型的参数c,并对其进行操作。
+func WithBinary(value bool) Options {
+	return func(c *Connection) {
+		c.binary = value
+	}
+}
+
 // WithClient函数，用于设置Connection的isClient属性。
 //
 // 参数：
@@ -118,9 +151,9 @@ func WithClient(value bool) Options {
 func WithHandle(value EventHandle) Options {
 	// 返回一个新的Options函数
 	return func(w *Connection) {
-		// 如果传入的事件句柄为空，则为w的handle属性分配一个新的空函数，并将func(c *Connection, e event.Event) {}作为参数传入
+		// 如果传入的事件句柄为空，则为w的handle属性分配一个新的空函数，并将func(p Peer, e event.Event) {}作为参数传入
 		if value == nil {
-			value = func(c *Connection, e event.Event) {}
+			value = func(p Peer, e event.Event) {}
 		}
 
 		// 将传入的事件句柄赋值给w的handle属性
@@ -157,7 +190,7 @@ func WithContext(value context.Context) Options {
 func WithClose(value EventHandle) Options {
 	return func(c *Connection) {
 		if value == nil {
-			value = func(c *Connection, e event.Event) {
+			value = func(_ Peer, e event.Event) {
 				c.cancel()
 			}
 		}
@@ -165,3 +198,82 @@ func WithClose(value EventHandle) Options {
 		c.On(event.TopicByClose, value)
 	}
 }
+
+// WithServerHandshake 函数用于设置服务端的握手配置，启用后连接建立时会先与客户端进行 X25519 密钥交换。
+//
+// 参数：
+//   - value *handshake.Server 服务端握手配置，为 nil 时不进行密钥交换
+//
+// 返回值：
+//   - Options 一个函数，该函数接收一个*Connection类型的参数c,并对其进行操作。
+func WithServerHandshake(value *handshake.Server) Options {
+	return func(c *Connection) {
+		c.accept = value
+	}
+}
+
+// WithClientHandshake 函数用于设置客户端的握手配置，启用后连接建立时会先与服务端进行 X25519 密钥交换。
+//
+// 参数：
+//   - value *handshake.Client 客户端握手配置，为 nil 时不进行密钥交换
+//
+// 返回值：
+//   - Options 一个函数，该函数接收一个*Connection类型的参数c,并对其进行操作。
+func WithClientHandshake(value *handshake.Client) Options {
+	return func(c *Connection) {
+		c.dial = value
+	}
+}
+
+// WithSecurity 函数用于设置安全事件处理函数，事件被编解码器拒绝时调用，例如签名错误、超出时间窗口或重放的消息。
+//
+// 参数：
+//   - value SecurityHandle 安全事件处理函数，为 nil 时只打印错误信息
+//
+// 返回值：
+//   - Options 一个函数，该函数接收一个*Connection类型的参数c,并对其进行操作。
+func WithSecurity(value SecurityHandle) Options {
+	return func(c *Connection) {
+		c.security = value
+	}
+}
+
+// WithCredentials 函数用于设置客户端的身份凭证，连接建立(密钥交换)后发送给服务端。
+//
+// 参数：
+//   - value Credentials 身份凭证，服务端启用 WithIdentify 时必须发送
+//
+// 返回值：
+//   - Options 一个函数，该函数接收一个*Connection类型的参数c,并对其进行操作。
+func WithCredentials(value Credentials) Options {
+	return func(c *Connection) {
+		c.creds = &value
+	}
+}
+
+// WithIdentify 函数用于设置服务端校验身份凭证的函数，启用后服务端在开始读写前等待客户端的身份凭证，
+// 并使用函数返回的 ID 作为连接 ID。
+//
+// 参数：
+//   - value IdentifyHandle 校验身份凭证并返回连接 ID 的函数，为 nil 时不等待身份凭证
+//
+// 返回值：
+//   - Options 一个函数，该函数接收一个*Connection类型的参数c,并对其进行操作。
+func WithIdentify(value IdentifyHandle) Options {
+	return func(c *Connection) {
+		c.identify = value
+	}
+}
+
+// WithReady 函数用于设置服务端连接初始化完成后调用的函数，此时已经完成密钥交换和身份校验，连接 ID 不会再变化。
+//
+// 参数：
+//   - value func(*Connection) 连接初始化完成后调用的函数
+//
+// 返回值：
+//   - Options 一个函数，该函数接收一个*Connection类型的参数c,并对其进行操作。
+func WithReady(value func(*Connection)) Options {
+	return func(c *Connection) {
+		c.ready = value
+	}
+}
@@ -0,0 +1,104 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/cotton-go/socket/pkg/event"
+)
+
+var (
+	// ErrClosed 表示连接已关闭
+	ErrClosed = errors.New("is closed")
+
+	// ErrNotConnected 表示连接对象没有网络连接，例如位于其他节点的连接
+	ErrNotConnected = errors.New("not connected")
+
+	// ErrUnsupported 表示连接对象不支持该操作
+	ErrUnsupported = errors.New("unsupported operation")
+
+	// ErrQueueFull 表示连接的写缓冲区已满，对端没有及时读取，事件被丢弃
+	ErrQueueFull = errors.New("send queue is full")
+)
+
+// Peer 是连接的抽象，本节点的连接、其他节点的连接和测试使用的连接都实现了该接口
+type Peer interface {
+	// ID 返回连接ID
+	ID() int64
+
+	// Send 向连接发送事件
+	Send(topic string, data any) error
+
+	// Request 向连接发送请求事件，并等待对端通过 Reply 返回的响应事件
+	Request(ctx context.Context, topic string, data any) (event.Event, error)
+
+	// Reply 响应对端通过 Request 发送的请求事件
+	Reply(e event.Event, data any) error
+
+	// Close 关闭连接
+	Close() error
+
+	// Info 返回连接的基本信息
+	Info() Info
+
+	// Attributes 返回连接的属性，用于在处理函数之间共享数据，属性只保存在本节点
+	Attributes() *Attributes
+}
+
+// Info 结构体表示连接的基本信息，序列化格式与初始化事件和缓存中保存的格式兼容
+type Info struct {
+	ID     int64  // 连接ID
+	WorkID int64  // 工作ID
+	Addr   string `json:",omitempty"` // 对端地址
+	Remote bool   `json:",omitempty"` // 是否位于其他节点
+}
+
+// Attributes 结构体表示连接的属性，零值可以直接使用，可以在多个协程中同时使用
+type Attributes struct {
+	lock   sync.RWMutex
+	values map[string]any
+}
+
+// Get 获取属性的值
+//
+// 参数：
+//   - key string 属性名称
+//
+// 返回值：
+//   - any 属性的值
+//   - bool 属性是否存在
+func (a *Attributes) Get(key string) (any, bool) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	value, ok := a.values[key]
+	return value, ok
+}
+
+// Set 设置属性的值
+//
+// 参数：
+//   - key string 属性名称
+//   - value any 属性的值
+func (a *Attributes) Set(key string, value any) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.values == nil {
+		a.values = make(map[string]any)
+	}
+
+	a.values[key] = value
+}
+
+// Delete 删除属性
+//
+// 参数：
+//   - key string 属性名称
+func (a *Attributes) Delete(key string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	delete(a.values, key)
+}
@@ -0,0 +1,185 @@
+package connection
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cotton-go/socket/pkg/codec"
+	"github.com/cotton-go/socket/pkg/encoding"
+	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/handshake"
+)
+
+// 编译期检查各个实现是否满足 Peer 接口
+var (
+	_ Peer = (*Connection)(nil)
+	_ Peer = (*Remote)(nil)
+	_ Peer = (*Fake)(nil)
+)
+
+func TestRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	icodec := codec.NewAESGCM("1234567890123456")
+	server, client := net.Pipe()
+	NewConnection(
+		WithConn(server),
+		WithCodec(icodec),
+		WithContext(ctx),
+		WithHandle(func(p Peer, e event.Event) {
+			if e.Topic == "ping" {
+				p.Reply(e, e.Data)
+			}
+		}),
+	)
+
+	conn := NewConnection(WithConn(client), WithClient(true), WithCodec(icodec), WithContext(ctx))
+	for _, value := range []string{"a", "b"} {
+		reqCtx, reqCancel := context.WithTimeout(ctx, time.Second*2)
+		e, err := conn.Request(reqCtx, "ping", value)
+		reqCancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if e.Data != value || e.Reply == 0 {
+			t.Fatalf("unexpected reply %+v", e)
+		}
+	}
+
+	// 对端不响应时等待超时
+	reqCtx, reqCancel := context.WithTimeout(ctx, time.Millisecond*100)
+	defer reqCancel()
+	if _, err := conn.Request(reqCtx, "other", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 对端不读取时写入阻塞，写缓冲区写满后丢弃事件而不是阻塞发送方
+	server, _ := net.Pipe()
+	conn := NewConnection(WithConn(server), WithContext(ctx))
+	var err error
+	for i := 0; i < 200 && err == nil; i++ {
+		err = conn.Send("news", i)
+	}
+
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestWriteEventMaxFrame(t *testing.T) {
+	var buf bytes.Buffer
+	c := &Connection{serializer: encoding.JSON, written: &countWriter{w: &buf}, maxFrame: 64}
+	c.encBuf = bufio.NewWriter(c.written)
+	c.bind()
+
+	if err := c.writeEvent(event.Event{Topic: "small", Data: "a"}); err != nil || buf.Len() == 0 {
+		t.Fatalf("small event not written: %d %v", buf.Len(), err)
+	}
+
+	// 超过最大帧大小的事件被丢弃，不写入连接
+	size := buf.Len()
+	if err := c.writeEvent(event.Event{Topic: "large", Data: strings.Repeat("a", 64)}); err != nil || buf.Len() != size {
+		t.Fatalf("large event written: %d %v", buf.Len(), err)
+	}
+}
+
+func TestLegacyFormat(t *testing.T) {
+	icodec := codec.NewAESGCM("1234567890123456")
+	c := &Connection{serializer: encoding.JSON, codec: icodec}
+
+	// 旧版本客户端使用 ICodec 的 Base64 字符串格式，默认仍然使用该格式
+	value, _, err := c.encode(event.Event{Topic: "news", Data: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := icodec.Decode(value); err != nil || data != "hello" {
+		t.Fatalf("legacy client cannot decode: %v %v", data, err)
+	}
+
+	legacy, _ := icodec.Encode("world")
+	e := event.Event{Topic: "news", Data: legacy}
+	if err := c.decode(&e); err != nil || e.Data != "world" {
+		t.Fatalf("legacy event not decoded: %v %v", e.Data, err)
+	}
+
+	// 协商的协议版本低于 BinaryVersion 时保持旧格式，不低于时使用字节数组格式
+	c.negotiate(handshake.Selected{Version: handshake.BinaryVersion - 1})
+	if value, _, _ = c.encode(event.Event{Topic: "news", Data: "hello"}); !isString(value) {
+		t.Fatalf("unexpected binary value %T", value)
+	}
+
+	c.negotiate(handshake.Selected{Version: handshake.BinaryVersion})
+	value, buf, err := c.encode(event.Event{Topic: "news", Data: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer codec.PutBuffer(buf)
+
+	if _, ok := value.([]byte); !ok {
+		t.Fatalf("expected binary value, got %T", value)
+	}
+}
+
+// isString 返回编码后的数据是否为 Base64 字符串格式
+func isString(value any) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake(1, 2)
+	fake.Respond(func(e event.Event) (any, error) {
+		return "pong", nil
+	})
+
+	var handle EventHandle = func(p Peer, e event.Event) {
+		p.Attributes().Set("user", "xxx")
+		resp, err := p.Request(context.Background(), "ping", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p.Send("msg", resp.Data)
+	}
+
+	handle(fake, event.Event{Topic: "login"})
+	sent := fake.Sent()
+	if len(sent) != 2 || sent[0].Seq == 0 || sent[1].Data != "pong" {
+		t.Fatalf("unexpected events %+v", sent)
+	}
+
+	if user, _ := fake.Attributes().Get("user"); user != "xxx" {
+		t.Fatalf("unexpected attribute %v", user)
+	}
+
+	if err := fake.Close(); err != nil || !fake.Closed() {
+		t.Fatal("fake is not closed")
+	}
+
+	if err := fake.Send("msg", nil); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+
+	remote := NewRemote(1, 2, nil)
+	if info := remote.Info(); !info.Remote || info.WorkID != 2 {
+		t.Fatalf("unexpected info %+v", info)
+	}
+
+	if err := remote.Send("msg", nil); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package connection
+
+import (
+	"context"
+
+	"github.com/cotton-go/socket/pkg/event"
+)
+
+// RemoteSender 是一个函数类型，用于将事件转发到连接所在的节点
+type RemoteSender func(info Info, topic string, data any) error
+
+// RemoteRequester 是一个函数类型，用于向连接所在的节点发送请求事件，并等待客户端的响应数据
+type RemoteRequester func(ctx context.Context, info Info, topic string, data any) (any, error)
+
+// RemoteCloser 是一个函数类型，用于通知连接所在的节点关闭连接
+type RemoteCloser func(info Info) error
+
+// RemoteOption 是一个函数类型，用于配置 Remote
+type RemoteOption func(*Remote)
+
+// WithRemoteRequest 设置远程请求函数，没有设置时 Request 返回 ErrUnsupported
+//
+// 参数：
+//   - value RemoteRequester 远程请求函数
+//
+// 返回值：
+//   - RemoteOption Remote 选项
+func WithRemoteRequest(value RemoteRequester) RemoteOption {
+	return func(r *Remote) {
+		r.request = value
+	}
+}
+
+// WithRemoteClose 设置远程关闭函数，没有设置时 Close 返回 ErrUnsupported
+//
+// 参数：
+//   - value RemoteCloser 远程关闭函数
+//
+// 返回值：
+//   - RemoteOption Remote 选项
+func WithRemoteClose(value RemoteCloser) RemoteOption {
+	return func(r *Remote) {
+		r.close = value
+	}
+}
+
+// Remote 结构体表示位于其他节点的连接，没有网络连接，Send、Request 和 Close 会转发到连接所在的节点
+type Remote struct {
+	info    Info            // 连接信息
+	attrs   Attributes      // 连接属性，只保存在本节点
+	send    RemoteSender    // 远程发送函数
+	request RemoteRequester // 远程请求函数
+	close   RemoteCloser    // 远程关闭函数
+}
+
+// NewRemote 创建一个位于其他节点的连接对象
+//
+// 参数：
+//   - id int64 连接ID
+//   - workID int64 连接所在节点的工作ID
+//   - send RemoteSender 远程发送函数，为 nil 时 Send 返回 ErrNotConnected
+//   - opts ...RemoteOption Remote 选项，用于设置远程请求和关闭函数
+//
+// 返回值：
+//   - *Remote 返回一个指向 Remote 类型的指针
+func NewRemote(id, workID int64, send RemoteSender, opts ...RemoteOption) *Remote {
+	r := &Remote{info: Info{ID: id, WorkID: workID, Remote: true}, send: send}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// ID 返回连接ID
+func (r *Remote) ID() int64 {
+	return r.info.ID
+}
+
+// Send 将事件转发到连接所在的节点
+//
+// 参数：
+//   - topic string 主题名称
+//   - data any 任意类型的数据
+//
+// 返回值：
+//   - error 返回错误信息
+func (r *Remote) Send(topic string, data any) error {
+	if r.send == nil {
+		return ErrNotConnected
+	}
+
+	return r.send(r.info, topic, data)
+}
+
+// Request 通过远程请求函数向连接所在的节点发送请求事件，并等待客户端的响应
+//
+// 参数：
+//   - ctx context.Context 上下文，用于设置等待响应的超时时间
+//   - topic string 主题名称
+//   - data any 任意类型的数据
+//
+// 返回值：
+//   - event.Event 响应事件，只包含主题和响应数据
+//   - error 没有设置远程请求函数时返回 ErrUnsupported
+func (r *Remote) Request(ctx context.Context, topic string, data any) (event.Event, error) {
+	if r.request == nil {
+		return event.Event{}, ErrUnsupported
+	}
+
+	value, err := r.request(ctx, r.info, topic, data)
+	if err != nil {
+		return event.Event{}, err
+	}
+
+	return event.Event{Topic: topic, Data: value}, nil
+}
+
+// Reply 位于其他节点的连接不支持响应，返回 ErrUnsupported
+func (r *Remote) Reply(e event.Event, data any) error {
+	return ErrUnsupported
+}
+
+// Close 通过远程关闭函数通知连接所在的节点关闭连接，没有设置远程关闭函数时返回 ErrUnsupported
+func (r *Remote) Close() error {
+	if r.close == nil {
+		return ErrUnsupported
+	}
+
+	return r.close(r.info)
+}
+
+// Info 返回连接的基本信息
+func (r *Remote) Info() Info {
+	return r.info
+}
+
+// Attributes 返回连接的属性
+func (r *Remote) Attributes() *Attributes {
+	return &r.attrs
+}
@@ -0,0 +1,86 @@
+package encoding
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cotton-go/socket/pkg/event"
+)
+
+// JSON 使用 JSON 格式序列化事件，与未引入序列化器前的格式兼容
+var JSON Serializer = jsonSerializer{}
+
+// jsonEvent 是事件在连接上的 JSON 表示，Data 保留原始字节用于 Event.Scan
+type jsonEvent struct {
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+	Seq   int64           `json:"seq,omitempty"`
+	Reply int64           `json:"reply,omitempty"`
+}
+
+// jsonSerializer 结构体，实现了 JSON 格式的序列化器
+type jsonSerializer struct{}
+
+// Name 返回序列化器的名称
+func (jsonSerializer) Name() string {
+	return "json"
+}
+
+// Marshal 将值序列化为 JSON
+func (jsonSerializer) Marshal(value any) ([]byte, error) {
+	return sonic.Marshal(value)
+}
+
+// Unmarshal 将 JSON 反序列化到 value 指向的值中
+func (jsonSerializer) Unmarshal(data []byte, value any) error {
+	return sonic.Unmarshal(data, value)
+}
+
+// NewEncoder 创建一个写入 JSON 流的编码器
+func (jsonSerializer) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+// NewDecoder 创建一个读取 JSON 流的解码器
+func (jsonSerializer) NewDecoder(r io.Reader) Decoder {
+	return &jsonDecoder{dec: json.NewDecoder(r)}
+}
+
+// jsonDecoder 结构体，解码事件时保留数据的原始 JSON
+type jsonDecoder struct {
+	dec *json.Decoder
+}
+
+// Decode 从流中读取下一个值，value 为 *event.Event 时保留数据的原始 JSON
+//
+// 参数：
+//   - value any 接收解码结果的指针
+//
+// 返回值：
+//   - error 返回错误信息
+func (d *jsonDecoder) Decode(value any) error {
+	e, ok := value.(*event.Event)
+	if !ok {
+		return d.dec.Decode(value)
+	}
+
+	var wire jsonEvent
+	if err := d.dec.Decode(&wire); err != nil {
+		return err
+	}
+
+	e.Topic, e.Data, e.Seq, e.Reply = wire.Topic, nil, wire.Seq, wire.Reply
+	e.SetRaw(nil, nil)
+	if len(wire.Data) == 0 || string(wire.Data) == "null" {
+		return nil
+	}
+
+	if err := sonic.Unmarshal(wire.Data, &e.Data); err != nil {
+		return err
+	}
+
+	e.SetRaw(wire.Data, sonic.Unmarshal)
+	return nil
+}
@@ -0,0 +1,105 @@
+package encoding
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+
+	"github.com/cotton-go/socket/pkg/event"
+)
+
+// MsgPack 使用 MessagePack 格式序列化事件，保留整数和二进制类型，体积小于 JSON
+var MsgPack Serializer = newMsgpackSerializer()
+
+// msgpackNil 是 MessagePack 中 nil 的编码
+const msgpackNil byte = 0xc0
+
+// msgpackEvent 是事件在连接上的 MessagePack 表示，Data 保留原始字节用于 Event.Scan
+type msgpackEvent struct {
+	Topic string    `codec:"topic"`
+	Data  codec.Raw `codec:"data"`
+	Seq   int64     `codec:"seq,omitempty"`
+	Reply int64     `codec:"reply,omitempty"`
+}
+
+// msgpackSerializer 结构体，实现了 MessagePack 格式的序列化器
+type msgpackSerializer struct {
+	handle *codec.MsgpackHandle
+}
+
+// newMsgpackSerializer 创建 MessagePack 序列化器。
+// 使用新版规范区分字符串和二进制数据，字符串解码为 string,二进制数据解码为 []byte,map 解码为 map[string]any。
+func newMsgpackSerializer() *msgpackSerializer {
+	handle := &codec.MsgpackHandle{WriteExt: true}
+	handle.MapType = reflect.TypeOf(map[string]any(nil))
+	handle.Raw = true
+	handle.ReaderBufferSize = 4096
+	return &msgpackSerializer{handle: handle}
+}
+
+// Name 返回序列化器的名称
+func (s *msgpackSerializer) Name() string {
+	return "msgpack"
+}
+
+// Marshal 将值序列化为 MessagePack
+func (s *msgpackSerializer) Marshal(value any) ([]byte, error) {
+	var b []byte
+	err := codec.NewEncoderBytes(&b, s.handle).Encode(value)
+	return b, err
+}
+
+// Unmarshal 将 MessagePack 反序列化到 value 指向的值中
+func (s *msgpackSerializer) Unmarshal(data []byte, value any) error {
+	return codec.NewDecoderBytes(data, s.handle).Decode(value)
+}
+
+// NewEncoder 创建一个写入 MessagePack 流的编码器
+func (s *msgpackSerializer) NewEncoder(w io.Writer) Encoder {
+	return codec.NewEncoder(w, s.handle)
+}
+
+// NewDecoder 创建一个读取 MessagePack 流的解码器
+func (s *msgpackSerializer) NewDecoder(r io.Reader) Decoder {
+	return &msgpackDecoder{dec: codec.NewDecoder(r, s.handle), serializer: s}
+}
+
+// msgpackDecoder 结构体，解码事件时保留数据的原始 MessagePack
+type msgpackDecoder struct {
+	dec        *codec.Decoder
+	serializer *msgpackSerializer
+}
+
+// Decode 从流中读取下一个值，value 为 *event.Event 时保留数据的原始 MessagePack
+//
+// 参数：
+//   - value any 接收解码结果的指针
+//
+// 返回值：
+//   - error 返回错误信息
+func (d *msgpackDecoder) Decode(value any) error {
+	e, ok := value.(*event.Event)
+	if !ok {
+		return d.dec.Decode(value)
+	}
+
+	var wire msgpackEvent
+	if err := d.dec.Decode(&wire); err != nil {
+		return err
+	}
+
+	e.Topic, e.Data, e.Seq, e.Reply = wire.Topic, nil, wire.Seq, wire.Reply
+	e.SetRaw(nil, nil)
+	if len(wire.Data) == 0 || (len(wire.Data) == 1 && wire.Data[0] == msgpackNil) {
+		return nil
+	}
+
+	raw := []byte(wire.Data)
+	if err := d.serializer.Unmarshal(raw, &e.Data); err != nil {
+		return err
+	}
+
+	e.SetRaw(raw, d.serializer.Unmarshal)
+	return nil
+}
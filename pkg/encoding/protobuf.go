@@ -0,0 +1,314 @@
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bytedance/sonic"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/cotton-go/socket/pkg/event"
+)
+
+// Protobuf 使用 Protobuf 格式序列化事件，每个事件前带有 varint 长度前缀。
+// 事件数据为 proto.Message 时按消息类型编码，接收方需要导入对应的生成代码才能解析为消息。
+var Protobuf Serializer = protobufSerializer{}
+
+// 事件在连接上的字段编号
+const (
+	protoTopic protowire.Number = 1 // 事件主题
+	protoData  protowire.Number = 2 // 事件数据
+	protoType  protowire.Number = 3 // 事件数据的类型
+	protoSeq   protowire.Number = 4 // 请求序号
+	protoReply protowire.Number = 5 // 响应对应的请求序号
+)
+
+// 非 proto.Message 数据的类型名称，[]byte 不携带类型名称
+const (
+	protoTypeString = "string" // 字符串
+	protoTypeJSON   = "json"   // 其他类型使用 JSON 编码
+)
+
+// maxProtoMessage 是单个事件允许的最大长度，防止长度前缀异常时分配过大的内存
+const maxProtoMessage = 64 << 20
+
+// protobufSerializer 结构体，实现了 Protobuf 格式的序列化器
+type protobufSerializer struct{}
+
+// Name 返回序列化器的名称
+func (protobufSerializer) Name() string {
+	return "protobuf"
+}
+
+// Marshal 将值序列化为 Protobuf。
+// 事件以外的值按事件数据编码为不带主题的事件，proto.Message 会携带类型名称，需要使用 Unmarshal 解析。
+//
+// 参数：
+//   - value any event.Event、*event.Event 或其他类型的值
+//
+// 返回值：
+//   - []byte 序列化后的数据
+//   - error 返回错误信息
+func (protobufSerializer) Marshal(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case event.Event:
+		return marshalProtoEvent(&v)
+	case *event.Event:
+		return marshalProtoEvent(v)
+	default:
+		return marshalProtoEvent(&event.Event{Data: value})
+	}
+}
+
+// Unmarshal 将 Protobuf 反序列化到 value 指向的值中
+//
+// 参数：
+//   - data []byte 序列化后的数据
+//   - value any *event.Event 或接收事件数据的指针，数据为 proto.Message 时 value 需要是对应类型的 proto.Message
+//
+// 返回值：
+//   - error 如果数据格式错误，则返回错误信息
+func (protobufSerializer) Unmarshal(data []byte, value any) error {
+	if e, ok := value.(*event.Event); ok {
+		return unmarshalProtoEvent(data, e)
+	}
+
+	var e event.Event
+	if err := unmarshalProtoEvent(data, &e); err != nil {
+		return err
+	}
+
+	if v, ok := value.(*any); ok {
+		*v = e.Data
+		return nil
+	}
+
+	if e.Data == nil {
+		return nil
+	}
+
+	return e.Scan(value)
+}
+
+// NewEncoder 创建一个写入带长度前缀的 Protobuf 流的编码器
+func (s protobufSerializer) NewEncoder(w io.Writer) Encoder {
+	return &protobufEncoder{w: w, serializer: s}
+}
+
+// NewDecoder 创建一个读取带长度前缀的 Protobuf 流的解码器
+func (s protobufSerializer) NewDecoder(r io.Reader) Decoder {
+	return &protobufDecoder{r: bufio.NewReader(r), serializer: s}
+}
+
+// protobufEncoder 结构体，每个值前写入 varint 长度前缀
+type protobufEncoder struct {
+	w          io.Writer
+	serializer protobufSerializer
+}
+
+// Encode 将值序列化后写入流中
+func (e *protobufEncoder) Encode(value any) error {
+	b, err := e.serializer.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(append(protowire.AppendVarint(nil, uint64(len(b))), b...))
+	return err
+}
+
+// protobufDecoder 结构体，按 varint 长度前缀读取每个值
+type protobufDecoder struct {
+	r          *bufio.Reader
+	serializer protobufSerializer
+}
+
+// Decode 从流中读取下一个值
+func (d *protobufDecoder) Decode(value any) error {
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return err
+	}
+
+	if size > maxProtoMessage {
+		return fmt.Errorf("protobuf: message too large: %d", size)
+	}
+
+	// 按实际收到的数据逐步分配内存，长度前缀不可信，不能按 size 一次分配；超过最大帧大小时由底层的读取返回错误
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d.r, int64(size)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return err
+	}
+
+	return d.serializer.Unmarshal(buf.Bytes(), value)
+}
+
+// marshalProtoEvent 将事件编码为 Protobuf
+func marshalProtoEvent(e *event.Event) ([]byte, error) {
+	data, name, err := marshalProtoData(e.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	b := protowire.AppendTag(nil, protoTopic, protowire.BytesType)
+	b = protowire.AppendString(b, e.Topic)
+	if data != nil {
+		b = protowire.AppendTag(b, protoData, protowire.BytesType)
+		b = protowire.AppendBytes(b, data)
+	}
+
+	if name != "" {
+		b = protowire.AppendTag(b, protoType, protowire.BytesType)
+		b = protowire.AppendString(b, name)
+	}
+
+	if e.Seq != 0 {
+		b = protowire.AppendTag(b, protoSeq, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(e.Seq))
+	}
+
+	if e.Reply != 0 {
+		b = protowire.AppendTag(b, protoReply, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(e.Reply))
+	}
+
+	return b, nil
+}
+
+// marshalProtoData 编码事件数据，返回编码后的数据和类型名称，数据为 nil 时返回的数据也为 nil
+func marshalProtoData(value any) ([]byte, string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, "", nil
+	case proto.Message:
+		b, err := proto.Marshal(v)
+		if b == nil {
+			b = []byte{}
+		}
+
+		return b, string(v.ProtoReflect().Descriptor().FullName()), err
+	case []byte:
+		if v == nil {
+			v = []byte{}
+		}
+
+		return v, "", nil
+	case string:
+		return []byte(v), protoTypeString, nil
+	default:
+		b, err := sonic.Marshal(v)
+		return b, protoTypeJSON, err
+	}
+}
+
+// unmarshalProtoEvent 从 Protobuf 解码事件，并保留数据的原始字节用于 Event.Scan
+func unmarshalProtoEvent(b []byte, e *event.Event) error {
+	var (
+		data    []byte
+		hasData bool
+		name    string
+	)
+
+	e.Topic, e.Data, e.Seq, e.Reply = "", nil, 0, 0
+	e.SetRaw(nil, nil)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+
+		b = b[n:]
+		if typ == protowire.VarintType && (num == protoSeq || num == protoReply) {
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+
+			b = b[n:]
+			if num == protoSeq {
+				e.Seq = int64(v)
+			} else {
+				e.Reply = int64(v)
+			}
+
+			continue
+		}
+
+		if typ != protowire.BytesType || num < protoTopic || num > protoType {
+			if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+				return protowire.ParseError(n)
+			}
+
+			b = b[n:]
+			continue
+		}
+
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+
+		b = b[n:]
+		switch num {
+		case protoTopic:
+			e.Topic = string(v)
+		case protoData:
+			data, hasData = v, true
+		case protoType:
+			name = string(v)
+		}
+	}
+
+	if !hasData {
+		return nil
+	}
+
+	switch name {
+	case "":
+		e.Data = append([]byte{}, data...)
+	case protoTypeString:
+		e.Data = string(data)
+	case protoTypeJSON:
+		if err := sonic.Unmarshal(data, &e.Data); err != nil {
+			return err
+		}
+
+		e.SetRaw(data, sonic.Unmarshal)
+	default:
+		mt, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(name))
+		if err != nil {
+			return fmt.Errorf("protobuf: %s: %w", name, err)
+		}
+
+		msg := mt.New().Interface()
+		if err := proto.Unmarshal(data, msg); err != nil {
+			return err
+		}
+
+		e.Data = msg
+		e.SetRaw(data, unmarshalProtoData)
+	}
+
+	return nil
+}
+
+// unmarshalProtoData 将事件的原始数据解析到 proto.Message 中
+func unmarshalProtoData(data []byte, value any) error {
+	msg, ok := value.(proto.Message)
+	if !ok {
+		return errors.New("protobuf: value must be a proto.Message")
+	}
+
+	return proto.Unmarshal(data, msg)
+}
@@ -0,0 +1,74 @@
+package encoding
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Serializer 接口定义了事件在连接上的表示形式
+type Serializer interface {
+	// Name 返回序列化器的名称
+	Name() string
+
+	// Marshal 将任意类型的值序列化为字节数组
+	Marshal(value any) ([]byte, error)
+
+	// Unmarshal 将字节数组反序列化到 value 指向的值中
+	Unmarshal(data []byte, value any) error
+
+	// NewEncoder 创建一个将事件写入 w 的编码器
+	NewEncoder(w io.Writer) Encoder
+
+	// NewDecoder 创建一个从 r 读取事件的解码器
+	NewDecoder(r io.Reader) Decoder
+}
+
+var (
+	lock        sync.RWMutex                  // 读写锁
+	serializers = make(map[string]Serializer) // 已注册的序列化器，键为小写的名称
+)
+
+// Register 注册一个序列化器，注册后可以在配置文件中通过名称选择。
+// 名称不区分大小写，重复注册时会 panic。
+//
+// 参数：
+//   - serializer Serializer 序列化器
+func Register(serializer Serializer) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	name := strings.ToLower(serializer.Name())
+	if _, ok := serializers[name]; ok {
+		panic("encoding: Register called twice for " + name)
+	}
+
+	serializers[name] = serializer
+}
+
+// Get 根据名称查找已注册的序列化器
+//
+// 参数：
+//   - name string 序列化器名称
+//
+// 返回值：
+//   - Serializer 序列化器
+//   - error 如果名称未注册，则返回错误信息
+func Get(name string) (Serializer, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	serializer, ok := serializers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown serializer %q", name)
+	}
+
+	return serializer, nil
+}
+
+func init() {
+	Register(JSON)
+	Register(MsgPack)
+	Register(Protobuf)
+}
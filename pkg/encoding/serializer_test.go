@@ -0,0 +1,159 @@
+package encoding_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/cotton-go/socket/pkg/encoding"
+	"github.com/cotton-go/socket/pkg/event"
+)
+
+type payload struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Body []byte `json:"body"`
+}
+
+func TestSerializer(t *testing.T) {
+	want := payload{ID: 9007199254740993, Name: "xxx", Body: []byte{0, 1, 2}}
+	for _, name := range []string{"json", "MsgPack", "protobuf"} {
+		t.Run(name, func(t *testing.T) {
+			serializer, err := encoding.Get(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			enc := serializer.NewEncoder(&buf)
+			for _, e := range []event.Event{{Topic: "a", Data: want}, {Topic: "b", Seq: 1, Reply: 2}, {Topic: "c", Data: []byte("raw")}} {
+				if err := enc.Encode(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			dec := serializer.NewDecoder(&buf)
+			var e event.Event
+			if err := dec.Decode(&e); err != nil {
+				t.Fatal(err)
+			}
+
+			var got payload
+			if err := e.Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+
+			if e.Topic != "a" || got.ID != want.ID || got.Name != want.Name || !bytes.Equal(got.Body, want.Body) {
+				t.Fatalf("got %q %+v", e.Topic, got)
+			}
+
+			if err := dec.Decode(&e); err != nil {
+				t.Fatal(err)
+			}
+
+			if e.Topic != "b" || e.Data != nil || e.Seq != 1 || e.Reply != 2 {
+				t.Fatalf("got %q %v %d %d", e.Topic, e.Data, e.Seq, e.Reply)
+			}
+
+			if err := dec.Decode(&e); err != nil {
+				t.Fatal(err)
+			}
+
+			var raw []byte
+			if err := e.Scan(&raw); err != nil {
+				t.Fatal(err)
+			}
+
+			if e.Topic != "c" || string(raw) != "raw" {
+				t.Fatalf("got %q %v", e.Topic, e.Data)
+			}
+		})
+	}
+
+	if _, err := encoding.Get("xml"); err == nil {
+		t.Fatal("expected unknown serializer error")
+	}
+}
+
+func TestProtobufTruncated(t *testing.T) {
+	// 长度前缀声明的长度大于实际收到的数据
+	b := append(protowire.AppendVarint(nil, 60<<20), "short"...)
+	var e event.Event
+	if err := encoding.Protobuf.NewDecoder(bytes.NewReader(b)).Decode(&e); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestProtobufMessage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := encoding.Protobuf.NewEncoder(&buf).Encode(event.Event{Topic: "a", Data: wrapperspb.String("xxx")}); err != nil {
+		t.Fatal(err)
+	}
+
+	var e event.Event
+	if err := encoding.Protobuf.NewDecoder(&buf).Decode(&e); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg, ok := e.Data.(*wrapperspb.StringValue); !ok || msg.GetValue() != "xxx" {
+		t.Fatalf("got %T %v", e.Data, e.Data)
+	}
+
+	var got wrapperspb.StringValue
+	if err := e.Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !proto.Equal(&got, wrapperspb.String("xxx")) {
+		t.Fatalf("got %v", &got)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	want := payload{ID: 9007199254740993, Name: "xxx", Body: []byte{0, 1, 2}}
+	for _, serializer := range []encoding.Serializer{encoding.JSON, encoding.MsgPack, encoding.Protobuf} {
+		t.Run(serializer.Name(), func(t *testing.T) {
+			b, err := serializer.Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got payload
+			if err := serializer.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.ID != want.ID || got.Name != want.Name || !bytes.Equal(got.Body, want.Body) {
+				t.Fatalf("got %+v", got)
+			}
+
+			var value any
+			if err := serializer.Unmarshal(b, &value); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := value.(map[string]any); !ok {
+				t.Fatalf("got %T", value)
+			}
+		})
+	}
+
+	b, err := encoding.Protobuf.Marshal(wrapperspb.String("xxx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got wrapperspb.StringValue
+	if err := encoding.Protobuf.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.GetValue() != "xxx" {
+		t.Fatalf("got %v", &got)
+	}
+}
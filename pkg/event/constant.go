@@ -5,4 +5,7 @@ const (
 	TopicByHeartbeat = "__heartbeat__"
 	TopicByClose     = "__close__"
 	TopicByLogin     = "__login__"
+	TopicByHandshake = "__handshake__"
+	TopicByAuth      = "__auth__"
+	TopicByKick      = "__kick__"
 )
@@ -9,11 +9,31 @@ import (
 
 // Event 结构体定义了一个事件，包含主题和数据两个字段
 type Event struct {
-	Topic string `json:"topic"` // 事件主题，字符串类型
-	Data  any    `json:"data"`  // 事件数据，任意类型
+	Topic string `json:"topic"`           // 事件主题，字符串类型
+	Data  any    `json:"data"`            // 事件数据，任意类型
+	Seq   int64  `json:"seq,omitempty"`   // 请求序号，请求方等待响应时不为 0
+	Reply int64  `json:"reply,omitempty"` // 响应对应的请求序号，不为 0 时表示该事件是响应
+
+	raw       []byte                  // 数据在连接上的原始表示，由解码器设置
+	unmarshal func([]byte, any) error // 解析原始数据的函数
+}
+
+// SetRaw 设置数据在连接上的原始表示，Scan 会直接从原始数据解析，避免中间类型丢失整数和二进制类型。
+//
+// 参数：
+//   - raw []byte 原始数据，为 nil 时清除
+//   - unmarshal func([]byte, any) error 解析原始数据的函数
+func (e *Event) SetRaw(raw []byte, unmarshal func([]byte, any) error) {
+	e.raw = raw
+	e.unmarshal = unmarshal
 }
 
 func (e *Event) Scan(value any) error {
+	// 优先从连接上的原始数据解析
+	if e.raw != nil && e.unmarshal != nil {
+		return e.unmarshal(e.raw, value)
+	}
+
 	to := reflect.TypeOf(e.Data)
 	if to.Kind() == reflect.Pointer {
 		to = to.Elem()
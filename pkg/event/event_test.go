@@ -1,6 +1,10 @@
 package event
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/bytedance/sonic"
+)
 
 func TestScan(t *testing.T) {
 	t.Run("int", func(t *testing.T) {
@@ -42,3 +46,20 @@ func TestScan(t *testing.T) {
 		t.Log("resp map:", resp)
 	})
 }
+
+func TestScanRaw(t *testing.T) {
+	e := Event{Data: map[string]any{"id": float64(1)}}
+	e.SetRaw([]byte(`{"id":9007199254740993}`), sonic.Unmarshal)
+
+	var resp struct {
+		ID int64 `json:"id"`
+	}
+
+	if err := e.Scan(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.ID != 9007199254740993 {
+		t.Fatalf("resp id = %d", resp.ID)
+	}
+}
@@ -0,0 +1,404 @@
+package handshake
+
+import (
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/cotton-go/socket/pkg/codec"
+)
+
+// info 是 HKDF 派生会话密钥时使用的上下文信息
+const info = "cotton-go/socket handshake v1"
+
+// ProtocolVersion 表示当前的协议版本
+const ProtocolVersion = 2
+
+// BinaryVersion 是使用字节数组格式的最低协议版本，协商的版本不低于该版本时，
+// 实现了 codec.BytesCodec 的编解码器直接加密序列化后的字节数组，低于该版本的客户端使用 Base64 字符串格式
+const BinaryVersion = 2
+
+// ErrRejected 表示服务端拒绝了握手请求
+var ErrRejected = errors.New("握手被拒绝")
+
+// Capabilities 结构体表示一方支持的能力
+type Capabilities struct {
+	Version     int      `json:"version"`     // 协议版本
+	Codecs      []string `json:"codecs"`      // 支持的编解码器，按优先级从高到低排列
+	Compression []string `json:"compression"` // 支持的压缩编解码器，按优先级从高到低排列
+	MaxFrame    int      `json:"maxFrame"`    // 最大帧大小(字节),0 表示不限制
+}
+
+// Selected 结构体表示服务端协商出的结果
+type Selected struct {
+	Version     int    `json:"version"`     // 协议版本
+	Codec       string `json:"codec"`       // 编解码器名称
+	Compression string `json:"compression"` // 压缩编解码器名称，为空表示不压缩
+	MaxFrame    int    `json:"maxFrame"`    // 最大帧大小(字节),0 表示不限制
+}
+
+// Hello 结构体表示客户端发起握手时发送的数据
+type Hello struct {
+	Key          []byte       `json:"key"`          // 客户端临时公钥
+	Capabilities Capabilities `json:"capabilities"` // 客户端支持的能力
+}
+
+// Reply 结构体表示服务端响应握手时返回的数据
+type Reply struct {
+	ID       int64    `json:"ID"`              // 连接ID
+	WorkID   int64    `json:"WorkID"`          // 工作ID
+	Static   []byte   `json:"static"`          // 服务端静态公钥
+	Key      []byte   `json:"key"`             // 服务端临时公钥
+	Proof    []byte   `json:"proof"`           // 服务端使用确认密钥对握手记录计算的签名
+	Selected Selected `json:"selected"`        // 协商结果
+	Error    string   `json:"error,omitempty"` // 拒绝连接的原因，为空表示握手成功
+}
+
+// GenerateKey 生成一对 X25519 静态密钥，用于配置服务端身份
+//
+// 返回值：
+//   - string Base64 编码的私钥，配置在服务端
+//   - string Base64 编码的公钥，配置在客户端用于身份固定
+//   - error 返回错误信息
+func GenerateKey() (string, string, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key.Bytes()), base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// Server 结构体表示服务端握手配置，持有服务端的静态私钥和可协商的能力
+type Server struct {
+	key  *ecdh.PrivateKey // 服务端静态私钥
+	opts options          // 服务端支持的能力
+}
+
+// NewServer 创建一个新的服务端握手实例
+//
+// 参数：
+//   - privateKey string Base64 编码的 X25519 私钥，如果为空则生成一个随机私钥(此时客户端无法固定服务端身份)
+//   - opts ...Option 服务端支持的能力，未配置编解码器时只使用会话编解码器
+//
+// 返回值：
+//   - *Server 服务端握手实例
+//   - error 如果私钥格式错误，则返回错误信息
+func NewServer(privateKey string, opts ...Option) (*Server, error) {
+	if privateKey == "" {
+		key, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Server{key: key, opts: newOptions(opts...)}, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "私钥格式错误")
+	}
+
+	key, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "私钥格式错误")
+	}
+
+	return &Server{key: key, opts: newOptions(opts...)}, nil
+}
+
+// PublicKey 返回 Base64 编码的服务端静态公钥
+func (s *Server) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.PublicKey().Bytes())
+}
+
+// Accept 处理客户端的握手请求，协商双方都支持的能力，生成服务端临时密钥并派生出会话编解码器
+//
+// 参数：
+//   - hello Hello 客户端发送的握手数据
+//
+// 返回值：
+//   - Reply 需要返回给客户端的握手数据，ID 和 WorkID 由调用方填充，拒绝连接时 Error 为拒绝原因
+//   - codec.ICodec 协商出的编解码器
+//   - error 返回错误信息，拒绝连接时返回 ErrRejected
+func (s *Server) Accept(hello Hello) (Reply, codec.ICodec, error) {
+	caps := hello.Capabilities
+	if caps.Version < s.opts.minVersion {
+		return reject(fmt.Sprintf("协议版本 %d 过低，最低支持版本 %d", caps.Version, s.opts.minVersion))
+	}
+
+	// 未携带能力列表的客户端只支持会话编解码器
+	peerCodecs := caps.Codecs
+	if len(peerCodecs) == 0 {
+		peerCodecs = []string{SessionCodec}
+	}
+
+	chosen, ok := choose(s.opts.codecs, peerCodecs)
+	if !ok {
+		return reject(fmt.Sprintf("没有双方都支持的编解码器，服务端支持 %v", names(s.opts.codecs)))
+	}
+
+	compression, _ := choose(s.opts.compression, caps.Compression)
+	selected := Selected{
+		Version:     caps.Version,
+		Codec:       chosen.name,
+		Compression: compression.name,
+		MaxFrame:    minFrame(s.opts.maxFrame, caps.MaxFrame),
+	}
+
+	if selected.Version > ProtocolVersion {
+		selected.Version = ProtocolVersion
+	}
+
+	peer, err := ecdh.X25519().NewPublicKey(hello.Key)
+	if err != nil {
+		return Reply{}, nil, errors.Wrap(err, "客户端公钥格式错误")
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return Reply{}, nil, err
+	}
+
+	ee, err := ephemeral.ECDH(peer)
+	if err != nil {
+		return Reply{}, nil, err
+	}
+
+	es, err := s.key.ECDH(peer)
+	if err != nil {
+		return Reply{}, nil, err
+	}
+
+	reply := Reply{
+		Static:   s.key.PublicKey().Bytes(),
+		Key:      ephemeral.PublicKey().Bytes(),
+		Selected: selected,
+	}
+
+	secret, confirm, err := derive(ee, es, hello.Key, reply.Key, reply.Static)
+	if err != nil {
+		return Reply{}, nil, err
+	}
+
+	reply.Proof = prove(confirm, hello.Key, reply.Key, reply.Static, transcript(caps, selected))
+	return reply, build(chosen, compression, secret), nil
+}
+
+// reject 返回拒绝连接的握手响应
+func reject(reason string) (Reply, codec.ICodec, error) {
+	return Reply{Error: reason}, nil, errors.Wrap(ErrRejected, reason)
+}
+
+// Client 结构体表示客户端握手配置
+type Client struct {
+	pinned []byte  // 固定的服务端静态公钥，为空时不校验服务端身份
+	opts   options // 客户端支持的能力
+}
+
+// NewClient 创建一个新的客户端握手实例
+//
+// 参数：
+//   - serverKey string Base64 编码的服务端静态公钥，如果为空则不校验服务端身份
+//   - opts ...Option 客户端支持的能力，未配置编解码器时只使用会话编解码器
+//
+// 返回值：
+//   - *Client 客户端握手实例
+//   - error 如果公钥格式错误，则返回错误信息
+func NewClient(serverKey string, opts ...Option) (*Client, error) {
+	if serverKey == "" {
+		return &Client{opts: newOptions(opts...)}, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(serverKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "服务端公钥格式错误")
+	}
+
+	if _, err := ecdh.X25519().NewPublicKey(raw); err != nil {
+		return nil, errors.Wrap(err, "服务端公钥格式错误")
+	}
+
+	return &Client{pinned: raw, opts: newOptions(opts...)}, nil
+}
+
+// Begin 为一次连接生成临时密钥对，开始握手
+//
+// 返回值：
+//   - *State 单次握手的状态
+//   - error 返回错误信息
+func (c *Client) Begin() (*State, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &State{client: c, key: key}, nil
+}
+
+// State 结构体表示客户端单次握手的状态
+type State struct {
+	client *Client          // 客户端握手配置
+	key    *ecdh.PrivateKey // 客户端临时私钥
+}
+
+// Hello 返回需要发送给服务端的握手数据
+func (s *State) Hello() Hello {
+	return Hello{
+		Key: s.key.PublicKey().Bytes(),
+		Capabilities: Capabilities{
+			Version:     ProtocolVersion,
+			Codecs:      names(s.client.opts.codecs),
+			Compression: names(s.client.opts.compression),
+			MaxFrame:    s.client.opts.maxFrame,
+		},
+	}
+}
+
+// Finish 校验服务端的握手响应并派生出会话编解码器
+//
+// 参数：
+//   - reply Reply 服务端返回的握手数据
+//
+// 返回值：
+//   - codec.ICodec 协商出的编解码器
+//   - error 如果服务端拒绝连接、服务端身份与固定公钥不一致或签名校验失败，则返回错误信息
+func (s *State) Finish(reply Reply) (codec.ICodec, error) {
+	if reply.Error != "" {
+		return nil, errors.Wrap(ErrRejected, reply.Error)
+	}
+
+	if s.client.pinned != nil && subtle.ConstantTimeCompare(s.client.pinned, reply.Static) != 1 {
+		return nil, errors.New("服务端身份校验失败")
+	}
+
+	static, err := ecdh.X25519().NewPublicKey(reply.Static)
+	if err != nil {
+		return nil, errors.Wrap(err, "服务端公钥格式错误")
+	}
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(reply.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "服务端公钥格式错误")
+	}
+
+	ee, err := s.key.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	es, err := s.key.ECDH(static)
+	if err != nil {
+		return nil, err
+	}
+
+	hello := s.Hello()
+	secret, confirm, err := derive(ee, es, hello.Key, reply.Key, reply.Static)
+	if err != nil {
+		return nil, err
+	}
+
+	// 签名覆盖客户端发送的能力列表和协商结果，防止中间人降级
+	if !hmac.Equal(reply.Proof, prove(confirm, hello.Key, reply.Key, reply.Static, transcript(hello.Capabilities, reply.Selected))) {
+		return nil, errors.New("握手签名校验失败")
+	}
+
+	chosen, ok := find(s.client.opts.codecs, reply.Selected.Codec)
+	if !ok {
+		return nil, errors.Errorf("不支持服务端选择的编解码器 %q", reply.Selected.Codec)
+	}
+
+	var compression entry
+	if reply.Selected.Compression != "" {
+		if compression, ok = find(s.client.opts.compression, reply.Selected.Compression); !ok {
+			return nil, errors.Errorf("不支持服务端选择的压缩编解码器 %q", reply.Selected.Compression)
+		}
+	}
+
+	return build(chosen, compression, secret), nil
+}
+
+// build 根据协商结果创建编解码器，先压缩再编码
+//
+// 参数：
+//   - chosen entry 协商出的编解码器
+//   - compression entry 协商出的压缩编解码器，名称为空表示不压缩
+//   - secret []byte 会话密钥
+//
+// 返回值：
+//   - codec.ICodec 编解码器
+func build(chosen, compression entry, secret []byte) codec.ICodec {
+	icodec := chosen.codec
+	if chosen.name == SessionCodec {
+		icodec = codec.NewAESGCM(string(secret))
+	}
+
+	if compression.codec != nil {
+		icodec = codec.Chain(compression.codec, icodec)
+	}
+
+	return icodec
+}
+
+// transcript 序列化能力列表和协商结果，用于计算握手签名
+func transcript(caps Capabilities, selected Selected) []byte {
+	b, _ := json.Marshal(struct {
+		Capabilities Capabilities
+		Selected     Selected
+	}{caps, selected})
+	return b
+}
+
+// minFrame 返回两个最大帧大小中较小的非零值
+func minFrame(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+
+	return a
+}
+
+// derive 使用 HKDF 从两次 X25519 计算结果中派生会话密钥和确认密钥
+//
+// 参数：
+//   - ee []byte 双方临时密钥的共享值
+//   - es []byte 客户端临时密钥与服务端静态密钥的共享值
+//   - transcript ...[]byte 握手记录，作为 HKDF 的盐值
+//
+// 返回值：
+//   - []byte 32 字节的会话密钥
+//   - []byte 32 字节的确认密钥
+//   - error 返回错误信息
+func derive(ee, es []byte, transcript ...[]byte) ([]byte, []byte, error) {
+	var salt []byte
+	for _, b := range transcript {
+		salt = append(salt, b...)
+	}
+
+	ikm := append(append([]byte{}, ee...), es...)
+	out := make([]byte, 64)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ikm, salt, []byte(info)), out); err != nil {
+		return nil, nil, err
+	}
+
+	return out[:32], out[32:], nil
+}
+
+// prove 使用确认密钥对握手记录计算 HMAC-SHA256 签名
+func prove(key []byte, transcript ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, b := range transcript {
+		mac.Write(b)
+	}
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,177 @@
+package handshake
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cotton-go/socket/pkg/codec"
+)
+
+func TestHandshake(t *testing.T) {
+	private, public, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := NewServer(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("pinned", func(t *testing.T) {
+		client, err := NewClient(public)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		state, err := client.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reply, serverCodec, err := server.Accept(state.Hello())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		clientCodec, err := state.Finish(reply)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		value, err := clientCodec.Encode("hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := serverCodec.Decode(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp != "hello" {
+			t.Fatalf("unexpected value %v", resp)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		_, other, err := GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client, err := NewClient(other)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		state, err := client.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reply, _, err := server.Accept(state.Hello())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := state.Finish(reply); err == nil {
+			t.Fatal("expected identity mismatch error")
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		client, _ := NewClient("")
+		state, err := client.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reply, _, err := server.Accept(state.Hello())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reply.Proof[0] ^= 0xff
+		if _, err := state.Finish(reply); err == nil {
+			t.Fatal("expected proof error")
+		}
+	})
+}
+
+func TestNegotiate(t *testing.T) {
+	des, _ := codec.New("DESECB", "12345678")
+	server, err := NewServer("",
+		WithMinVersion(1),
+		WithMaxFrame(4096),
+		WithCodec(SessionCodec, nil),
+		WithCodec("DESECB", des),
+		WithCompression("GZIP", codec.NewGzip(0, 0)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("select", func(t *testing.T) {
+		client, _ := NewClient("", WithCodec("DESECB", des), WithCompression("GZIP", codec.NewGzip(0, 0)), WithMaxFrame(1024))
+		state, _ := client.Begin()
+		reply, serverCodec, err := server.Accept(state.Hello())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := Selected{Version: ProtocolVersion, Codec: "DESECB", Compression: "GZIP", MaxFrame: 1024}
+		if reply.Selected != want {
+			t.Fatalf("unexpected selected %+v", reply.Selected)
+		}
+
+		clientCodec, err := state.Finish(reply)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		value, _ := clientCodec.Encode("hello")
+		if resp, err := serverCodec.Decode(value); err != nil || resp != "hello" {
+			t.Fatalf("decode: %v %v", resp, err)
+		}
+	})
+
+	t.Run("version", func(t *testing.T) {
+		client, _ := NewClient("")
+		state, _ := client.Begin()
+		hello := state.Hello()
+		hello.Capabilities.Version = 0
+		reply, _, err := server.Accept(hello)
+		if !errors.Is(err, ErrRejected) || reply.Error == "" {
+			t.Fatalf("expected rejection, got %v", err)
+		}
+
+		if _, err := state.Finish(reply); !errors.Is(err, ErrRejected) {
+			t.Fatalf("expected rejection, got %v", err)
+		}
+	})
+
+	t.Run("no codec", func(t *testing.T) {
+		client, _ := NewClient("", WithCodec("AESGCM", codec.NewAESGCM("")))
+		state, _ := client.Begin()
+		if _, _, err := server.Accept(state.Hello()); !errors.Is(err, ErrRejected) {
+			t.Fatalf("expected rejection, got %v", err)
+		}
+	})
+
+	t.Run("downgrade", func(t *testing.T) {
+		client, _ := NewClient("", WithCodec(SessionCodec, nil), WithCodec("DESECB", des))
+		state, _ := client.Begin()
+		hello := state.Hello()
+		// 中间人移除会话编解码器，迫使服务端选择较弱的编解码器
+		hello.Capabilities.Codecs = []string{"DESECB"}
+		reply, _, err := server.Accept(hello)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := state.Finish(reply); err == nil {
+			t.Fatal("expected proof error")
+		}
+	})
+}
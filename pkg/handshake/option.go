@@ -0,0 +1,171 @@
+package handshake
+
+import (
+	"strings"
+
+	"github.com/cotton-go/socket/pkg/codec"
+)
+
+// SessionCodec 是使用握手派生的会话密钥进行 AES-GCM 加密的编解码器名称，双方都支持
+const SessionCodec = "SESSION"
+
+// entry 表示一个可协商的编解码器
+type entry struct {
+	name  string       // 编解码器名称
+	codec codec.ICodec // 编解码器实例，会话编解码器为 nil
+}
+
+// options 表示握手时可协商的能力
+type options struct {
+	minVersion  int     // 允许的最低协议版本
+	maxFrame    int     // 最大帧大小(字节),0 表示不限制
+	codecs      []entry // 支持的编解码器，按优先级从高到低排列
+	compression []entry // 支持的压缩编解码器，按优先级从高到低排列
+}
+
+// Option 类型是一个函数，用于配置握手时可协商的能力
+type Option func(*options)
+
+// WithMinVersion 设置服务端允许的最低协议版本，低于该版本的客户端会被拒绝
+//
+// 参数：
+//   - value int 最低协议版本
+//
+// 返回值：
+//   - Option 返回一个配置选项
+func WithMinVersion(value int) Option {
+	return func(o *options) {
+		o.minVersion = value
+	}
+}
+
+// WithMaxFrame 设置最大帧大小，双方协商时取较小的非零值
+//
+// 参数：
+//   - value int 最大帧大小(字节),0 表示不限制
+//
+// 返回值：
+//   - Option 返回一个配置选项
+func WithMaxFrame(value int) Option {
+	return func(o *options) {
+		o.maxFrame = value
+	}
+}
+
+// WithCodec 添加一个支持的编解码器，先添加的优先级更高
+//
+// 参数：
+//   - name string 编解码器名称，双方使用相同的名称表示相同的编解码器
+//   - value codec.ICodec 编解码器实例，名称为 SessionCodec 时忽略
+//
+// 返回值：
+//   - Option 返回一个配置选项
+func WithCodec(name string, value codec.ICodec) Option {
+	return func(o *options) {
+		o.codecs = append(o.codecs, entry{name: strings.ToUpper(name), codec: value})
+	}
+}
+
+// WithCompression 添加一个支持的压缩编解码器，先添加的优先级更高
+//
+// 参数：
+//   - name string 压缩编解码器名称
+//   - value codec.ICodec 压缩编解码器实例
+//
+// 返回值：
+//   - Option 返回一个配置选项
+func WithCompression(name string, value codec.ICodec) Option {
+	return func(o *options) {
+		o.compression = append(o.compression, entry{name: strings.ToUpper(name), codec: value})
+	}
+}
+
+// NewOptions 根据编解码器配置创建可协商能力的选项
+//
+// 参数：
+//   - codecs []codec.Config 支持的编解码器，按优先级从高到低排列，名称为 SessionCodec 时使用会话密钥
+//   - compression []codec.Config 支持的压缩编解码器，按优先级从高到低排列
+//   - ring *codec.Keyring 密钥环，未配置密钥的编解码器使用该密钥环，可以为 nil
+//
+// 返回值：
+//   - []Option 配置选项
+//   - error 如果任意一个编解码器创建失败，则返回错误信息
+func NewOptions(codecs, compression []codec.Config, ring *codec.Keyring) ([]Option, error) {
+	var opts []Option
+	for _, conf := range codecs {
+		if strings.ToUpper(conf.Name) == SessionCodec {
+			opts = append(opts, WithCodec(SessionCodec, nil))
+			continue
+		}
+
+		if conf.Keyring == nil {
+			conf.Keyring = ring
+		}
+
+		icodec, err := codec.Build(conf)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, WithCodec(conf.Name, icodec))
+	}
+
+	for _, conf := range compression {
+		icodec, err := codec.Build(conf)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, WithCompression(conf.Name, icodec))
+	}
+
+	return opts, nil
+}
+
+// newOptions 应用配置选项，未配置编解码器时默认只支持会话编解码器
+func newOptions(opts ...Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if len(o.codecs) == 0 {
+		o.codecs = []entry{{name: SessionCodec}}
+	}
+
+	return o
+}
+
+// names 返回编解码器名称列表
+func names(entries []entry) []string {
+	resp := make([]string, 0, len(entries))
+	for _, e := range entries {
+		resp = append(resp, e.name)
+	}
+
+	return resp
+}
+
+// find 根据名称查找编解码器
+func find(entries []entry, name string) (entry, bool) {
+	for _, e := range entries {
+		if e.name == name {
+			return e, true
+		}
+	}
+
+	return entry{}, false
+}
+
+// choose 按 entries 的优先级选择第一个对方也支持的编解码器
+func choose(entries []entry, peer []string) (entry, bool) {
+	for _, e := range entries {
+		for _, name := range peer {
+			if strings.ToUpper(name) == e.name {
+				return e, true
+			}
+		}
+	}
+
+	return entry{}, false
+}
@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 是所有指标名称的前缀
+const namespace = "socket"
+
+// maxTopics 是按主题统计的指标中最多记录的主题数，超过后的主题记为 OtherTopic,
+// 避免客户端发送任意主题导致指标数量无限增长
+const maxTopics = 256
+
+// OtherTopic 是超过 maxTopics 后的主题在指标中的标签值
+const OtherTopic = "other"
+
+// Registry 是本项目所有指标注册的位置，包含 Go 运行时和进程的指标，应用可以向其中注册自己的指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	// ConnectionsCurrent 是本节点当前在线的连接数
+	ConnectionsCurrent = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "connections_current",
+		Help:      "Number of connections currently online on this node.",
+	})
+
+	// ConnectionsTotal 是本节点上线过的连接总数
+	ConnectionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "connections_total",
+		Help:      "Total number of connections that came online on this node.",
+	})
+
+	// Accepts 是交给 Worker 创建连接的网络连接数，包括之后被拒绝的连接
+	Accepts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "accepts_total",
+		Help:      "Total number of accepted network connections.",
+	})
+
+	// Rejects 是在密钥交换或身份校验阶段被拒绝的连接数
+	Rejects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rejects_total",
+		Help:      "Total number of connections rejected before coming online.",
+	}, []string{"reason"})
+
+	// MessagesIn 是从客户端读取的事件数
+	MessagesIn = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_in_total",
+		Help:      "Total number of events read from clients.",
+	}, []string{"topic"})
+
+	// MessagesOut 是写入客户端的事件数
+	MessagesOut = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_out_total",
+		Help:      "Total number of events written to clients.",
+	}, []string{"topic"})
+
+	// BytesIn 是从客户端读取的字节数
+	BytesIn = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bytes_in_total",
+		Help:      "Total number of bytes read from clients.",
+	}, []string{"topic"})
+
+	// BytesOut 是写入客户端的字节数
+	BytesOut = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bytes_out_total",
+		Help:      "Total number of bytes written to clients.",
+	}, []string{"topic"})
+
+	// SendQueue 是所有连接的写缓冲区中等待写入的事件数
+	SendQueue = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "send_queue_depth",
+		Help:      "Number of events waiting in connection send queues.",
+	})
+
+	// Dropped 是没有写入客户端或没有交给请求的事件数
+	Dropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dropped_messages_total",
+		Help:      "Total number of events dropped instead of being delivered.",
+	}, []string{"reason"})
+
+	// HandlerDuration 是事件处理函数的耗时
+	HandlerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "handler_duration_seconds",
+		Help:      "Time spent in event handlers.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	// CodecErrors 是编解码器编码或解码事件数据失败的次数
+	CodecErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "codec_errors_total",
+		Help:      "Total number of codec encode and decode failures.",
+	}, []string{"op"})
+
+	// ClockBackwards 是生成 ID 时遇到时钟回拨超过容忍范围的次数，每次回拨只记录一次
+	ClockBackwards = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "snowflake_clock_backwards_total",
+		Help:      "Total number of clock steps back beyond the snowflake tolerance.",
+	})
+
+	// CacheDuration 是缓存操作的耗时
+	CacheDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cache_duration_seconds",
+		Help:      "Latency of cache operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "op"})
+
+	// RegistryDuration 是注册中心操作的耗时
+	RegistryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "registry_duration_seconds",
+		Help:      "Latency of registry operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "op"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ConnectionsCurrent,
+		ConnectionsTotal,
+		Accepts,
+		Rejects,
+		MessagesIn,
+		MessagesOut,
+		BytesIn,
+		BytesOut,
+		SendQueue,
+		Dropped,
+		HandlerDuration,
+		CodecErrors,
+		ClockBackwards,
+		CacheDuration,
+		RegistryDuration,
+	)
+}
+
+// Handler 返回以 Prometheus 文本格式输出 Registry 中所有指标的 HTTP 处理函数
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+var (
+	topics     sync.Map // 已经记录的主题
+	topicCount int32    // 已经记录的主题数
+)
+
+// Topic 返回主题在指标中的标签值，记录的主题数达到 maxTopics 后，新的主题返回 OtherTopic
+//
+// 参数：
+//   - topic string 事件主题
+//
+// 返回值：
+//   - string 标签值
+func Topic(topic string) string {
+	if _, ok := topics.Load(topic); ok {
+		return topic
+	}
+
+	if atomic.AddInt32(&topicCount, 1) > maxTopics {
+		atomic.AddInt32(&topicCount, -1)
+		return OtherTopic
+	}
+
+	if _, loaded := topics.LoadOrStore(topic, struct{}{}); loaded {
+		atomic.AddInt32(&topicCount, -1)
+	}
+
+	return topic
+}
+
+// ObserveHandler 记录事件处理函数的耗时，与 defer 一起使用
+//
+// 参数：
+//   - topic string 事件主题
+//   - start time.Time 开始处理的时间
+func ObserveHandler(topic string, start time.Time) {
+	HandlerDuration.WithLabelValues(Topic(topic)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveCache 记录缓存操作的耗时，与 defer 一起使用
+//
+// 参数：
+//   - backend string 缓存的实现，例如 redis、memory
+//   - op string 操作名称
+//   - start time.Time 开始操作的时间
+func ObserveCache(backend, op string, start time.Time) {
+	CacheDuration.WithLabelValues(backend, op).Observe(time.Since(start).Seconds())
+}
+
+// ObserveRegistry 记录注册中心操作的耗时，与 defer 一起使用
+//
+// 参数：
+//   - backend string 注册中心的实现，例如 redis、gossip、static
+//   - op string 操作名称
+//   - start time.Time 开始操作的时间
+func ObserveRegistry(backend, op string, start time.Time) {
+	RegistryDuration.WithLabelValues(backend, op).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,157 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/cotton-go/socket/pkg/codec"
+	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/metrics"
+	"github.com/cotton-go/socket/pkg/worker"
+)
+
+func TestTopic(t *testing.T) {
+	if topic := metrics.Topic("known"); topic != "known" {
+		t.Fatalf("unexpected topic %q", topic)
+	}
+
+	// 主题数达到上限后，新的主题记为 OtherTopic,已经记录的主题不受影响
+	for i := 0; i < 300; i++ {
+		metrics.Topic("topic-" + strconv.Itoa(i))
+	}
+
+	if topic := metrics.Topic("unknown"); topic != metrics.OtherTopic {
+		t.Fatalf("unexpected topic %q", topic)
+	}
+
+	if topic := metrics.Topic("known"); topic != "known" {
+		t.Fatalf("unexpected topic %q", topic)
+	}
+}
+
+func TestInstrumentation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	accepts := testutil.ToFloat64(metrics.Accepts)
+	total := testutil.ToFloat64(metrics.ConnectionsTotal)
+	handled := make(chan event.Event, 10)
+	work := worker.NewWorker(
+		worker.WithContext(ctx),
+		worker.WithHandle(func(c connection.Peer, e event.Event) {
+			if e.Topic == "metrics" {
+				handled <- e
+			}
+		}),
+	)
+
+	time.Sleep(time.Millisecond * 100)
+	server, client := net.Pipe()
+	conn := connection.NewConnection(
+		connection.WithConn(client),
+		connection.WithClient(true),
+		connection.WithContext(ctx),
+	)
+
+	work.Connection(server)
+	deadline := time.Now().Add(time.Second * 2)
+	for conn.ID() == 0 || work.Local(conn.ID()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not online")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// 其他测试可能已经记录了 maxTopics 个主题
+	topic := metrics.Topic("metrics")
+	in := testutil.ToFloat64(metrics.MessagesIn.WithLabelValues(topic))
+	if err := conn.Send("metrics", "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-handled:
+	case <-time.After(time.Second * 2):
+		t.Fatal("event not handled")
+	}
+
+	if got := testutil.ToFloat64(metrics.Accepts); got != accepts+1 {
+		t.Fatalf("unexpected accepts %v", got)
+	}
+
+	if got := testutil.ToFloat64(metrics.ConnectionsTotal); got != total+1 {
+		t.Fatalf("unexpected connections total %v", got)
+	}
+
+	if got := testutil.ToFloat64(metrics.MessagesIn.WithLabelValues(topic)); got != in+1 {
+		t.Fatalf("unexpected messages in %v", got)
+	}
+
+	if got := testutil.ToFloat64(metrics.BytesIn.WithLabelValues(topic)); got <= 0 {
+		t.Fatalf("unexpected bytes in %v", got)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, name := range []string{
+		"socket_connections_current",
+		"socket_messages_in_total",
+		"socket_handler_duration_seconds",
+		"socket_cache_duration_seconds",
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), name) {
+			t.Fatalf("metric %s not exposed", name)
+		}
+	}
+}
+
+func TestRejectedTopic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rejected := make(chan string, 1)
+	work := worker.NewWorker(
+		worker.WithContext(ctx),
+		worker.WithCodec(codec.NewHMAC("1234567890123456", 0)),
+		worker.WithSecurity(func(c connection.Peer, e event.Event, err error) {
+			rejected <- e.Topic
+		}),
+	)
+
+	time.Sleep(time.Millisecond * 100)
+	server, client := net.Pipe()
+	conn := connection.NewConnection(
+		connection.WithConn(client),
+		connection.WithClient(true),
+		connection.WithContext(ctx),
+		connection.WithCodec(codec.NewHMAC("6543210987654321", 0)),
+	)
+
+	work.Connection(server)
+	if err := conn.Send("forged-topic", "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-rejected:
+	case <-time.After(time.Second * 2):
+		t.Fatal("forged event not rejected")
+	}
+
+	// 没有通过校验的事件不按照客户端指定的主题记录
+	if got := testutil.ToFloat64(metrics.MessagesIn.WithLabelValues("forged-topic")); got != 0 {
+		t.Fatalf("topic of a rejected event is recorded: %v", got)
+	}
+}
@@ -0,0 +1,580 @@
+package registry
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cotton-go/socket/pkg/metrics"
+)
+
+const (
+	// defaultProbe 是 Gossip 探测成员的默认间隔
+	defaultProbe = time.Second
+
+	// defaultSuspect 是 Gossip 成员从疑似故障到确认故障的默认时间
+	defaultSuspect = time.Second * 5
+
+	// indirectProbes 是直接探测失败后，请求其他成员间接探测的成员数
+	indirectProbes = 3
+
+	// maxPacketSize 是 UDP 报文的最大长度
+	maxPacketSize = 65507
+
+	// minGossipKey 是 Gossip 共享密钥的最小长度
+	minGossipKey = 16
+)
+
+// ErrGossipKey 表示 Gossip 共享密钥的长度不足
+var ErrGossipKey = errors.New("gossip key must be at least 16 bytes")
+
+// memberStatus 表示 Gossip 成员的状态，版本号相同时状态值大的覆盖状态值小的
+type memberStatus int
+
+const (
+	statusAlive   memberStatus = iota // 存活
+	statusSuspect                     // 疑似故障，超时后确认故障
+	statusDead                        // 已故障或已注销
+)
+
+// packet 类型
+const (
+	packetPing    = "ping"     // 探测，接收方回复 ack
+	packetAck     = "ack"      // 探测的回复
+	packetPingReq = "ping-req" // 请求接收方代为探测目标成员，收到目标的回复后转发 ack
+	packetSync    = "sync"     // 只同步成员状态，不需要回复
+)
+
+// member 结构体表示 Gossip 中的一个成员，在成员之间传播
+type member struct {
+	Node        Node         `json:"node"`        // 节点信息
+	Addr        string       `json:"addr"`        // Gossip 的 UDP 地址
+	Status      memberStatus `json:"status"`      // 成员状态
+	Incarnation uint64       `json:"incarnation"` // 由成员自己递增的版本号，用于反驳疑似故障和更新节点信息
+	changed     time.Time    // 本地记录的状态变化时间，不传播
+}
+
+// packet 结构体表示成员之间传递的 UDP 报文，每个报文都携带发送方已知的所有成员状态
+type packet struct {
+	Type    string   `json:"type"`
+	Seq     uint64   `json:"seq,omitempty"`
+	Target  string   `json:"target,omitempty"` // ping-req 间接探测的目标地址
+	Members []member `json:"members,omitempty"`
+}
+
+// Gossip 结构体，基于 SWIM 协议的嵌入式注册中心，节点之间通过 UDP 交换成员状态，不依赖 Redis 等外部存储。
+// 每隔探测间隔随机探测一个成员，直接探测失败时请求其他成员间接探测，仍然失败时将成员标记为疑似故障，
+// 疑似故障超时后确认故障。成员状态附带在每个报文中传播，适用于节点较少的部署。
+// 每个报文都使用共享密钥计算 HMAC-SHA256,签名不正确的报文直接丢弃，避免其他主机伪造成员或借本节点发送探测。
+type Gossip struct {
+	options
+	key     []byte // 成员之间共享的报文签名密钥
+	conn    *net.UDPConn
+	addr    string   // 本节点的 Gossip 地址，会传播给其他成员
+	seeds   []string // 加入集群时联系的种子地址
+	ctx     context.Context
+	cancel  context.CancelFunc
+	lock    sync.Mutex
+	self    *member           // 本节点，注册前为 nil
+	members map[int64]*member // 其他成员
+	seq     uint64            // 探测序号
+	pending map[uint64]func() // 等待回复的探测，收到 ack 时调用
+}
+
+// NewGossip 创建一个基于 SWIM 协议的注册中心，监听 UDP 地址并开始探测成员
+//
+// 参数：
+//   - addr string 监听的 UDP 地址，同时作为本节点的 Gossip 地址传播给其他成员，需要是其他节点可以访问的地址
+//   - key []byte 所有成员共享的报文签名密钥，至少 16 字节
+//   - seeds []string 种子节点的 Gossip 地址，注册后联系种子节点加入集群，没有其他成员时会定期重试
+//   - opts ...Option 注册中心选项
+//
+// 返回值：
+//   - *Gossip 注册中心
+//   - error 密钥长度不足或监听失败时返回错误信息
+func NewGossip(addr string, key []byte, seeds []string, opts ...Option) (*Gossip, error) {
+	if len(key) < minGossipKey {
+		return nil, ErrGossipKey
+	}
+
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Gossip{
+		options: newOptions(opts...),
+		key:     key,
+		conn:    conn,
+		addr:    conn.LocalAddr().String(),
+		seeds:   seeds,
+		members: make(map[int64]*member),
+		pending: make(map[uint64]func()),
+	}
+
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+	go g.receive()
+	go g.loop()
+	return g, nil
+}
+
+// Addr 返回本节点的 Gossip 地址
+func (g *Gossip) Addr() string {
+	return g.addr
+}
+
+// Close 停止探测并关闭 UDP 连接，不会通知其他成员，需要先调用 Deregister 注销
+func (g *Gossip) Close() error {
+	g.cancel()
+	return g.conn.Close()
+}
+
+// Register 注册本节点或更新本节点信息，节点信息变化时递增版本号，使其他成员更新节点信息
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node Node 本节点
+//   - ttl time.Duration 不使用，成员的存活由探测判断
+//
+// 返回值：
+//   - error 返回错误信息
+func (g *Gossip) Register(ctx context.Context, node Node, ttl time.Duration) error {
+	defer metrics.ObserveRegistry("gossip", "register", time.Now())
+	node.Expire = time.Time{}
+
+	g.lock.Lock()
+	join := g.self == nil || g.self.Status != statusAlive || g.self.Node.ID != node.ID
+	switch {
+	case g.self == nil:
+		g.self = &member{Node: node, Addr: g.addr}
+	case join:
+		g.self.Node, g.self.Status = node, statusAlive
+		g.self.Incarnation++
+	case !reflect.DeepEqual(g.self.Node, node):
+		g.self.Node = node
+		g.self.Incarnation++
+	}
+	g.lock.Unlock()
+
+	if join {
+		g.join()
+	}
+
+	return nil
+}
+
+// Deregister 注销节点，注销本节点时立即通知所有成员，注销其他节点时将其标记为故障
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - id int64 节点 ID
+//
+// 返回值：
+//   - error 节点不存在时返回 ErrNotFound
+func (g *Gossip) Deregister(ctx context.Context, id int64) error {
+	defer metrics.ObserveRegistry("gossip", "deregister", time.Now())
+	g.lock.Lock()
+	if g.self == nil || g.self.Node.ID != id || g.self.Status != statusAlive {
+		defer g.lock.Unlock()
+
+		m, ok := g.members[id]
+		if !ok || m.Status == statusDead {
+			return ErrNotFound
+		}
+
+		m.Status, m.changed = statusDead, g.now()
+		return nil
+	}
+
+	g.self.Status = statusDead
+	g.self.Incarnation++
+	addrs := make([]string, 0, len(g.members))
+	for _, m := range g.members {
+		if m.Status != statusDead {
+			addrs = append(addrs, m.Addr)
+		}
+	}
+	g.lock.Unlock()
+
+	msg := g.packet(packetSync, 0, "")
+	for _, addr := range addrs {
+		g.send(addr, msg)
+	}
+
+	return nil
+}
+
+// Discover 返回本节点和所有存活或疑似故障的成员，按 ID 排序
+func (g *Gossip) Discover(ctx context.Context) ([]Node, error) {
+	defer metrics.ObserveRegistry("gossip", "discover", time.Now())
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	nodes := make([]Node, 0, len(g.members)+1)
+	if g.self != nil && g.self.Status == statusAlive {
+		nodes = append(nodes, g.self.Node)
+	}
+
+	for _, m := range g.members {
+		if m.Status != statusDead {
+			nodes = append(nodes, m.Node)
+		}
+	}
+
+	sortNodes(nodes)
+	return nodes, nil
+}
+
+// Watch 返回节点列表的通道，每隔检查间隔比较一次节点列表
+func (g *Gossip) Watch(ctx context.Context) (<-chan []Node, error) {
+	return watch(ctx, g.interval, g.Discover)
+}
+
+// join 向种子节点发送探测，种子节点回复时会带上其已知的所有成员
+func (g *Gossip) join() {
+	msg := g.packet(packetPing, 0, "")
+	for _, seed := range g.seeds {
+		if seed != g.addr {
+			g.send(seed, msg)
+		}
+	}
+}
+
+// loop 每隔探测间隔探测一个成员，并将超时的疑似故障成员标记为故障
+func (g *Gossip) loop() {
+	ticker := time.NewTicker(g.probe)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-ticker.C:
+			g.expire()
+			g.probeOnce()
+		}
+	}
+}
+
+// probeOnce 随机探测一个成员，没有可探测的成员时重新联系种子节点
+func (g *Gossip) probeOnce() {
+	g.lock.Lock()
+	registered := g.self != nil && g.self.Status == statusAlive
+	g.lock.Unlock()
+
+	if !registered {
+		return
+	}
+
+	target, ok := g.pick()
+	if !ok {
+		g.join()
+		return
+	}
+
+	// 先直接探测，超时后请求其他成员间接探测
+	acked := make(chan struct{}, 1)
+	seq := g.await(func() {
+		select {
+		case acked <- struct{}{}:
+		default:
+		}
+	})
+	defer g.done(seq)
+
+	g.send(target.Addr, g.packet(packetPing, seq, ""))
+	timeout := time.NewTimer(g.probe / 2)
+	defer timeout.Stop()
+
+	select {
+	case <-acked:
+		return
+	case <-g.ctx.Done():
+		return
+	case <-timeout.C:
+	}
+
+	req := g.packet(packetPingReq, seq, target.Addr)
+	for _, addr := range g.helpers(target.Node.ID) {
+		g.send(addr, req)
+	}
+
+	timeout.Reset(g.probe / 2)
+	select {
+	case <-acked:
+	case <-g.ctx.Done():
+	case <-timeout.C:
+		g.suspectMember(target.Node.ID, target.Incarnation)
+	}
+}
+
+// pick 随机返回一个存活或疑似故障的成员
+func (g *Gossip) pick() (member, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	candidates := make([]*member, 0, len(g.members))
+	for _, m := range g.members {
+		if m.Status != statusDead {
+			candidates = append(candidates, m)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return member{}, false
+	}
+
+	return *candidates[rand.Intn(len(candidates))], true
+}
+
+// helpers 随机返回最多 indirectProbes 个存活成员的地址，用于间接探测目标成员
+func (g *Gossip) helpers(target int64) []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	addrs := make([]string, 0, len(g.members))
+	for id, m := range g.members {
+		if id != target && m.Status == statusAlive {
+			addrs = append(addrs, m.Addr)
+		}
+	}
+
+	rand.Shuffle(len(addrs), func(i, j int) { addrs[i], addrs[j] = addrs[j], addrs[i] })
+	if len(addrs) > indirectProbes {
+		addrs = addrs[:indirectProbes]
+	}
+
+	return addrs
+}
+
+// suspectMember 将探测失败的成员标记为疑似故障，成员的版本号已经变化时说明成员已经反驳，不做处理
+func (g *Gossip) suspectMember(id int64, incarnation uint64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if m, ok := g.members[id]; ok && m.Status == statusAlive && m.Incarnation == incarnation {
+		m.Status, m.changed = statusSuspect, g.now()
+	}
+}
+
+// expire 将疑似故障超时的成员标记为故障，并删除故障时间较长的成员
+func (g *Gossip) expire() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	now := g.now()
+	for id, m := range g.members {
+		switch {
+		case m.Status == statusSuspect && now.Sub(m.changed) >= g.suspect:
+			m.Status, m.changed = statusDead, now
+		case m.Status == statusDead && now.Sub(m.changed) >= g.suspect*10:
+			// 保留一段时间故障状态，避免过期的存活状态使成员复活
+			delete(g.members, id)
+		}
+	}
+}
+
+// await 分配一个探测序号，收到对应的 ack 时调用 fn
+func (g *Gossip) await(fn func()) uint64 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.seq++
+	g.pending[g.seq] = fn
+	return g.seq
+}
+
+// done 删除等待回复的探测
+func (g *Gossip) done(seq uint64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	delete(g.pending, seq)
+}
+
+// receive 接收并处理其他成员的报文，直到 UDP 连接被关闭
+func (g *Gossip) receive() {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, from, err := g.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-g.ctx.Done():
+				return
+			default:
+				fmt.Println("gossip receive error", err)
+				continue
+			}
+		}
+
+		// 丢弃没有签名或签名不正确的报文，不合并其中的成员状态
+		body, ok := g.verify(buf[:n])
+		if !ok {
+			fmt.Println("gossip invalid signature", from)
+			continue
+		}
+
+		var msg packet
+		if err := sonic.Unmarshal(body, &msg); err != nil {
+			fmt.Println("gossip decode error", err, from)
+			continue
+		}
+
+		g.handle(from.String(), msg)
+	}
+}
+
+// handle 合并报文中的成员状态，并按报文类型回复
+func (g *Gossip) handle(from string, msg packet) {
+	g.merge(msg.Members)
+
+	switch msg.Type {
+	case packetPing:
+		g.send(from, g.packet(packetAck, msg.Seq, ""))
+	case packetAck:
+		g.lock.Lock()
+		fn := g.pending[msg.Seq]
+		g.lock.Unlock()
+
+		if fn != nil {
+			fn()
+		}
+	case packetPingReq:
+		// 只代为探测已知的成员，收到回复后以请求方的序号转发 ack
+		if !g.known(msg.Target) {
+			return
+		}
+
+		seq := g.await(func() {
+			g.send(from, g.packet(packetAck, msg.Seq, ""))
+		})
+
+		g.send(msg.Target, g.packet(packetPing, seq, ""))
+		time.AfterFunc(g.probe, func() { g.done(seq) })
+	}
+}
+
+// known 返回地址是否为已知的存活或疑似故障成员的 Gossip 地址
+func (g *Gossip) known(addr string) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for _, m := range g.members {
+		if m.Addr == addr && m.Status != statusDead {
+			return true
+		}
+	}
+
+	return false
+}
+
+// merge 合并其他成员传播的成员状态，版本号大的覆盖版本号小的，版本号相同时状态值大的覆盖状态值小的。
+// 其他成员认为本节点疑似故障或故障时，递增本节点的版本号进行反驳。
+func (g *Gossip) merge(members []member) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	now := g.now()
+	for _, m := range members {
+		m := m
+		if g.self != nil && m.Node.ID == g.self.Node.ID {
+			if g.self.Status == statusAlive && m.Status != statusAlive && m.Incarnation >= g.self.Incarnation {
+				g.self.Incarnation = m.Incarnation + 1
+			}
+
+			continue
+		}
+
+		cur, ok := g.members[m.Node.ID]
+		if !ok {
+			// 不记录未知成员的故障状态
+			if m.Status != statusDead {
+				m.changed = now
+				g.members[m.Node.ID] = &m
+			}
+
+			continue
+		}
+
+		if m.Incarnation > cur.Incarnation || (m.Incarnation == cur.Incarnation && m.Status > cur.Status) {
+			if m.Status != cur.Status {
+				cur.changed = now
+			}
+
+			cur.Node, cur.Addr, cur.Status, cur.Incarnation = m.Node, m.Addr, m.Status, m.Incarnation
+		}
+	}
+}
+
+// packet 创建一个携带所有已知成员状态的报文
+func (g *Gossip) packet(typ string, seq uint64, target string) []byte {
+	g.lock.Lock()
+	members := make([]member, 0, len(g.members)+1)
+	if g.self != nil {
+		members = append(members, *g.self)
+	}
+
+	for _, m := range g.members {
+		members = append(members, *m)
+	}
+	g.lock.Unlock()
+
+	b, err := sonic.Marshal(packet{Type: typ, Seq: seq, Target: target, Members: members})
+	if err != nil {
+		fmt.Println("gossip encode error", err)
+	}
+
+	return g.sign(b)
+}
+
+// sign 在报文之前添加使用共享密钥计算的 HMAC-SHA256
+func (g *Gossip) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, g.key)
+	mac.Write(body)
+	return append(mac.Sum(make([]byte, 0, sha256.Size+len(body))), body...)
+}
+
+// verify 校验报文的 HMAC-SHA256,校验通过时返回去掉签名的报文
+func (g *Gossip) verify(b []byte) ([]byte, bool) {
+	if len(b) < sha256.Size {
+		return nil, false
+	}
+
+	mac := hmac.New(sha256.New, g.key)
+	mac.Write(b[sha256.Size:])
+	if !hmac.Equal(mac.Sum(nil), b[:sha256.Size]) {
+		return nil, false
+	}
+
+	return b[sha256.Size:], true
+}
+
+// send 向指定地址发送报文
+func (g *Gossip) send(addr string, b []byte) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		fmt.Println("gossip resolve error", err, addr)
+		return
+	}
+
+	if _, err := g.conn.WriteToUDP(b, udpAddr); err != nil {
+		select {
+		case <-g.ctx.Done():
+		default:
+			fmt.Println("gossip send error", err, addr)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package registry
+
+import "time"
+
+// Option 是一个函数类型，用于配置注册中心
+type Option func(*options)
+
+// options 结构体，注册中心的公共配置
+type options struct {
+	interval time.Duration    // Watch 检查节点变化的间隔
+	now      func() time.Time // 当前时间，便于测试
+	probe    time.Duration    // Gossip 探测成员的间隔
+	suspect  time.Duration    // Gossip 成员从疑似故障到确认故障的时间
+}
+
+// newOptions 根据传入的选项创建配置
+func newOptions(opts ...Option) options {
+	o := options{interval: defaultInterval, now: time.Now, probe: defaultProbe, suspect: defaultSuspect}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithInterval 设置 Watch 检查节点变化的间隔
+//
+// 参数：
+//   - value time.Duration 检查间隔，小于等于 0 时使用默认值 1 秒
+//
+// 返回值：
+//   - Option 注册中心选项
+func WithInterval(value time.Duration) Option {
+	return func(o *options) {
+		if value <= 0 {
+			value = defaultInterval
+		}
+
+		o.interval = value
+	}
+}
+
+// WithProbe 设置 Gossip 探测成员的间隔，只对 Gossip 有效
+//
+// 参数：
+//   - value time.Duration 探测间隔，小于等于 0 时使用默认值 1 秒
+//
+// 返回值：
+//   - Option 注册中心选项
+func WithProbe(value time.Duration) Option {
+	return func(o *options) {
+		if value <= 0 {
+			value = defaultProbe
+		}
+
+		o.probe = value
+	}
+}
+
+// WithSuspect 设置 Gossip 成员从疑似故障到确认故障的时间，只对 Gossip 有效
+//
+// 参数：
+//   - value time.Duration 疑似故障的超时时间，小于等于 0 时使用默认值 5 秒
+//
+// 返回值：
+//   - Option 注册中心选项
+func WithSuspect(value time.Duration) Option {
+	return func(o *options) {
+		if value <= 0 {
+			value = defaultSuspect
+		}
+
+		o.suspect = value
+	}
+}
@@ -0,0 +1,199 @@
+package registry
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/cotton-go/socket/pkg/metrics"
+)
+
+// claimScript 在雪花算法节点 ID 没有被占用或已被同一个节点占用时设置占用者和过期时间
+var claimScript = redis.NewScript(`
+local owner = redis.call('GET', KEYS[1])
+if owner == false or owner == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// Redis 结构体，使用 Redis 保存节点的注册中心。
+// 节点信息保存在哈希表中，租约的过期时间保存在有序集合中，发现节点时忽略租约已过期的节点。
+type Redis struct {
+	options
+	store  *redis.Client // Redis 客户端
+	prefix string        // 键名前缀
+}
+
+// NewRedis 创建一个使用 Redis 保存节点的注册中心
+//
+// 参数：
+//   - store *redis.Client Redis 客户端
+//   - prefix string 键名前缀，为空时使用 "socket:registry:"
+//   - opts ...Option 注册中心选项
+//
+// 返回值：
+//   - *Redis 注册中心
+func NewRedis(store *redis.Client, prefix string, opts ...Option) *Redis {
+	if prefix == "" {
+		prefix = "socket:registry:"
+	}
+
+	return &Redis{options: newOptions(opts...), store: store, prefix: prefix}
+}
+
+// makeKey 返回保存节点信息的哈希表和保存租约的有序集合的键名
+func (r *Redis) makeKey() (string, string) {
+	return r.prefix + "nodes", r.prefix + "leases"
+}
+
+// Register 注册节点或续约，同时清理租约已过期的节点
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node Node 节点
+//   - ttl time.Duration 租约有效期，小于等于 0 时使用 DefaultTTL
+//
+// 返回值：
+//   - error 返回错误信息，雪花算法节点 ID 已被其他节点占用时返回 ErrClaimed
+func (r *Redis) Register(ctx context.Context, node Node, ttl time.Duration) error {
+	defer metrics.ObserveRegistry("redis", "register", time.Now())
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	now := r.now()
+	node.Expire = now.Add(ttl)
+	value, err := sonic.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	nodes, leases := r.makeKey()
+	field := strconv.FormatInt(node.ID, 10)
+	_, err = r.store.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, nodes, field, value)
+		pipe.ZAdd(ctx, leases, redis.Z{Score: float64(node.Expire.UnixMilli()), Member: field})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 续约占用的雪花算法节点 ID,已被其他节点占用时返回错误
+	if node.Snowflake > 0 {
+		ok, err := r.Claim(ctx, node.Snowflake, claimOwner(node), ttl)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return ErrClaimed
+		}
+	}
+
+	return r.prune(ctx, now)
+}
+
+// Claim 为节点 owner 占用雪花算法节点 ID,使用 Lua 脚本原子地检查和设置占用者
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - snowflake int64 雪花算法节点 ID
+//   - owner int64 节点 ID
+//   - ttl time.Duration 租约有效期，小于等于 0 时使用 DefaultTTL
+//
+// 返回值：
+//   - bool ID 已被其他节点占用时返回 false
+//   - error 返回错误信息
+func (r *Redis) Claim(ctx context.Context, snowflake, owner int64, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	key := r.prefix + "snowflake:" + strconv.FormatInt(snowflake, 10)
+	n, err := claimScript.Run(ctx, r.store, []string{key}, owner, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// prune 删除租约已过期的节点
+func (r *Redis) prune(ctx context.Context, now time.Time) error {
+	nodes, leases := r.makeKey()
+	max := strconv.FormatInt(now.UnixMilli(), 10)
+	expired, err := r.store.ZRangeByScore(ctx, leases, &redis.ZRangeBy{Min: "-inf", Max: max}).Result()
+	if err != nil || len(expired) == 0 {
+		return err
+	}
+
+	_, err = r.store.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, nodes, expired...)
+		pipe.ZRemRangeByScore(ctx, leases, "-inf", max)
+		return nil
+	})
+
+	return err
+}
+
+// Deregister 注销节点
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - id int64 节点 ID
+//
+// 返回值：
+//   - error 返回错误信息
+func (r *Redis) Deregister(ctx context.Context, id int64) error {
+	defer metrics.ObserveRegistry("redis", "deregister", time.Now())
+	nodes, leases := r.makeKey()
+	field := strconv.FormatInt(id, 10)
+	_, err := r.store.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, nodes, field)
+		pipe.ZRem(ctx, leases, field)
+		return nil
+	})
+
+	return err
+}
+
+// Discover 返回所有租约未过期的节点，按 ID 排序
+func (r *Redis) Discover(ctx context.Context) ([]Node, error) {
+	defer metrics.ObserveRegistry("redis", "discover", time.Now())
+	nodes, leases := r.makeKey()
+	min := "(" + strconv.FormatInt(r.now().UnixMilli(), 10)
+	fields, err := r.store.ZRangeByScore(ctx, leases, &redis.ZRangeBy{Min: min, Max: "+inf"}).Result()
+	if err != nil || len(fields) == 0 {
+		return []Node{}, err
+	}
+
+	values, err := r.store.HMGet(ctx, nodes, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Node, 0, len(values))
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		var node Node
+		if err := sonic.UnmarshalString(s, &node); err != nil {
+			continue
+		}
+
+		result = append(result, node)
+	}
+
+	sortNodes(result)
+	return result, nil
+}
+
+// Watch 返回节点列表的通道，每隔检查间隔比较一次节点列表
+func (r *Redis) Watch(ctx context.Context) (<-chan []Node, error) {
+	return watch(ctx, r.interval, r.Discover)
+}
@@ -1,6 +1,257 @@
 package registry
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultTTL 是节点租约的默认有效期
+	DefaultTTL = time.Second * 10
+
+	// defaultInterval 是 Watch 检查节点变化的默认间隔
+	defaultInterval = time.Second
+)
+
+var (
+	// ErrNotFound 表示节点没有注册或租约已过期
+	ErrNotFound = errors.New("node not found")
+
+	// ErrExhausted 表示所有的雪花算法节点 ID 都已经被其他节点使用
+	ErrExhausted = errors.New("snowflake node exhausted")
+
+	// ErrClaimed 表示注册时续约的雪花算法节点 ID 已经被其他节点占用，本节点生成的 ID 可能与其他节点重复
+	ErrClaimed = errors.New("snowflake node claimed by another node")
+)
+
+// Node 结构体表示一个注册的节点
+type Node struct {
+	ID        int64     `json:"id" yaml:"ID"`                         // 节点的工作 ID
+	TCP       string    `json:"tcp" yaml:"TCP"`                       // TCP 服务地址
+	HTTP      string    `json:"http" yaml:"HTTP"`                     // HTTP 服务地址
+	GRPC      string    `json:"grpc" yaml:"GRPC"`                     // gRPC 服务地址
+	Snowflake int64     `json:"snowflake,omitempty" yaml:"Snowflake"` // 雪花算法节点 ID,0 表示未分配
+	Owner     int64     `json:"owner,omitempty" yaml:"-"`             // 占用雪花算法节点 ID 的占用者，由 AllocateSnowflake 返回，为 0 时使用 ID
+	Load      int64     `json:"load" yaml:"-"`                        // 节点上的连接数
+	Expire    time.Time `json:"expire" yaml:"-"`                      // 租约的过期时间，零值表示不会过期
+}
+
+// Registry 接口，定义了节点注册和发现的方法
 type Registry interface {
-	Register() error
-	Deregister() error
+	// Register 注册节点或续约，租约在 ttl 后过期
+	Register(ctx context.Context, node Node, ttl time.Duration) error
+
+	// Deregister 注销节点
+	Deregister(ctx context.Context, id int64) error
+
+	// Discover 返回所有租约未过期的节点，按 ID 排序
+	Discover(ctx context.Context) ([]Node, error)
+
+	// Watch 返回一个通道，先发送当前的节点列表，之后每次节点变化时发送新的节点列表，上下文被取消时关闭
+	Watch(ctx context.Context) (<-chan []Node, error)
+}
+
+// Claimer 接口由可以原子地占用雪花算法节点 ID 的注册中心实现，同时启动的节点通过它避免分配到相同的 ID
+type Claimer interface {
+	// Claim 为节点 owner 占用雪花算法节点 ID,租约在 ttl 后过期，注册节点时续约。
+	// ID 已被其他节点占用时返回 false,已被 owner 占用时续约并返回 true
+	Claim(ctx context.Context, snowflake, owner int64, ttl time.Duration) (bool, error)
+}
+
+// Keepalive 注册节点，并在租约过期前定期续约，直到上下文被取消后注销节点。
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - r Registry 注册中心
+//   - ttl time.Duration 租约有效期，小于等于 0 时使用 DefaultTTL,每隔 ttl/3 续约一次
+//   - node func() Node 返回当前节点信息的函数，每次续约时调用，用于更新节点负载
+//
+// 返回值：
+//   - error 注销失败时返回错误信息
+func Keepalive(ctx context.Context, r Registry, ttl time.Duration, node func() Node) error {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	var id int64
+	register := func() {
+		n := node()
+		id = n.ID
+		if err := r.Register(ctx, n, ttl); err != nil {
+			fmt.Println("registry register error", err, n.ID)
+		}
+	}
+
+	register()
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 上下文已被取消，使用新的上下文注销节点
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancel()
+			return r.Deregister(ctx, id)
+		case <-ticker.C:
+			register()
+		}
+	}
+}
+
+// AllocateSnowflake 返回注册中心中其他节点没有使用的最小雪花算法节点 ID,从 1 开始分配。
+// 注册中心实现了 Claimer 时原子地占用 ID,被其他节点抢先占用时尝试下一个 ID;
+// 没有实现 Claimer 的注册中心(例如 Gossip)只能根据节点列表避开，同时启动的节点可能分配到相同的 ID,需要在配置中指定节点 ID。
+// 节点需要在注册时带上分配到的 ID 和占用者，注册时续约占用的 ID。
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - r Registry 注册中心
+//   - self int64 本节点的 ID,本节点之前注册的雪花算法节点 ID 会被优先复用；为 0 时使用随机的占用者，节点注册后根据节点列表避开
+//   - max int64 雪花算法位布局支持的最大节点 ID
+//   - ttl time.Duration 占用 ID 的租约有效期，应与节点租约相同，小于等于 0 时使用 DefaultTTL
+//
+// 返回值：
+//   - int64 雪花算法节点 ID
+//   - int64 占用 ID 使用的占用者，注册节点时设置到 Node.Owner
+//   - error 获取节点列表或占用 ID 失败时返回错误信息，没有可用的 ID 时返回 ErrExhausted
+func AllocateSnowflake(ctx context.Context, r Registry, self int64, max int64, ttl time.Duration) (int64, int64, error) {
+	nodes, err := r.Discover(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	// 节点 ID 由雪花算法生成时分配前还不知道节点 ID,使用随机的占用者避免同时启动的节点使用相同的占用者
+	owner := self
+	if owner <= 0 {
+		owner = rand.Int63() + 1
+	}
+
+	claim := func(id int64) (bool, error) {
+		if claimer, ok := r.(Claimer); ok {
+			return claimer.Claim(ctx, id, owner, ttl)
+		}
+
+		return true, nil
+	}
+
+	used := make(map[int64]bool, len(nodes))
+	for _, node := range nodes {
+		if node.ID == self && node.Snowflake > 0 {
+			if ok, err := claim(node.Snowflake); err != nil || ok {
+				return node.Snowflake, owner, err
+			}
+		}
+
+		used[node.Snowflake] = true
+	}
+
+	for id := int64(1); id <= max; id++ {
+		if used[id] {
+			continue
+		}
+
+		ok, err := claim(id)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if ok {
+			return id, owner, nil
+		}
+	}
+
+	return 0, 0, ErrExhausted
+}
+
+// claimOwner 返回节点占用雪花算法节点 ID 使用的占用者
+func claimOwner(node Node) int64 {
+	if node.Owner > 0 {
+		return node.Owner
+	}
+
+	return node.ID
+}
+
+// watch 每隔 interval 调用一次 discover,节点列表变化时发送到返回的通道中。
+//
+// 参数：
+//   - ctx context.Context 上下文，被取消时关闭通道
+//   - interval time.Duration 检查间隔
+//   - discover func(context.Context) ([]Node, error) 获取节点列表的函数
+//
+// 返回值：
+//   - <-chan []Node 节点列表的通道
+//   - error 第一次获取节点列表失败时返回错误信息
+func watch(ctx context.Context, interval time.Duration, discover func(context.Context) ([]Node, error)) (<-chan []Node, error) {
+	last, err := discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []Node, 1)
+	ch <- last
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				nodes, err := discover(ctx)
+				if err != nil {
+					fmt.Println("registry watch error", err)
+					continue
+				}
+
+				if changed(last, nodes) {
+					last = nodes
+					select {
+					case ch <- nodes:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// changed 判断节点列表是否变化，只续约不视为变化
+func changed(a, b []Node) bool {
+	if len(a) != len(b) {
+		return true
+	}
+
+	for i := range a {
+		x, y := a[i], b[i]
+		x.Expire, y.Expire = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(x, y) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortNodes 按 ID 对节点排序
+func sortNodes(nodes []Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
 }
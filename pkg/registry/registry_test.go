@@ -0,0 +1,361 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/redis/go-redis/v9"
+)
+
+// gossipKey 是测试使用的 Gossip 共享密钥
+var gossipKey = []byte("0123456789abcdef")
+
+func TestStatic(t *testing.T) {
+	now := time.Now()
+	r := NewStatic([]Node{{ID: 1, GRPC: "127.0.0.1:6455"}})
+	r.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	if err := r.Register(ctx, Node{ID: 2, Load: 3}, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, _ := r.Discover(ctx)
+	if len(nodes) != 2 || nodes[0].ID != 1 || nodes[1].Load != 3 {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+
+	// 租约过期后不再被发现，固定的节点不会过期
+	now = now.Add(time.Second * 2)
+	if nodes, _ := r.Discover(ctx); len(nodes) != 1 || nodes[0].ID != 1 {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+
+	if err := r.Deregister(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Deregister(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestKeepalive(t *testing.T) {
+	r := NewStatic(nil, WithInterval(time.Millisecond*10))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := r.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nodes := <-ch; len(nodes) != 0 {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+
+	keepCtx, keepCancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- Keepalive(keepCtx, r, time.Millisecond*300, func() Node {
+			return Node{ID: 1, TCP: "127.0.0.1:6453"}
+		})
+	}()
+
+	if nodes := next(t, ch); len(nodes) != 1 || nodes[0].TCP != "127.0.0.1:6453" {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+
+	// 续约后租约不会过期
+	time.Sleep(time.Millisecond * 500)
+	if nodes, _ := r.Discover(ctx); len(nodes) != 1 {
+		t.Fatalf("lease is not renewed: %+v", nodes)
+	}
+
+	keepCancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if nodes := next(t, ch); len(nodes) != 0 {
+		t.Fatalf("node is not deregistered: %+v", nodes)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodes.yml")
+	content := "- ID: 1\n  GRPC: 10.0.0.1:6455\n- ID: 2\n  GRPC: 10.0.0.2:6455\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, _ := r.Discover(context.Background())
+	if len(nodes) != 2 || nodes[1].GRPC != "10.0.0.2:6455" {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+}
+
+func TestRedis(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skip("redis is not available:", err)
+	}
+
+	now := time.Now()
+	r := NewRedis(client, "socket:test:registry:")
+	r.now = func() time.Time { return now }
+	nodes, leases := r.makeKey()
+	defer client.Del(ctx, nodes, leases)
+
+	if err := r.Register(ctx, Node{ID: 1, GRPC: "127.0.0.1:6455"}, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Register(ctx, Node{ID: 2}, time.Second*5); err != nil {
+		t.Fatal(err)
+	}
+
+	if nodes, err := r.Discover(ctx); err != nil || len(nodes) != 2 || nodes[0].GRPC != "127.0.0.1:6455" {
+		t.Fatalf("unexpected nodes %+v %v", nodes, err)
+	}
+
+	now = now.Add(time.Second * 2)
+	if nodes, err := r.Discover(ctx); err != nil || len(nodes) != 1 || nodes[0].ID != 2 {
+		t.Fatalf("unexpected nodes %+v %v", nodes, err)
+	}
+
+	if err := r.Deregister(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if nodes, err := r.Discover(ctx); err != nil || len(nodes) != 0 {
+		t.Fatalf("unexpected nodes %+v %v", nodes, err)
+	}
+}
+
+// next 等待 Watch 发送下一个节点列表
+func next(t *testing.T, ch <-chan []Node) []Node {
+	select {
+	case nodes := <-ch:
+		return nodes
+	case <-time.After(time.Second * 2):
+		t.Fatal("nodes are not changed")
+		return nil
+	}
+}
+
+func TestGossip(t *testing.T) {
+	ctx := context.Background()
+	opts := []Option{WithProbe(time.Millisecond * 50), WithSuspect(time.Millisecond * 200)}
+
+	var (
+		members []*Gossip
+		seeds   []string
+	)
+
+	for i := 1; i <= 3; i++ {
+		g, err := NewGossip("127.0.0.1:0", gossipKey, seeds, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer g.Close()
+
+		if err := g.Register(ctx, Node{ID: int64(i), GRPC: g.Addr()}, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		members = append(members, g)
+		seeds = []string{members[0].Addr()}
+	}
+
+	for _, g := range members {
+		converge(t, g, 1, 2, 3)
+	}
+
+	// 节点信息的变化会传播给其他成员
+	members[1].Register(ctx, Node{ID: 2, GRPC: members[1].Addr(), Load: 7}, 0)
+	deadline := time.Now().Add(time.Second * 2)
+	for {
+		nodes, _ := members[0].Discover(ctx)
+		if len(nodes) == 3 && nodes[1].Load == 7 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("node is not updated: %+v", nodes)
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// 节点宕机后被其他成员探测到故障
+	members[2].Close()
+	converge(t, members[0], 1, 2)
+	converge(t, members[1], 1, 2)
+
+	// 节点注销后立即通知其他成员
+	if err := members[1].Deregister(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	converge(t, members[0], 1)
+	if nodes, _ := members[1].Discover(ctx); len(nodes) != 1 || nodes[0].ID != 1 {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+}
+
+func TestGossipForged(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewGossip("127.0.0.1:0", []byte("short"), nil); !errors.Is(err, ErrGossipKey) {
+		t.Fatalf("expected ErrGossipKey, got %v", err)
+	}
+
+	g, err := NewGossip("127.0.0.1:0", gossipKey, nil, WithProbe(time.Millisecond*50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	if err := g.Register(ctx, Node{ID: 1, GRPC: g.Addr()}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有签名和使用其他密钥签名的报文都不会合并其中的成员
+	forged := packet{Type: packetSync, Members: []member{{Node: Node{ID: 99, GRPC: "10.0.0.99:6455"}, Addr: "10.0.0.99:6456"}}}
+	body, _ := sonic.Marshal(forged)
+	other, err := NewGossip("127.0.0.1:0", []byte("fedcba9876543210"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	other.send(g.Addr(), body)
+	other.send(g.Addr(), other.sign(body))
+	time.Sleep(time.Millisecond * 200)
+	converge(t, g, 1)
+
+	// 使用共享密钥签名的报文被接受
+	peer, err := NewGossip("127.0.0.1:0", gossipKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+
+	peer.send(g.Addr(), peer.sign(body))
+	converge(t, g, 1, 99)
+}
+
+// converge 等待成员发现的节点 ID 与期望的一致
+func converge(t *testing.T, g *Gossip, ids ...int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second * 3)
+	for {
+		nodes, _ := g.Discover(context.Background())
+		got := make([]int64, 0, len(nodes))
+		for _, node := range nodes {
+			got = append(got, node.ID)
+		}
+
+		if reflect.DeepEqual(got, ids) {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected nodes %v, got %v", ids, got)
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestAllocateSnowflake(t *testing.T) {
+	ctx := context.Background()
+	r := NewStatic([]Node{{ID: 10, Snowflake: 1}, {ID: 11, Snowflake: 3}, {ID: 12}})
+
+	if id, _, err := AllocateSnowflake(ctx, r, 20, 3, 0); err != nil || id != 2 {
+		t.Fatalf("unexpected id %d %v", id, err)
+	}
+
+	// 本节点之前分配的 ID 被复用
+	if id, _, err := AllocateSnowflake(ctx, r, 11, 3, 0); err != nil || id != 3 {
+		t.Fatalf("unexpected id %d %v", id, err)
+	}
+
+	r.Register(ctx, Node{ID: 20, Snowflake: 2}, 0)
+	if _, _, err := AllocateSnowflake(ctx, r, 21, 3, 0); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("expected ErrExhausted, got %v", err)
+	}
+
+	// 还没有注册的节点同时分配时，占用的 ID 不会被重复分配
+	r = NewStatic(nil)
+	if id, _, err := AllocateSnowflake(ctx, r, 30, 3, 0); err != nil || id != 1 {
+		t.Fatalf("unexpected id %d %v", id, err)
+	}
+
+	if id, _, err := AllocateSnowflake(ctx, r, 31, 3, 0); err != nil || id != 2 {
+		t.Fatalf("unexpected id %d %v", id, err)
+	}
+
+	// 节点 ID 未知时使用随机的占用者，注册时使用该占用者续约，其他节点不能占用
+	id, owner, err := AllocateSnowflake(ctx, r, 0, 3, 0)
+	if err != nil || id != 3 || owner <= 0 {
+		t.Fatalf("unexpected id %d %d %v", id, owner, err)
+	}
+
+	if err := r.Register(ctx, Node{ID: 40, Snowflake: id, Owner: owner}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Register(ctx, Node{ID: 41, Snowflake: id}, 0); !errors.Is(err, ErrClaimed) {
+		t.Fatalf("expected ErrClaimed, got %v", err)
+	}
+}
+
+func TestRedisSnowflake(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skip("redis is not available:", err)
+	}
+
+	r := NewRedis(client, "socket:test:snowflake:")
+	nodes, leases := r.makeKey()
+	defer client.Del(ctx, nodes, leases, r.prefix+"snowflake:1", r.prefix+"snowflake:2")
+
+	// 节点 ID 未知时使用随机的占用者，注册时使用返回的占用者续约
+	id, owner, err := AllocateSnowflake(ctx, r, 0, 3, time.Second)
+	if err != nil || id != 1 || owner <= 0 {
+		t.Fatalf("unexpected id %d %d %v", id, owner, err)
+	}
+
+	if err := r.Register(ctx, Node{ID: 1, Snowflake: id, Owner: owner}, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同时启动的节点不会分配到已被占用的 ID
+	if other, _, err := AllocateSnowflake(ctx, r, 0, 3, time.Second); err != nil || other != 2 {
+		t.Fatalf("unexpected id %d %v", other, err)
+	}
+
+	// 其他节点占用后续约失败时返回错误
+	if err := r.Register(ctx, Node{ID: 2, Snowflake: id}, time.Second); !errors.Is(err, ErrClaimed) {
+		t.Fatalf("expected ErrClaimed, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cotton-go/socket/pkg/metrics"
+)
+
+// Static 结构体，在内存中保存节点的注册中心，用于测试和不依赖 Redis 的固定部署。
+// 创建时传入的节点不会过期，通过 Register 注册的节点在租约过期后不再被发现。
+type Static struct {
+	options
+	lock   sync.RWMutex
+	nodes  map[int64]Node // 节点 ID 到节点的映射
+	claims map[int64]Node // 雪花算法节点 ID 到占用该 ID 的节点的映射，Expire 为占用的过期时间
+}
+
+// NewStatic 创建一个在内存中保存节点的注册中心
+//
+// 参数：
+//   - nodes []Node 固定的节点列表，这些节点不会过期
+//   - opts ...Option 注册中心选项
+//
+// 返回值：
+//   - *Static 注册中心
+func NewStatic(nodes []Node, opts ...Option) *Static {
+	s := &Static{options: newOptions(opts...), nodes: make(map[int64]Node, len(nodes)), claims: make(map[int64]Node)}
+	for _, node := range nodes {
+		node.Expire = time.Time{}
+		s.nodes[node.ID] = node
+	}
+
+	return s
+}
+
+// LoadFile 从 YAML 文件中加载固定的节点列表，文件内容为节点数组
+//
+// 参数：
+//   - path string 文件路径
+//   - opts ...Option 注册中心选项
+//
+// 返回值：
+//   - *Static 注册中心
+//   - error 读取或解析文件失败时返回错误信息
+func LoadFile(path string, opts ...Option) (*Static, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []Node
+	if err := yaml.Unmarshal(b, &nodes); err != nil {
+		return nil, err
+	}
+
+	return NewStatic(nodes, opts...), nil
+}
+
+// Register 注册节点或续约
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - node Node 节点
+//   - ttl time.Duration 租约有效期，小于等于 0 时使用 DefaultTTL
+//
+// 返回值：
+//   - error 返回错误信息，雪花算法节点 ID 已被其他节点占用时返回 ErrClaimed
+func (s *Static) Register(ctx context.Context, node Node, ttl time.Duration) error {
+	defer metrics.ObserveRegistry("static", "register", time.Now())
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	node.Expire = s.now().Add(ttl)
+	s.nodes[node.ID] = node
+	if node.Snowflake > 0 && !s.claim(node.Snowflake, claimOwner(node), ttl) {
+		return ErrClaimed
+	}
+
+	return nil
+}
+
+// Claim 为节点 owner 占用雪花算法节点 ID
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - snowflake int64 雪花算法节点 ID
+//   - owner int64 节点 ID
+//   - ttl time.Duration 租约有效期，小于等于 0 时使用 DefaultTTL
+//
+// 返回值：
+//   - bool ID 已被其他节点占用时返回 false
+//   - error 返回错误信息
+func (s *Static) Claim(ctx context.Context, snowflake, owner int64, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.claim(snowflake, owner, ttl), nil
+}
+
+// claim 在持有锁时占用雪花算法节点 ID,ID 没有被占用、占用已过期或已被 owner 占用时返回 true
+func (s *Static) claim(snowflake, owner int64, ttl time.Duration) bool {
+	now := s.now()
+	if c, ok := s.claims[snowflake]; ok && c.ID != owner && now.Before(c.Expire) {
+		return false
+	}
+
+	s.claims[snowflake] = Node{ID: owner, Expire: now.Add(ttl)}
+	return true
+}
+
+// Deregister 注销节点
+//
+// 参数：
+//   - ctx context.Context 上下文
+//   - id int64 节点 ID
+//
+// 返回值：
+//   - error 节点不存在时返回 ErrNotFound
+func (s *Static) Deregister(ctx context.Context, id int64) error {
+	defer metrics.ObserveRegistry("static", "deregister", time.Now())
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	node, ok := s.nodes[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	delete(s.nodes, id)
+	owner := claimOwner(node)
+	for snowflake, c := range s.claims {
+		if c.ID == owner {
+			delete(s.claims, snowflake)
+		}
+	}
+
+	return nil
+}
+
+// Discover 返回所有租约未过期的节点，按 ID 排序
+func (s *Static) Discover(ctx context.Context) ([]Node, error) {
+	defer metrics.ObserveRegistry("static", "discover", time.Now())
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	now := s.now()
+	nodes := make([]Node, 0, len(s.nodes))
+	for _, node := range s.nodes {
+		if node.Expire.IsZero() || now.Before(node.Expire) {
+			nodes = append(nodes, node)
+		}
+	}
+
+	sortNodes(nodes)
+	return nodes, nil
+}
+
+// Watch 返回节点列表的通道，每隔检查间隔比较一次节点列表
+func (s *Static) Watch(ctx context.Context) (<-chan []Node, error) {
+	return watch(ctx, s.interval, s.Discover)
+}
@@ -1,4 +1,7 @@
 package grpc
 
 type Config struct {
+	Host   string `yaml:"Host"`
+	Port   int    `yaml:"Port"`
+	Secret string `yaml:"Secret"` // 节点服务的共享密钥，所有节点需要相同；监听非回环地址时必须配置
 }
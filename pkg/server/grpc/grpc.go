@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync/atomic"
 	"time"
 
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 
 	"github.com/cotton-go/socket/pkg/log"
@@ -13,10 +15,12 @@ import (
 
 // Server 结构体表示一个 gRPC 服务器
 type Server struct {
-	*grpc.Server             // gRPC 服务器实例
-	host         string      // 服务器主机名
-	port         int         // 服务器端口号
-	logger       *log.Logger // 日志记录器实例
+	*grpc.Server                     // gRPC 服务器实例
+	host         string              // 服务器主机名
+	port         int                 // 服务器端口号
+	logger       *log.Logger         // 日志记录器实例
+	ready        int32               // 是否已经开始监听，开始停止后为 0
+	options      []grpc.ServerOption // 创建 gRPC 服务器实例的选项
 }
 
 // NewServer 创建一个新的 Server 实例。
@@ -30,7 +34,6 @@ type Server struct {
 func NewServer(logger *log.Logger, opts ...Option) *Server {
 	// 创建一个新的 Server 实例。
 	s := &Server{
-		Server: grpc.NewServer(),
 		logger: logger,
 	}
 
@@ -39,10 +42,12 @@ func NewServer(logger *log.Logger, opts ...Option) *Server {
 		opt(s)
 	}
 
+	// 所有选项生效后再创建 gRPC 服务器实例，服务器选项只能在创建时设置。
+	s.Server = grpc.NewServer(s.options...)
 	return s
 }
 
-// Start 是 Server 的启动方法。它会启动服务器并监听指定的主机和端口。如果启动失败，将记录错误信息并终止程序。
+// Start 是 Server 的启动方法。它会启动服务器并监听指定的主机和端口。如果启动失败，将记录错误信息并返回错误。
 //
 // 参数：
 // - ctx context.Context 一个上下文对象，用于控制服务器的生命周期。在调用此方法时传入该对象。
@@ -53,15 +58,19 @@ func (s *Server) Start(ctx context.Context) error {
 	// 在指定的主机和端口上监听连接请求。
 	li, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.host, s.port))
 	if err != nil {
-		// 如果监听失败，记录错误信息并终止程序。
-		s.logger.Sugar().Fatalf("Failed to listen: %v", err)
+		// 如果监听失败，记录错误信息并返回。
+		s.logger.Error("Failed to listen", zap.Error(err))
+		return err
 	}
 	defer li.Close() // 确保在函数结束时关闭监听器。
 
-	// 将监听器传递给 gRPC 服务器以便接受连接请求并处理请求。如果处理失败，记录错误信息并终止程序。
+	atomic.StoreInt32(&s.ready, 1)
+	defer atomic.StoreInt32(&s.ready, 0)
+
+	// 将监听器传递给 gRPC 服务器以便接受连接请求并处理请求。如果处理失败，记录错误信息并返回。
 	if err = s.Server.Serve(li); err != nil {
-		// 如果处理失败，记录错误信息并终止程序。
-		s.logger.Sugar().Fatalf("Failed to serve: %v", err)
+		s.logger.Error("Failed to serve", zap.Error(err))
+		return err
 	}
 	return nil // 如果一切正常，返回 nil。
 }
@@ -76,7 +85,8 @@ func (s *Server) Stop(ctx context.Context) error {
 	// 使用 WithTimeout 函数设置超时时间为5秒
 	_, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	// 调用 GracefulStop 方法来优雅地停止服务器
+	// 先标记为未就绪，再调用 GracefulStop 方法来优雅地停止服务器
+	atomic.StoreInt32(&s.ready, 0)
 	s.Server.GracefulStop()
 
 	// 记录日志信息
@@ -84,3 +94,8 @@ func (s *Server) Stop(ctx context.Context) error {
 	// 返回 nil 表示没有错误发生
 	return nil
 }
+
+// Ready 返回服务器是否已经开始监听，开始停止后返回 false
+func (s *Server) Ready() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
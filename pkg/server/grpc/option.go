@@ -1,5 +1,7 @@
 package grpc
 
+import "google.golang.org/grpc"
+
 // Option 类型是一个函数，接收一个 *Server 类型的指针参数。
 type Option func(*Server)
 
@@ -30,3 +32,17 @@ func WithServerPort(port int) Option {
 		s.port = port
 	}
 }
+
+// WithServerOptions 为 gRPC 服务器实例添加选项，例如拦截器和 TLS 凭证。
+//
+// 参数：
+// - opts ...grpc.ServerOption 要添加的 gRPC 服务器选项。
+//
+// 返回值：
+// - Option 一个 Option 类型的函数，用于接收一个 *Server 并对其进行配置。
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(s *Server) {
+		// 保存选项，在创建 gRPC 服务器实例时使用。
+		s.options = append(s.options, opts...)
+	}
+}
@@ -1,6 +1,26 @@
 package http
 
+import "time"
+
 type Config struct {
-	Host string `yaml:"Host"`
-	Port int    `yaml:"Port"`
+	Host      string           `yaml:"Host"`
+	Port      int              `yaml:"Port"`
+	Tokens    []TokenConfig    `yaml:"Tokens"`    // 管理接口的 API 令牌，没有配置时拒绝所有请求
+	Insecure  bool             `yaml:"Insecure"`  // 没有配置令牌时允许所有请求，仅用于本地开发
+	Transport *TransportConfig `yaml:"Transport"` // HTTP 传输，无法使用 TCP 的客户端通过 SSE 或长轮询收发事件
+	Pprof     bool             `yaml:"Pprof"`     // 是否注册 /debug/pprof/ 性能分析的路由
+}
+
+// TransportConfig 表示 HTTP 传输的配置
+type TransportConfig struct {
+	Path        string        `yaml:"Path"`        // 挂载路径，为空时为 /transport
+	IdleTimeout time.Duration `yaml:"IdleTimeout"` // 会话的空闲时间，为 0 时为 1 分钟
+	PollTimeout time.Duration `yaml:"PollTimeout"` // 长轮询最长等待的时间，为 0 时为 25 秒
+	MaxSessions int           `yaml:"MaxSessions"` // 最多同时存在的会话数，为 0 时为 10000
+}
+
+// TokenConfig 表示管理接口的 API 令牌
+type TokenConfig struct {
+	Token string `yaml:"Token"` // 令牌，请求时放在 Authorization: Bearer <token> 请求头中
+	Scope string `yaml:"Scope"` // 权限范围:read 只能查询、write 可以发送消息和踢出连接
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -14,11 +16,13 @@ import (
 
 // Server 结构体表示一个 HTTP 服务器
 type Server struct {
-	handler http.Handler // HTTP 请求处理函数
-	httpSrv *http.Server // HTTP 服务器实例
-	host    string       // 服务器主机名
-	port    int          // 服务器端口号
-	logger  *log.Logger  // 日志记录器实例
+	handler   http.Handler // HTTP 请求处理函数
+	httpSrv   *http.Server // HTTP 服务器实例
+	host      string       // 服务器主机名
+	port      int          // 服务器端口号
+	logger    *log.Logger  // 日志记录器实例
+	transport *Transport   // HTTP 传输，服务器关闭前关闭其所有会话
+	ready     int32        // 是否已经开始监听，开始停止后为 0
 }
 
 // Option 类型表示对 Server 结构体的选项设置函数，参数为指向 Server 的指针。
@@ -66,6 +70,18 @@ func WithServerPort(port int) Option {
 	}
 }
 
+// WithServerTransport 设置服务器挂载的 HTTP 传输，服务器关闭前先关闭传输的会话，
+// 避免 SSE 和长轮询请求阻塞服务器关闭
+// 参数：
+// - transport *Transport HTTP 传输
+// 返回值：
+// - Option 一个函数，接受一个 *Server 参数并设置其 HTTP 传输
+func WithServerTransport(transport *Transport) Option {
+	return func(s *Server) {
+		s.transport = transport
+	}
+}
+
 // Start 启动服务器的函数
 // 参数：
 // - ctx context.Context 上下文
@@ -78,11 +94,22 @@ func (s *Server) Start(ctx context.Context) error {
 		Handler: s.handler,
 	}
 
+	// 开始监听，监听失败时返回错误
+	listener, err := net.Listen("tcp", s.httpSrv.Addr)
+	if err != nil {
+		s.logger.Error("Failed to listen", zap.Error(err))
+		return err
+	}
+
+	atomic.StoreInt32(&s.ready, 1)
+	defer atomic.StoreInt32(&s.ready, 0)
+
 	s.logger.Info("HTTP Server started listener", zap.String("host", s.host), zap.Int("port", s.port))
-	// 开始监听并处理请求
-	if err := s.httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		// 如果出现错误且不是服务器关闭错误，则记录错误并退出程序
-		s.logger.Sugar().Fatalf("listen: %s\n", err)
+	// 处理请求
+	if err := s.httpSrv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		// 如果出现错误且不是服务器关闭错误，则记录错误并返回
+		s.logger.Error("Failed to serve", zap.Error(err))
+		return err
 	}
 
 	// 返回 nil 表示没有错误
@@ -97,6 +124,12 @@ func (s *Server) Start(ctx context.Context) error {
 func (s *Server) Stop(ctx context.Context) error {
 	// 记录日志，表示正在关闭服务器
 	s.logger.Sugar().Info("Shutting down server...")
+	atomic.StoreInt32(&s.ready, 0)
+
+	// 关闭 HTTP 传输的会话，SSE 和长轮询请求会立即返回
+	if s.transport != nil {
+		s.transport.Close()
+	}
 
 	// 使用上下文和超时时间创建一个新的上下文
 	// 这个新的上下文会告诉服务器它有5秒钟的时间来完成当前正在处理的请求
@@ -115,3 +148,8 @@ func (s *Server) Stop(ctx context.Context) error {
 	// 返回nil表示没有错误
 	return nil
 }
+
+// Ready 返回服务器是否已经开始监听，开始停止后返回 false
+func (s *Server) Ready() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
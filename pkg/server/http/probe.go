@@ -0,0 +1,61 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+)
+
+// Probe 接口定义了健康检查和就绪检查的方法，app.App 实现了该接口
+type Probe interface {
+	// Healthy 返回 nil 表示进程健康，返回错误时存活检查失败
+	Healthy() error
+
+	// Ready 返回 nil 表示可以接受新的客户端，返回错误时就绪检查失败
+	Ready() error
+}
+
+// NewProbeHandler 返回处理健康检查、就绪检查和性能分析请求的 HTTP 处理函数
+//
+// 路由：
+//   - GET /healthz 存活检查，Healthy 返回错误时返回 503
+//   - GET /readyz 就绪检查，Ready 返回错误时返回 503
+//   - /debug/pprof/ 性能分析，enablePprof 为 true 时注册
+//
+// 参数：
+//   - probe Probe 检查的对象
+//   - enablePprof bool 是否注册性能分析的路由
+//
+// 返回值：
+//   - http.Handler HTTP 处理函数
+func NewProbeHandler(probe Probe, enablePprof bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", check(probe.Healthy))
+	mux.HandleFunc("/readyz", check(probe.Ready))
+	if enablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	return mux
+}
+
+// check 返回调用检查函数的 HTTP 处理函数，检查通过时返回 200,否则返回 503 和失败的原因
+func check(fn func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{"status": "ok"}
+		status := http.StatusOK
+		if err := fn(); err != nil {
+			body = map[string]string{"status": "unavailable", "error": err.Error()}
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+}
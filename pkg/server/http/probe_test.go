@@ -0,0 +1,45 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// probe 结构体模拟健康检查的对象
+type probe struct {
+	healthy error
+	ready   error
+}
+
+func (p probe) Healthy() error { return p.healthy }
+func (p probe) Ready() error   { return p.ready }
+
+func TestProbeHandler(t *testing.T) {
+	get := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	h := NewProbeHandler(probe{ready: errors.New("app is draining")}, false)
+	if rec := get(h, "/healthz"); rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+
+	rec := get(h, "/readyz")
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "app is draining") {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := get(h, "/debug/pprof/"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+
+	h = NewProbeHandler(probe{}, true)
+	if rec := get(h, "/debug/pprof/"); rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+}
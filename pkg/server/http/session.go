@@ -0,0 +1,181 @@
+package http
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// addr 结构体实现了 net.Addr,表示 HTTP 会话的地址
+type addr string
+
+// Network 返回网络名称
+func (a addr) Network() string {
+	return "http"
+}
+
+// String 返回地址
+func (a addr) String() string {
+	return string(a)
+}
+
+// session 结构体将一个 HTTP 会话模拟为 net.Conn,交给 Worker 创建与 TCP 客户端相同的连接。
+// 客户端通过 POST 发送的字节流作为连接的读取端，连接写入的字节流由 SSE 或长轮询取走。
+type session struct {
+	id        string
+	local     net.Addr
+	remote    net.Addr
+	upstream  chan []byte   // 客户端发送的数据
+	pending   []byte        // 上一次读取剩余的数据
+	frames    chan []byte   // 等待客户端取走的数据
+	closed    chan struct{} // 会话关闭时关闭
+	once      sync.Once
+	lock      sync.Mutex // 保护 deadline 和 wdeadline
+	deadline  time.Time  // 读取的截止时间
+	wdeadline time.Time  // 写入的截止时间
+	attached  int32      // 正在取走数据的请求数，大于 0 时会话不会因空闲被关闭
+	seen      int64      // 客户端最后一次请求的时间，Unix 纳秒
+}
+
+// newSession 创建一个 HTTP 会话
+func newSession(id string, local, remote string, buffer int) *session {
+	return &session{
+		id:       id,
+		local:    addr(local),
+		remote:   addr(remote),
+		upstream: make(chan []byte, buffer),
+		frames:   make(chan []byte, buffer),
+		closed:   make(chan struct{}),
+		seen:     time.Now().UnixNano(),
+	}
+}
+
+// Read 读取客户端发送的数据，没有数据时阻塞到截止时间或会话关闭
+func (s *session) Read(p []byte) (int, error) {
+	if len(s.pending) == 0 {
+		s.lock.Lock()
+		deadline := s.deadline
+		s.lock.Unlock()
+		timeout, stop := after(deadline)
+		defer stop()
+
+		select {
+		case b := <-s.upstream:
+			s.pending = b
+		case <-s.closed:
+			return 0, io.EOF
+		case <-timeout:
+			return 0, os.ErrDeadlineExceeded
+		}
+	}
+
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// Write 将连接写入的数据放入队列，队列已满时阻塞到客户端取走数据、截止时间或会话关闭
+func (s *session) Write(p []byte) (int, error) {
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	s.lock.Lock()
+	deadline := s.wdeadline
+	s.lock.Unlock()
+	timeout, stop := after(deadline)
+	defer stop()
+
+	select {
+	case s.frames <- b:
+		return len(p), nil
+	case <-s.closed:
+		return 0, net.ErrClosed
+	case <-timeout:
+		return 0, os.ErrDeadlineExceeded
+	}
+}
+
+// after 返回截止时间到达时接收到数据的通道和停止计时器的函数，截止时间为零值时通道永远不会接收到数据
+func after(deadline time.Time) (<-chan time.Time, func()) {
+	if deadline.IsZero() {
+		return nil, func() {}
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	return timer.C, func() { timer.Stop() }
+}
+
+// push 将客户端发送的数据交给连接读取
+func (s *session) push(b []byte) error {
+	select {
+	case s.upstream <- b:
+		return nil
+	case <-s.closed:
+		return net.ErrClosed
+	}
+}
+
+// Close 关闭会话，阻塞中的读写和取走数据的请求会立即返回
+func (s *session) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+// LocalAddr 返回本地地址
+func (s *session) LocalAddr() net.Addr {
+	return s.local
+}
+
+// RemoteAddr 返回客户端地址
+func (s *session) RemoteAddr() net.Addr {
+	return s.remote
+}
+
+// SetDeadline 设置读取和写入的截止时间
+func (s *session) SetDeadline(t time.Time) error {
+	s.SetReadDeadline(t)
+	return s.SetWriteDeadline(t)
+}
+
+// SetReadDeadline 设置读取的截止时间
+func (s *session) SetReadDeadline(t time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.deadline = t
+	return nil
+}
+
+// SetWriteDeadline 设置写入的截止时间，客户端没有及时取走数据导致队列已满时，写入在截止时间后返回错误
+func (s *session) SetWriteDeadline(t time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.wdeadline = t
+	return nil
+}
+
+// touch 记录客户端的请求时间
+func (s *session) touch() {
+	atomic.StoreInt64(&s.seen, time.Now().UnixNano())
+}
+
+// attach 标记有请求正在取走数据，返回的函数在请求结束时调用
+func (s *session) attach() func() {
+	atomic.AddInt32(&s.attached, 1)
+	s.touch()
+	return func() {
+		atomic.AddInt32(&s.attached, -1)
+		s.touch()
+	}
+}
+
+// idle 返回会话是否已经空闲超过 timeout
+func (s *session) idle(timeout time.Duration) bool {
+	if atomic.LoadInt32(&s.attached) > 0 {
+		return false
+	}
+
+	return time.Since(time.Unix(0, atomic.LoadInt64(&s.seen))) > timeout
+}
@@ -0,0 +1,373 @@
+package http
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cotton-go/socket/pkg/worker"
+)
+
+const (
+	// maxUpstream 是客户端一次 POST 发送的最大字节数
+	maxUpstream = 1 << 20
+
+	// keepAlive 是 SSE 没有数据时发送注释行的间隔，避免代理断开空闲连接
+	keepAlive = time.Second * 15
+
+	// sessionHeader 是携带会话 ID 的请求头
+	sessionHeader = "X-Session"
+
+	// sessionCookie 是携带会话 ID 的 Cookie 名称，浏览器的 EventSource 无法设置请求头时使用
+	sessionCookie = "socket_session"
+)
+
+// TransportOption 是一个函数类型，用于配置 HTTP 传输
+type TransportOption func(*Transport)
+
+// WithIdleTimeout 设置会话的空闲时间，超过该时间没有 SSE 或长轮询请求的会话会被关闭，连接随之离线
+//
+// 参数：
+//   - value time.Duration 空闲时间，默认为 1 分钟
+//
+// 返回值：
+//   - TransportOption HTTP 传输选项
+func WithIdleTimeout(value time.Duration) TransportOption {
+	return func(t *Transport) {
+		if value > 0 {
+			t.idle = value
+		}
+	}
+}
+
+// WithPollTimeout 设置长轮询没有数据时最长等待的时间
+//
+// 参数：
+//   - value time.Duration 等待时间，默认为 25 秒
+//
+// 返回值：
+//   - TransportOption HTTP 传输选项
+func WithPollTimeout(value time.Duration) TransportOption {
+	return func(t *Transport) {
+		if value > 0 {
+			t.poll = value
+		}
+	}
+}
+
+// WithMaxSessions 设置最多同时存在的会话数，达到上限后创建会话返回 503
+//
+// 参数：
+//   - value int 最大会话数，默认为 10000
+//
+// 返回值：
+//   - TransportOption HTTP 传输选项
+func WithMaxSessions(value int) TransportOption {
+	return func(t *Transport) {
+		if value > 0 {
+			t.max = value
+		}
+	}
+}
+
+// Transport 结构体，在无法使用 TCP 或 WebSocket 的网络中通过普通的 HTTP 请求收发事件。
+// 每个会话对应 Worker 中的一个连接，与 TCP 客户端使用相同的连接 ID、编解码器、处理函数和在线状态。
+//
+// 路由(相对于挂载路径):
+//   - POST /connect 创建会话，返回 {"session": "<会话 ID>"},同时设置会话 Cookie
+//   - GET /events 以 SSE 接收连接写入的字节流，非 UTF-8 的数据以 base64 事件发送
+//   - GET /poll 长轮询接收连接写入的字节流，没有数据时返回 204
+//   - POST /send 请求体为客户端发送的字节流，格式与 TCP 客户端相同
+//   - POST /close 关闭会话
+//
+// 会话 ID 只能放在 X-Session 请求头或 Cookie 中，不接受查询参数，避免写入访问日志。
+type Transport struct {
+	work     *worker.Worker
+	lock     sync.Mutex
+	sessions map[string]*session
+	idle     time.Duration // 会话的空闲时间
+	poll     time.Duration // 长轮询最长等待的时间
+	max      int           // 最大会话数
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewTransport 创建一个 HTTP 传输，并开始定期关闭空闲的会话
+//
+// 参数：
+//   - work *worker.Worker 创建连接的 Worker
+//   - opts ...TransportOption HTTP 传输选项
+//
+// 返回值：
+//   - *Transport HTTP 传输
+func NewTransport(work *worker.Worker, opts ...TransportOption) *Transport {
+	t := &Transport{
+		work:     work,
+		sessions: make(map[string]*session),
+		idle:     time.Minute,
+		poll:     time.Second * 25,
+		max:      10000,
+		done:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	go t.reap()
+	return t
+}
+
+// ServeHTTP 根据路径的最后一段分发请求
+func (t *Transport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	switch {
+	case action == "connect" && r.Method == http.MethodPost:
+		t.connect(w, r)
+	case action == "events" && r.Method == http.MethodGet:
+		t.events(w, r)
+	case action == "poll" && r.Method == http.MethodGet:
+		t.longPoll(w, r)
+	case action == "send" && r.Method == http.MethodPost:
+		t.send(w, r)
+	case action == "close" && r.Method == http.MethodPost:
+		if s := t.session(w, r); s != nil {
+			s.Close()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Close 关闭所有会话，停止关闭空闲会话。HTTP 服务器关闭前调用，使 SSE 和长轮询请求立即返回
+//
+// 返回值：
+//   - error 总是返回 nil
+func (t *Transport) Close() error {
+	t.once.Do(func() { close(t.done) })
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, s := range t.sessions {
+		s.Close()
+	}
+
+	return nil
+}
+
+// Count 返回会话数
+func (t *Transport) Count() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.sessions)
+}
+
+// connect 创建会话，并交给 Worker 创建连接
+func (t *Transport) connect(w http.ResponseWriter, r *http.Request) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	select {
+	case <-t.done:
+		http.Error(w, "transport closed", http.StatusServiceUnavailable)
+		return
+	default:
+	}
+
+	s := newSession(hex.EncodeToString(b), r.Host, r.RemoteAddr, 64)
+	t.lock.Lock()
+	if len(t.sessions) >= t.max {
+		t.lock.Unlock()
+		http.Error(w, "too many sessions", http.StatusServiceUnavailable)
+		return
+	}
+
+	t.sessions[s.id] = s
+	t.lock.Unlock()
+
+	// 连接关闭时会关闭会话，会话关闭后从会话表中移除
+	go func() {
+		<-s.closed
+		t.lock.Lock()
+		delete(t.sessions, s.id)
+		t.lock.Unlock()
+	}()
+
+	t.work.Connection(s)
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: s.id, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"session":%q}`, s.id)
+}
+
+// session 根据 X-Session 请求头或 Cookie 查找请求中的会话，找不到时返回 404
+func (t *Transport) session(w http.ResponseWriter, r *http.Request) *session {
+	id := r.Header.Get(sessionHeader)
+	if cookie, err := r.Cookie(sessionCookie); id == "" && err == nil {
+		id = cookie.Value
+	}
+
+	t.lock.Lock()
+	s, ok := t.sessions[id]
+	t.lock.Unlock()
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return nil
+	}
+
+	s.touch()
+	return s
+}
+
+// events 以 SSE 推送连接写入的数据，直到会话关闭或客户端断开
+func (t *Transport) events(w http.ResponseWriter, r *http.Request) {
+	s := t.session(w, r)
+	if s == nil {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	defer s.attach()()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case b := <-s.frames:
+			writeEvent(w, b)
+			flusher.Flush()
+		case <-ticker.C:
+			io.WriteString(w, ": ping\n\n")
+			flusher.Flush()
+		case <-s.closed:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent 将一段数据写为 SSE 消息，每行一个 data 字段，客户端按 SSE 规则拼接后与原数据相同。
+// SSE 解析器会把 CR 当作换行并且不能传递 NUL,不是合法 UTF-8 或包含这些字节的数据(例如 protobuf、msgpack 的二进制帧)使用 Base64 编码
+func writeEvent(w io.Writer, b []byte) {
+	if !utf8.Valid(b) || bytes.ContainsAny(b, "\r\x00") {
+		fmt.Fprintf(w, "event: base64\ndata: %s\n\n", base64.StdEncoding.EncodeToString(b))
+		return
+	}
+
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+
+	io.WriteString(w, "\n")
+}
+
+// longPoll 等待连接写入的数据，返回所有已经写入的数据，超时时返回 204
+func (t *Transport) longPoll(w http.ResponseWriter, r *http.Request) {
+	s := t.session(w, r)
+	if s == nil {
+		return
+	}
+
+	defer s.attach()()
+	timer := time.NewTimer(t.poll)
+	defer timer.Stop()
+
+	var buf bytes.Buffer
+	select {
+	case b := <-s.frames:
+		buf.Write(b)
+	case <-timer.C:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case <-s.closed:
+		http.Error(w, "session closed", http.StatusGone)
+		return
+	case <-r.Context().Done():
+		return
+	}
+
+	// 一次取走队列中的所有数据，减少请求次数
+drain:
+	for {
+		select {
+		case b := <-s.frames:
+			buf.Write(b)
+		default:
+			break drain
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(buf.Bytes())
+}
+
+// send 将请求体交给连接读取
+func (t *Transport) send(w http.ResponseWriter, r *http.Request) {
+	s := t.session(w, r)
+	if s == nil {
+		return
+	}
+
+	b, err := io.ReadAll(io.LimitReader(r.Body, maxUpstream+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(b) > maxUpstream {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if len(b) > 0 {
+		if err := s.push(b); err != nil {
+			http.Error(w, "session closed", http.StatusGone)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reap 定期关闭空闲的会话
+func (t *Transport) reap() {
+	ticker := time.NewTicker(t.idle / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			t.lock.Lock()
+			for _, s := range t.sessions {
+				if s.idle(t.idle) {
+					s.Close()
+				}
+			}
+			t.lock.Unlock()
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/worker"
+)
+
+// pollConn 结构体通过长轮询和 POST 实现 net.Conn,模拟使用 HTTP 传输的客户端
+type pollConn struct {
+	base    string
+	session string
+	pending []byte
+	closed  chan struct{}
+}
+
+// dial 创建会话
+func dial(t *testing.T, base string) *pollConn {
+	t.Helper()
+
+	resp, err := http.Post(base+"/connect", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+	var body struct {
+		Session string `json:"session"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Session == "" {
+		t.Fatalf("unexpected session %+v %v", body, err)
+	}
+
+	return &pollConn{base: base, session: body.Session, closed: make(chan struct{})}
+}
+
+func (c *pollConn) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		select {
+		case <-c.closed:
+			return 0, io.EOF
+		default:
+		}
+
+		req, err := http.NewRequest(http.MethodGet, c.base+"/poll", nil)
+		if err != nil {
+			return 0, err
+		}
+
+		req.Header.Set(sessionHeader, c.session)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
+
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return 0, err
+		}
+
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+			return 0, io.EOF
+		}
+
+		c.pending = b
+	}
+
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *pollConn) Write(p []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, c.base+"/send", bytes.NewReader(p))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set(sessionHeader, c.session)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return 0, net.ErrClosed
+	}
+
+	return len(p), nil
+}
+
+func (c *pollConn) Close() error {
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+	}
+
+	return nil
+}
+
+func (c *pollConn) LocalAddr() net.Addr                { return addr("client") }
+func (c *pollConn) RemoteAddr() net.Addr               { return addr(c.base) }
+func (c *pollConn) SetDeadline(t time.Time) error      { return nil }
+func (c *pollConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *pollConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// waitFor 等待条件成立
+func waitFor(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second * 2)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// receive 等待收到指定主题的事件
+func receive(t *testing.T, received chan event.Event, topic string) event.Event {
+	t.Helper()
+
+	timeout := time.After(time.Second * 2)
+	for {
+		select {
+		case e := <-received:
+			if e.Topic == topic {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("event %q not received", topic)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handled := make(chan event.Event, 10)
+	work := worker.NewWorker(
+		worker.WithContext(ctx),
+		worker.WithHandle(func(c connection.Peer, e event.Event) {
+			handled <- e
+		}),
+	)
+
+	time.Sleep(time.Millisecond * 100)
+	transport := NewTransport(work, WithIdleTimeout(time.Millisecond*300), WithPollTimeout(time.Millisecond*100))
+	defer transport.Close()
+
+	srv := httptest.NewServer(http.StripPrefix("/transport", transport))
+	defer srv.Close()
+	base := srv.URL + "/transport"
+
+	t.Run("poll", func(t *testing.T) {
+		received := make(chan event.Event, 10)
+		conn := dial(t, base)
+		defer conn.Close()
+
+		client := connection.NewConnection(
+			connection.WithConn(conn),
+			connection.WithClient(true),
+			connection.WithContext(ctx),
+			connection.WithHandle(func(c connection.Peer, e event.Event) {
+				received <- e
+			}),
+		)
+
+		waitFor(t, "connection is not online", func() bool {
+			return client.ID() != 0 && work.Local(client.ID()) != nil
+		})
+
+		if err := client.Send("hello", "world"); err != nil {
+			t.Fatal(err)
+		}
+
+		if e := receive(t, handled, "hello"); e.Data != "world" {
+			t.Fatalf("unexpected event %+v", e)
+		}
+
+		if err := work.Send(client.ID(), "news", "hi"); err != nil {
+			t.Fatal(err)
+		}
+
+		if e := receive(t, received, "news"); e.Data != "hi" {
+			t.Fatalf("unexpected event %+v", e)
+		}
+
+		// 客户端持续长轮询，会话不会因空闲被关闭
+		time.Sleep(time.Millisecond * 500)
+		if work.Local(client.ID()) == nil {
+			t.Fatal("active session closed")
+		}
+	})
+
+	t.Run("events", func(t *testing.T) {
+		conn := dial(t, base)
+
+		// 会话 ID 不接受查询参数，避免写入访问日志
+		resp, err := http.Get(base + "/poll?session=" + conn.session)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("unexpected status %d", resp.StatusCode)
+		}
+
+		// 浏览器的 EventSource 通过 Cookie 携带会话 ID
+		req, _ := http.NewRequest(http.MethodGet, base+"/events", nil)
+		req.AddCookie(&http.Cookie{Name: sessionCookie, Value: conn.session})
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer resp.Body.Close()
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("unexpected content type %q", ct)
+		}
+
+		// 连接创建后会写入初始化事件
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil || (!strings.HasPrefix(line, "data: ") && !strings.HasPrefix(line, "event: base64")) {
+			t.Fatalf("unexpected line %q %v", line, err)
+		}
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		dial(t, base)
+		if transport.Count() == 0 {
+			t.Fatal("session not created")
+		}
+
+		// 之前的客户端都已停止请求，所有会话因空闲被关闭，连接随之离线
+		waitFor(t, "idle session is not closed", func() bool {
+			return transport.Count() == 0 && len(work.Connections()) == 0
+		})
+
+		req, _ := http.NewRequest(http.MethodGet, base+"/poll", nil)
+		req.Header.Set(sessionHeader, "unknown")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("unexpected status %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("max", func(t *testing.T) {
+		limited := NewTransport(work, WithMaxSessions(1))
+		defer limited.Close()
+
+		srv := httptest.NewServer(limited)
+		defer srv.Close()
+
+		dial(t, srv.URL)
+		resp, err := http.Post(srv.URL+"/connect", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("unexpected status %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestSessionWriteDeadline(t *testing.T) {
+	s := newSession("id", "local", "remote", 1)
+	if _, err := s.Write([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 客户端没有取走数据，队列已满时写入在截止时间后返回
+	s.SetWriteDeadline(time.Now().Add(time.Millisecond * 50))
+	if _, err := s.Write([]byte("b")); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected ErrDeadlineExceeded, got %v", err)
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	var buf bytes.Buffer
+	writeEvent(&buf, []byte("a\nb"))
+	if buf.String() != "data: a\ndata: b\n\n" {
+		t.Fatalf("unexpected event %q", buf.String())
+	}
+
+	buf.Reset()
+	writeEvent(&buf, []byte{0xff, 0x00})
+	if buf.String() != "event: base64\ndata: /wA=\n\n" {
+		t.Fatalf("unexpected event %q", buf.String())
+	}
+
+	// 合法 UTF-8 的二进制帧中包含 CR 时也使用 Base64,例如长度为 13 的 varint
+	buf.Reset()
+	writeEvent(&buf, []byte{0x0d, 'a'})
+	if buf.String() != "event: base64\ndata: DWE=\n\n" {
+		t.Fatalf("unexpected event %q", buf.String())
+	}
+}
@@ -11,4 +11,7 @@ type Server interface {
 
 	// Stop 方法用于停止服务器，接收一个 context.Context 类型的参数，返回值为 error 类型
 	Stop(context.Context) error
+
+	// Ready 方法返回服务器是否已经开始监听并可以接受新的请求，启动前、启动失败和开始停止后返回 false
+	Ready() bool
 }
@@ -1,11 +1,47 @@
 package tcp
 
+import (
+	"time"
+
+	"github.com/cotton-go/socket/pkg/codec"
+	"github.com/cotton-go/socket/pkg/handshake"
+)
+
 type Config struct {
-	Codec  string       `yaml:"Codec"`
-	Secret string       `yaml:"Secret"`
-	Host   string       `yaml:"Host"`
-	Port   int          `yaml:"Port"`
-	Redis  *RedisConfig `yaml:"Redis"`
+	Codec      string            `yaml:"Codec"`
+	Secret     string            `yaml:"Secret"`
+	KeyID      string            `yaml:"KeyID"`      // 用于加密的活动密钥 ID,配置 Keys 时生效
+	Keys       map[string]string `yaml:"Keys"`       // 密钥环，键为密钥 ID,配置后忽略 Secret
+	KeyFile    string            `yaml:"KeyFile"`    // 密钥文件路径，配置后忽略 Keys,文件变化时自动重新加载
+	Codecs     []codec.Config    `yaml:"Codecs"`     // 编解码管道，按编码顺序排列，配置后忽略 Codec
+	Serializer string            `yaml:"Serializer"` // 事件在连接上的序列化格式:json(默认)、msgpack、protobuf
+	Binary     bool              `yaml:"Binary"`     // 不经过握手的连接也使用字节数组格式，所有客户端都支持时启用
+	Host       string            `yaml:"Host"`
+	Port       int               `yaml:"Port"`
+	Redis      *RedisConfig      `yaml:"Redis"`
+	Cluster    bool              `yaml:"Cluster"` // 集群模式，使用 Redis 保存在线连接并在节点之间转发消息
+	WorkID     int64             `yaml:"WorkID"`  // 节点的工作 ID,为 0 时随机生成，配置 Nodes 时必须与其中的键一致
+	Nodes      map[int64]string  `yaml:"Nodes"`   // 集群节点的 gRPC 地址，键为工作 ID,配置后使用节点服务代替 Redis 发布订阅转发消息
+	Handshake  *HandshakeConfig  `yaml:"Handshake"`
+	Registry   *RegistryConfig   `yaml:"Registry"`  // 注册中心，启用后本节点会注册地址和负载并定期续约
+	Snowflake  *SnowflakeConfig  `yaml:"Snowflake"` // 生成连接 ID 的雪花算法，集群中的每个节点需要使用不同的节点 ID
+	Identity   *IdentityConfig   `yaml:"Identity"`  // 连接 ID 的生成方式和客户端身份
+}
+
+// IdentityConfig 表示连接 ID 的配置
+type IdentityConfig struct {
+	Generator string `yaml:"Generator"` // 连接 ID 生成器:snowflake(默认)、uuidv7、identity
+	Required  bool   `yaml:"Required"`  // 连接建立后等待客户端发送身份凭证，Generator 为 identity 时总是启用，需要通过 socket.WithAuthenticator 提供身份校验函数
+	Conflict  string `yaml:"Conflict"`  // 连接 ID 已经在线时的处理方式:reject(默认)拒绝新连接、replace 关闭旧连接
+}
+
+// SnowflakeConfig 表示雪花算法的配置
+type SnowflakeConfig struct {
+	Node         int64         `yaml:"Node"`         // 节点 ID,为 0 时从注册中心分配，没有注册中心时使用 0;配置了注册中心时即使没有该配置也会分配
+	Epoch        time.Time     `yaml:"Epoch"`        // 时间戳的起始时间，为空时为 2021-01-01
+	NodeBits     uint8         `yaml:"NodeBits"`     // 节点 ID 的位数，与 SequenceBits 都为 0 时为 5
+	SequenceBits uint8         `yaml:"SequenceBits"` // 序列号的位数，与 NodeBits 都为 0 时为 12
+	Tolerance    time.Duration `yaml:"Tolerance"`    // 容忍的时钟回拨时间，为 0 时为 10 毫秒
 }
 
 type RedisConfig struct {
@@ -15,3 +51,43 @@ type RedisConfig struct {
 	DB         int    `yaml:"DB"`
 	MaxRetries int    `yaml:"MaxRetries"`
 }
+
+// RegistryConfig 表示注册中心的配置
+type RegistryConfig struct {
+	File   string        `yaml:"File"`   // 固定的节点列表文件，为空时使用 Gossip 或 Redis 保存节点
+	Gossip string        `yaml:"Gossip"` // Gossip 监听的 UDP 地址，配置后节点之间通过 Gossip 发现彼此，不依赖 Redis
+	Seeds  []string      `yaml:"Seeds"`  // Gossip 种子节点的 UDP 地址
+	Key    string        `yaml:"Key"`    // Gossip 报文签名的共享密钥，所有节点相同，至少 16 字节
+	TTL    time.Duration `yaml:"TTL"`    // 租约有效期，为 0 时为 10 秒
+	TCP    string        `yaml:"TCP"`    // 本节点对外的 TCP 地址
+	HTTP   string        `yaml:"HTTP"`   // 本节点对外的 HTTP 地址
+	GRPC   string        `yaml:"GRPC"`   // 本节点对外的 gRPC 地址，配置后其他节点通过注册中心找到本节点的节点服务
+}
+
+// HandshakeConfig 表示 X25519 密钥交换的配置，启用后每个连接使用独立的会话密钥
+type HandshakeConfig struct {
+	PrivateKey string `yaml:"PrivateKey"` // 服务端静态私钥(Base64),为空时随机生成
+	PublicKey  string `yaml:"PublicKey"`  // 客户端固定的服务端静态公钥(Base64),为空时不校验服务端身份
+
+	MinVersion  int            `yaml:"MinVersion"`  // 服务端允许的最低协议版本
+	MaxFrame    int            `yaml:"MaxFrame"`    // 最大帧大小(字节),0 表示不限制
+	Codecs      []codec.Config `yaml:"Codecs"`      // 可协商的编解码器，按优先级从高到低排列，SESSION 表示使用会话密钥
+	Compression []codec.Config `yaml:"Compression"` // 可协商的压缩编解码器，按优先级从高到低排列
+}
+
+// Options 根据配置创建握手时可协商能力的选项
+//
+// 参数：
+//   - ring *codec.Keyring 密钥环，未配置密钥的编解码器使用该密钥环，可以为 nil
+//
+// 返回值：
+//   - []handshake.Option 握手选项
+//   - error 如果任意一个编解码器创建失败，则返回错误信息
+func (c HandshakeConfig) Options(ring *codec.Keyring) ([]handshake.Option, error) {
+	opts, err := handshake.NewOptions(c.Codecs, c.Compression, ring)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, handshake.WithMinVersion(c.MinVersion), handshake.WithMaxFrame(c.MaxFrame)), nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 
@@ -25,6 +26,7 @@ type Server struct {
 	startAfter  func(context.Context) // 在启动后执行的回调函数
 	stopBefore  func(context.Context) // 在停止前执行的回调函数
 	stopAfter   func(context.Context) // 在停止后执行的回调函数
+	ready       int32                 // 是否已经开始监听，开始停止后为 0
 }
 
 // NewServer 创建一个新的服务器实例
@@ -76,10 +78,15 @@ func (s *Server) Start(ctx context.Context) error {
 	// 监听指定的地址和端口
 	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.host, s.port))
 	if err != nil {
-		s.logger.Sugar().Fatalf("Failed to listen: %v", err)
+		s.logger.Error("Failed to listen", zap.Error(err))
 		return err
 	}
 
+	// 停止时关闭监听器，不再接受新的连接
+	s.Server = listener
+	atomic.StoreInt32(&s.ready, 1)
+	defer atomic.StoreInt32(&s.ready, 0)
+
 	// 如果 startAfter 不为空，则在启动之后执行该函数
 	if s.startAfter != nil {
 		s.startAfter(ctx)
@@ -101,6 +108,10 @@ func (s *Server) Start(ctx context.Context) error {
 			// 以非阻塞方式接受新的连接
 			conn, err := listener.Accept()
 			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					continue
+				}
+
 				s.logger.Error("Error accepting connection:", zap.Error(err))
 				continue
 			}
@@ -133,8 +144,12 @@ func (s *Server) Stop(ctx context.Context) error {
 		s.stopBefore(ctx)
 	}
 
-	// 取消服务器的所有 goroutine
+	// 先标记为未就绪，再取消服务器的所有 goroutine 并关闭监听器
+	atomic.StoreInt32(&s.ready, 0)
 	s.cancel()
+	if s.Server != nil {
+		s.Server.Close()
+	}
 
 	// fmt.Println("count", s.worker.Count())
 
@@ -144,3 +159,8 @@ func (s *Server) Stop(ctx context.Context) error {
 	// 返回 nil 表示没有错误发生
 	return nil
 }
+
+// Ready 返回服务器是否已经开始监听，开始停止后返回 false
+func (s *Server) Ready() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
@@ -20,15 +20,19 @@ func TestNewTCP(t *testing.T) {
 	prot := 8080
 	host := "127.0.0.1"
 
-	icodec := codec.New("AESECB", "1234567890123456")
+	icodec, err := codec.New("AESECB", "1234567890123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	work := worker.NewWorker(
 		worker.WithContext(ctx),
 		worker.WithCodec(icodec),
-		worker.WithHandle(func(c *connection.Connection, e event.Event) {
+		worker.WithHandle(func(c connection.Peer, e event.Event) {
 			fmt.Println("on handle", "topic", e.Topic, "value", e.Data)
 			if e.Topic == event.TopicByClose {
-				fmt.Println("收到断开连接请求", c.ID)
+				fmt.Println("收到断开连接请求", c.ID())
 				time.Sleep(time.Second * 5)
 				cancel()
 				return
@@ -36,21 +40,21 @@ func TestNewTCP(t *testing.T) {
 
 			if e.Topic == event.TopicByLogin {
 				auth = true
-				fmt.Println("收到登陆认证请求", c.ID)
+				fmt.Println("收到登陆认证请求", c.ID())
 				c.Send("logind", e.Data)
 				return
 			}
 
 			//
 			if !auth {
-				fmt.Println("未收到登陆认证请求，立即退出", c.ID)
+				fmt.Println("未收到登陆认证请求，立即退出", c.ID())
 				c.Close()
 				return
 			}
 
 			// fmt.Println("on handle", "topic", e.Topic, "value", e.Data)
-			c.On("msg", func(c *connection.Connection, e event.Event) {
-				fmt.Println("on msg", e.Data, "conn", c.ID)
+			c.(*connection.Connection).On("msg", func(c connection.Peer, e event.Event) {
+				fmt.Println("on msg", e.Data, "conn", c.ID())
 				c.Send("rev", e.Data)
 			})
 		}),
@@ -87,14 +91,14 @@ func TestNewTCP(t *testing.T) {
 	t.Run("client", func(t *testing.T) {
 		wg.Wait()
 
-		handle := connection.WithHandle(func(c *connection.Connection, e event.Event) {
+		handle := connection.WithHandle(func(c connection.Peer, e event.Event) {
 			fmt.Println("on handle 1", "topic", e.Topic, "value", e.Data)
 			if e.Topic == "logind" {
-				fmt.Println("登陆成功", c.ID)
+				fmt.Println("登陆成功", c.ID())
 				return
 			}
 
-			c.On("rev", func(c *connection.Connection, e event.Event) {
+			c.(*connection.Connection).On("rev", func(c connection.Peer, e event.Event) {
 				fmt.Println("on rev", e.Data)
 				fmt.Println("count", work.Count())
 				fmt.Println()
@@ -1,7 +1,20 @@
 package snowflake
 
-// 声明一个全局的 Snowflake 指针变量 workerID
-var workerID *Snowflake
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cotton-go/socket/pkg/metrics"
+)
+
+var (
+	// lock 保护包级别的 Snowflake 实例
+	lock sync.RWMutex
+
+	// 声明一个全局的 Snowflake 指针变量 workerID
+	workerID *Snowflake
+)
 
 // init 初始化函数
 func init() {
@@ -9,15 +22,71 @@ func init() {
 	workerID, _ = NewSnowflake(0)
 }
 
-// Next 生成一个新的 ID
+// SetDefault 替换包级别的 Snowflake 实例，集群中的每个节点需要使用不同的工作节点 ID
+//
+// 参数：
+//   - s *Snowflake Snowflake 实例，为 nil 时不做处理
+func SetDefault(s *Snowflake) {
+	if s == nil {
+		return
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	workerID = s
+}
+
+// Default 返回包级别的 Snowflake 实例
+func Default() *Snowflake {
+	lock.RLock()
+	defer lock.RUnlock()
+	return workerID
+}
+
+// Next 使用包级别的 Snowflake 实例生成一个新的 ID
 //
 // 参数：无
 //
 // 返回值：
 //   - int64 返回新生成的 ID
 func Next() int64 {
-	// 调用 workerID 的 Generate 方法生成一个新的 ID,并将其赋值给 id
-	id, _ := workerID.Generate()
-	// 返回新生成的 ID
-	return id
+	return Default().Next()
+}
+
+// Next 生成一个新的 ID,时钟回拨超过容忍范围时休眠到上次生成 ID 的时间后再生成，每次回拨只记录一次日志和指标
+//
+// 参数：
+//   - s *Snowflake 雪花算法实例
+//
+// 返回值：
+//   - int64 返回新生成的 ID
+func (s *Snowflake) Next() int64 {
+	reported := false
+	for {
+		// 调用 Generate 方法生成一个新的 ID
+		id, err := s.Generate()
+		if err == nil {
+			// 返回新生成的 ID
+			return id
+		}
+
+		if !reported {
+			reported = true
+			metrics.ClockBackwards.Inc()
+			log.Printf("Snowflake generate err: %v, waiting for the clock to catch up", err)
+		}
+
+		time.Sleep(s.behind())
+	}
+}
+
+// Parse 使用包级别的 Snowflake 实例的位布局解析 ID
+//
+// 参数：
+//   - id int64 由包级别的 Snowflake 实例生成的 ID
+//
+// 返回值：
+//   - ID 解析后的时间、节点 ID 和序列号
+func Parse(id int64) ID {
+	return Default().Parse(id)
 }
@@ -1,45 +1,150 @@
 package snowflake
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 )
 
+var (
+	// ErrClockBackwards 表示时钟回拨超过了容忍范围
+	ErrClockBackwards = errors.New("clock moved backwards")
+
+	// ErrInvalidLayout 表示位布局无效
+	ErrInvalidLayout = errors.New("invalid layout")
+)
+
+const (
+	// DefaultTolerance 是默认容忍的时钟回拨时间
+	DefaultTolerance = time.Millisecond * 10
+
+	// maxNodeSequenceBits 是节点 ID 和序列号的最大总位数，保证时间戳至少有 41 位
+	maxNodeSequenceBits = 22
+)
+
+// Layout 结构体表示 ID 的位布局，从高位到低位依次为时间戳、节点 ID 和序列号
+type Layout struct {
+	Epoch        time.Time // 时间戳的起始时间
+	NodeBits     uint8     // 节点 ID 的位数
+	SequenceBits uint8     // 序列号的位数
+}
+
+// DefaultLayout 是默认的位布局，支持 32 个节点，每个节点每毫秒生成 4096 个 ID
+var DefaultLayout = Layout{
+	Epoch:        time.UnixMilli(1609459200000), // 2021-01-01 00:00:00 UTC
+	NodeBits:     5,
+	SequenceBits: 12,
+}
+
+// MaxNode 返回位布局支持的最大节点 ID
+func (l Layout) MaxNode() int64 {
+	return -1 ^ (-1 << l.NodeBits)
+}
+
+// maxSequence 返回位布局支持的最大序列号
+func (l Layout) maxSequence() int64 {
+	return -1 ^ (-1 << l.SequenceBits)
+}
+
+// validate 校验位布局
+func (l Layout) validate() error {
+	if l.SequenceBits == 0 || int(l.NodeBits)+int(l.SequenceBits) > maxNodeSequenceBits {
+		return fmt.Errorf("%w: node bits and sequence bits must be at most %d in total, sequence bits must be positive", ErrInvalidLayout, maxNodeSequenceBits)
+	}
+
+	return nil
+}
+
+// ID 结构体表示解析后的 ID
+type ID struct {
+	Time     time.Time `json:"time"`     // 生成 ID 的时间，精确到毫秒
+	Node     int64     `json:"node"`     // 生成 ID 的节点
+	Sequence int64     `json:"sequence"` // 同一毫秒内的序列号
+}
+
+// Option 是一个函数类型，用于配置 Snowflake 实例
+type Option func(*Snowflake)
+
+// WithLayout 设置 ID 的位布局
+//
+// 参数：
+//   - value Layout 位布局，同一个集群中的所有节点需要使用相同的位布局
+//
+// 返回值：
+//   - Option Snowflake 选项
+func WithLayout(value Layout) Option {
+	return func(s *Snowflake) {
+		s.layout = value
+	}
+}
+
+// WithTolerance 设置容忍的时钟回拨时间，回拨在容忍范围内时继续使用上次的时间戳生成 ID
+//
+// 参数：
+//   - value time.Duration 容忍的时钟回拨时间，为 0 时不容忍时钟回拨
+//
+// 返回值：
+//   - Option Snowflake 选项
+func WithTolerance(value time.Duration) Option {
+	return func(s *Snowflake) {
+		s.tolerance = value
+	}
+}
+
 // Snowflake 结构体
 type Snowflake struct {
 	mu            sync.Mutex
 	lastTimestamp int64
 	workerID      int64
 	sequence      int64
+	layout        Layout           // 位布局
+	tolerance     time.Duration    // 容忍的时钟回拨时间
+	now           func() time.Time // 当前时间，便于测试
 }
 
-const (
-	epoch        = 1609459200000 // 2021-01-01 00:00:00 UTC
-	workerIDBits = 5
-	sequenceBits = 12
-	maxWorkerID  = -1 ^ (-1 << workerIDBits)
-	maxSequence  = -1 ^ (-1 << sequenceBits)
-)
-
 // NewSnowflake 创建一个新的 Snowflake 实例
 //
 // 参数：
 //   - workerID int64 工作节点的 ID
+//   - opts ...Option Snowflake 选项，默认使用 DefaultLayout 和 DefaultTolerance
 //
 // 返回值：
 //   - *Snowflake Snowflake 实例
-//   - error 返回错误信息，如果输入的工作节点 ID 在有效范围内
-func NewSnowflake(workerID int64) (*Snowflake, error) {
-	if workerID < 0 || workerID > maxWorkerID {
-		return nil, fmt.Errorf("Worker ID must be between 0 and %d", maxWorkerID)
-	}
-
-	return &Snowflake{
+//   - error 返回错误信息，如果位布局无效或输入的工作节点 ID 不在有效范围内
+func NewSnowflake(workerID int64, opts ...Option) (*Snowflake, error) {
+	s := &Snowflake{
 		lastTimestamp: 0,
 		workerID:      workerID,
 		sequence:      0,
-	}, nil
+		layout:        DefaultLayout,
+		tolerance:     DefaultTolerance,
+		now:           time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := s.layout.validate(); err != nil {
+		return nil, err
+	}
+
+	if workerID < 0 || workerID > s.layout.MaxNode() {
+		return nil, fmt.Errorf("Worker ID must be between 0 and %d", s.layout.MaxNode())
+	}
+
+	return s, nil
+}
+
+// Node 返回工作节点的 ID
+func (s *Snowflake) Node() int64 {
+	return s.workerID
+}
+
+// Layout 返回 ID 的位布局
+func (s *Snowflake) Layout() Layout {
+	return s.layout
 }
 
 // Generate 生成ID
@@ -49,28 +154,35 @@ func NewSnowflake(workerID int64) (*Snowflake, error) {
 //
 // 返回值：
 //   - int64 ID 生成的ID
-//   - error 错误信息，如果没有错误则返回nil
+//   - error 错误信息，时钟回拨超过容忍范围时返回 ErrClockBackwards,如果没有错误则返回nil
 func (s *Snowflake) Generate() (int64, error) {
 	// 加锁以保证线程安全
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// 获取当前时间戳(毫秒级)
-	currentTimestamp := time.Now().UnixNano() / 1e6
+	currentTimestamp := s.millis()
 
-	// 如果当前时间戳小于上次生成ID的时间戳，说明时钟回拨了，拒绝生成ID
+	// 如果当前时间戳小于上次生成ID的时间戳，说明时钟回拨了
 	if currentTimestamp < s.lastTimestamp {
-		return 0, fmt.Errorf("Clock moved backwards. Refusing to generate ID for %d milliseconds", s.lastTimestamp-currentTimestamp)
+		// 回拨超过容忍范围时拒绝生成ID
+		if backwards := s.lastTimestamp - currentTimestamp; backwards > s.tolerance.Milliseconds() {
+			return 0, fmt.Errorf("%w: refusing to generate ID for %d milliseconds", ErrClockBackwards, backwards)
+		}
+
+		// 在容忍范围内继续使用上次的时间戳，序列号用尽时等待时钟追上
+		currentTimestamp = s.lastTimestamp
 	}
 
 	// 如果当前时间戳等于上次生成ID的时间戳，序列号加1,并进行位运算处理
 	if currentTimestamp == s.lastTimestamp {
-		s.sequence = (s.sequence + 1) & maxSequence
+		s.sequence = (s.sequence + 1) & s.layout.maxSequence()
 
 		// 如果序列号为0,等待到下一毫秒再生成ID
 		if s.sequence == 0 {
 			for currentTimestamp <= s.lastTimestamp {
-				currentTimestamp = time.Now().UnixNano() / 1e6
+				time.Sleep(time.Microsecond * 100)
+				currentTimestamp = s.millis()
 			}
 		}
 	} else {
@@ -82,6 +194,38 @@ func (s *Snowflake) Generate() (int64, error) {
 	s.lastTimestamp = currentTimestamp
 
 	// 根据公式计算ID,并返回ID和错误信息(如果有的话)
-	id := (currentTimestamp-epoch)<<(workerIDBits+sequenceBits) | (s.workerID << sequenceBits) | s.sequence
+	id := (currentTimestamp-s.layout.Epoch.UnixMilli())<<(s.layout.NodeBits+s.layout.SequenceBits) | (s.workerID << s.layout.SequenceBits) | s.sequence
 	return id, nil
 }
+
+// Parse 按照位布局解析 ID
+//
+// 参数：
+//   - id int64 由相同位布局生成的 ID
+//
+// 返回值：
+//   - ID 解析后的时间、节点 ID 和序列号
+func (s *Snowflake) Parse(id int64) ID {
+	return ID{
+		Time:     time.UnixMilli(id>>(s.layout.NodeBits+s.layout.SequenceBits) + s.layout.Epoch.UnixMilli()),
+		Node:     id >> s.layout.SequenceBits & s.layout.MaxNode(),
+		Sequence: id & s.layout.maxSequence(),
+	}
+}
+
+// behind 返回当前时间落后于上次生成 ID 的时间的时长，至少为 1 毫秒
+func (s *Snowflake) behind() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d := time.Duration(s.lastTimestamp-s.millis()) * time.Millisecond; d > time.Millisecond {
+		return d
+	}
+
+	return time.Millisecond
+}
+
+// millis 返回当前的毫秒时间戳
+func (s *Snowflake) millis() int64 {
+	return s.now().UnixMilli()
+}
@@ -0,0 +1,65 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	layout := Layout{Epoch: time.UnixMilli(1700000000000), NodeBits: 10, SequenceBits: 8}
+	s, err := NewSnowflake(1000, WithLayout(layout))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.UnixMilli(1700000012345)
+	s.now = func() time.Time { return now }
+
+	first, _ := s.Generate()
+	second, _ := s.Generate()
+	if id := s.Parse(second); !id.Time.Equal(now) || id.Node != 1000 || id.Sequence != 1 {
+		t.Fatalf("unexpected id %+v", id)
+	}
+
+	if first >= second {
+		t.Fatalf("ids are not increasing: %d %d", first, second)
+	}
+
+	if _, err := NewSnowflake(1024, WithLayout(layout)); err == nil {
+		t.Fatal("expected error for node out of range")
+	}
+
+	if _, err := NewSnowflake(0, WithLayout(Layout{NodeBits: 16, SequenceBits: 16})); !errors.Is(err, ErrInvalidLayout) {
+		t.Fatalf("expected ErrInvalidLayout, got %v", err)
+	}
+}
+
+func TestTolerance(t *testing.T) {
+	s, err := NewSnowflake(1, WithTolerance(time.Millisecond*5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	last, _ := s.Generate()
+
+	// 时钟回拨在容忍范围内，继续使用上次的时间戳
+	now = now.Add(-time.Millisecond * 3)
+	id, err := s.Generate()
+	if err != nil || id <= last {
+		t.Fatalf("unexpected id %d %v", id, err)
+	}
+
+	// 时钟回拨超过容忍范围
+	now = now.Add(-time.Millisecond * 10)
+	if _, err := s.Generate(); !errors.Is(err, ErrClockBackwards) {
+		t.Fatalf("expected ErrClockBackwards, got %v", err)
+	}
+
+	// 等待的时间为落后于上次时间戳的时长
+	if d := s.behind(); d != time.Millisecond*13 {
+		t.Fatalf("unexpected behind %s", d)
+	}
+}
@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/snowflake"
+)
+
+var (
+	// ErrNoIdentity 表示生成连接 ID 需要客户端的身份，但客户端没有发送身份凭证
+	ErrNoIdentity = errors.New("identity required")
+
+	// ErrConflict 表示连接 ID 已经在线，并且冲突策略为 ConflictReject
+	ErrConflict = errors.New("connection id conflict")
+
+	// ErrNoAuthenticator 表示启用了身份凭证但没有设置身份校验函数，客户端声明的身份不能被信任
+	ErrNoAuthenticator = errors.New("authenticator required")
+)
+
+// IDGenerator 接口，定义了生成连接 ID 的方法
+type IDGenerator interface {
+	// Generate 生成连接 ID,identity 为客户端通过校验的身份，没有启用身份校验时为空
+	Generate(identity string) (int64, error)
+}
+
+// IDGeneratorFunc 是一个函数类型，实现了 IDGenerator 接口
+type IDGeneratorFunc func(identity string) (int64, error)
+
+// Generate 调用函数生成连接 ID
+func (f IDGeneratorFunc) Generate(identity string) (int64, error) {
+	return f(identity)
+}
+
+// Authenticator 是一个函数类型，用于校验客户端的身份凭证，返回通过校验的身份
+type Authenticator func(connection.Credentials) (string, error)
+
+// ConflictPolicy 表示连接 ID 已经在本节点或其他节点上在线时的处理方式
+type ConflictPolicy int
+
+const (
+	// ConflictReject 拒绝新连接，是默认的冲突策略
+	ConflictReject ConflictPolicy = iota
+
+	// ConflictReplace 关闭已经在线的连接，新连接使用该 ID。
+	// 只有身份校验函数能够证明客户端拥有该身份时才应使用，否则任何客户端都可以踢出其他用户的连接
+	ConflictReplace
+)
+
+// SnowflakeID 函数返回一个使用雪花算法生成连接 ID 的生成器，忽略客户端的身份。
+//
+// 参数：
+// s *snowflake.Snowflake: 雪花算法实例。如果为 nil,则使用包级别的实例。
+//
+// 返回值：
+// IDGenerator: 连接 ID 生成器。
+func SnowflakeID(s *snowflake.Snowflake) IDGenerator {
+	return IDGeneratorFunc(func(string) (int64, error) {
+		if s == nil {
+			return snowflake.Next(), nil
+		}
+
+		return s.Next(), nil
+	})
+}
+
+// UUIDv7ID 函数返回一个按照 UUIDv7 生成连接 ID 的生成器，忽略客户端的身份。
+// 连接 ID 只有 64 位，生成器取 UUIDv7 随机部分的 62 位作为连接 ID,
+// 不包含节点和时间信息，不需要为节点分配雪花算法节点 ID,但生成的 ID 不保证有序。
+//
+// 返回值：
+// IDGenerator: 连接 ID 生成器。
+func UUIDv7ID() IDGenerator {
+	return IDGeneratorFunc(func(string) (int64, error) {
+		uuid, err := newUUIDv7()
+		if err != nil {
+			return 0, err
+		}
+
+		// 清除变体位和符号位，保证 ID 为正数
+		id := int64(binary.BigEndian.Uint64(uuid[8:]) & (1<<62 - 1))
+		if id == 0 {
+			id = 1
+		}
+
+		return id, nil
+	})
+}
+
+// IdentityID 函数返回一个根据客户端身份生成连接 ID 的生成器，同一个身份总是得到相同的连接 ID。
+// 身份为正整数时直接作为连接 ID,否则使用身份的 FNV-1a 哈希值。
+//
+// 返回值：
+// IDGenerator: 连接 ID 生成器，客户端没有身份时返回 ErrNoIdentity。
+func IdentityID() IDGenerator {
+	return IDGeneratorFunc(func(identity string) (int64, error) {
+		if identity == "" {
+			return 0, ErrNoIdentity
+		}
+
+		if id, err := strconv.ParseInt(identity, 10, 64); err == nil && id > 0 {
+			return id, nil
+		}
+
+		h := fnv.New64a()
+		h.Write([]byte(identity))
+		id := int64(h.Sum64() &^ (1 << 63))
+		if id == 0 {
+			id = 1
+		}
+
+		return id, nil
+	})
+}
+
+// newUUIDv7 生成一个 RFC 9562 定义的 UUIDv7,高 48 位为毫秒时间戳，其余为随机数
+func newUUIDv7() ([16]byte, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[6:]); err != nil {
+		return uuid, err
+	}
+
+	ms := uint64(time.Now().UnixMilli())
+	uuid[0], uuid[1], uuid[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	uuid[3], uuid[4], uuid[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	uuid[6] = uuid[6]&0x0f | 0x70 // 版本 7
+	uuid[8] = uuid[8]&0x3f | 0x80 // 变体 10
+	return uuid, nil
+}
@@ -0,0 +1,143 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cotton-go/socket/pkg/connection"
+)
+
+func TestIdentityID(t *testing.T) {
+	gen := IdentityID()
+	if id, _ := gen.Generate("42"); id != 42 {
+		t.Fatalf("unexpected id %d", id)
+	}
+
+	a, _ := gen.Generate("alice")
+	b, _ := gen.Generate("alice")
+	if a <= 0 || a != b {
+		t.Fatalf("unexpected ids %d %d", a, b)
+	}
+
+	if _, err := gen.Generate(""); !errors.Is(err, ErrNoIdentity) {
+		t.Fatalf("expected ErrNoIdentity, got %v", err)
+	}
+
+	x, _ := UUIDv7ID().Generate("")
+	y, _ := UUIDv7ID().Generate("")
+	if x <= 0 || y <= 0 || x == y {
+		t.Fatalf("unexpected ids %d %d", x, y)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	auth := func(creds connection.Credentials) (string, error) {
+		if creds.Token != "secret" {
+			return "", errors.New("invalid token")
+		}
+
+		return creds.Identity, nil
+	}
+
+	w := NewWorker(WithContext(ctx), WithIDGenerator(IdentityID()), WithIdentity(auth), WithConflict(ConflictReplace))
+	time.Sleep(time.Millisecond * 100)
+
+	dial := func(creds connection.Credentials) (*connection.Connection, *connection.Connection) {
+		server, client := net.Pipe()
+		c := connection.NewConnection(
+			connection.WithConn(client),
+			connection.WithClient(true),
+			connection.WithContext(ctx),
+			connection.WithCredentials(creds),
+		)
+
+		return w.Connection(server), c
+	}
+
+	first, client := dial(connection.Credentials{Identity: "42", Token: "secret"})
+	online(t, w, 42, first)
+
+	// 客户端收到服务端根据身份分配的连接 ID
+	deadline := time.Now().Add(time.Second * 2)
+	for client.ID() != 42 {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected client id %d", client.ID())
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if identity, _ := first.Attributes().Get("identity"); identity != "42" {
+		t.Fatalf("unexpected identity %v", identity)
+	}
+
+	// 相同身份的新连接替换已经在线的连接
+	second, _ := dial(connection.Credentials{Identity: "42", Token: "secret"})
+	online(t, w, 42, second)
+	if err := first.Send("hello", "world"); !errors.Is(err, connection.ErrClosed) {
+		t.Fatalf("expected first connection closed, got %v", err)
+	}
+
+	time.Sleep(time.Millisecond * 100)
+	if count := w.Count(); count != 1 {
+		t.Fatalf("unexpected count %d", count)
+	}
+
+	// 身份校验失败时关闭连接
+	rejected, _ := dial(connection.Credentials{Identity: "7", Token: "wrong"})
+	time.Sleep(time.Millisecond * 100)
+	if w.Local(7) != nil || rejected.Send("hello", "world") == nil {
+		t.Fatal("connection with invalid token is accepted")
+	}
+
+	// 冲突策略为拒绝时保留已经在线的连接
+	WithConflict(ConflictReject)(w)
+	third, _ := dial(connection.Credentials{Identity: "42", Token: "secret"})
+	time.Sleep(time.Millisecond * 100)
+	if w.Local(42) != second || third.Send("hello", "world") == nil {
+		t.Fatal("conflicting connection is accepted")
+	}
+}
+
+func TestIdentityWithoutAuthenticator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := NewWorker(WithContext(ctx), WithIDGenerator(IdentityID()), WithIdentity(nil))
+	time.Sleep(time.Millisecond * 100)
+
+	// 没有身份校验函数时不信任客户端声明的身份
+	server, client := net.Pipe()
+	connection.NewConnection(
+		connection.WithConn(client),
+		connection.WithClient(true),
+		connection.WithContext(ctx),
+		connection.WithCredentials(connection.Credentials{Identity: "42"}),
+	)
+
+	conn := w.Connection(server)
+	time.Sleep(time.Millisecond * 100)
+	if w.Local(42) != nil || conn.Send("hello", "world") == nil {
+		t.Fatal("connection without authenticator is accepted")
+	}
+}
+
+// online 等待连接在工作器中上线
+func online(t *testing.T, w *Worker, id int64, conn *connection.Connection) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second * 2)
+	for w.Local(id) != conn {
+		if time.Now().After(deadline) {
+			t.Fatalf("connection %d is not online", id)
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
@@ -0,0 +1,135 @@
+package worker
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cotton-go/socket/pkg/cache"
+	"github.com/cotton-go/socket/pkg/connection"
+)
+
+// leaseCache 是一个支持节点租约的内存缓存，用于模拟租约过期
+type leaseCache struct {
+	cache.ICache
+	lock   sync.Mutex
+	resets []int64 // 调用 Reset 的节点
+	alive  bool    // Renew 返回的续约前租约是否有效
+}
+
+func (c *leaseCache) Renew(node int64, ttl time.Duration) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	alive := c.alive
+	c.alive = true
+	return alive, nil
+}
+
+func (c *leaseCache) Reset(node int64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.resets = append(c.resets, node)
+	return nil
+}
+
+func TestLease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cachex := &leaseCache{ICache: cache.NewMemory()}
+	work := NewWorker(WithID(7), WithContext(ctx), WithCache(cachex), WithLease(time.Millisecond*90))
+
+	server, client := net.Pipe()
+	connection.NewConnection(connection.WithConn(client), connection.WithClient(true), connection.WithContext(ctx))
+	time.Sleep(time.Millisecond * 50)
+	conn := work.Connection(server)
+
+	deadline := time.Now().Add(time.Second * 2)
+	for work.Local(conn.ID()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not online")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	cachex.lock.Lock()
+	resets := append([]int64{}, cachex.resets...)
+	cachex.lock.Unlock()
+	if len(resets) != 1 || resets[0] != 7 {
+		t.Fatalf("stale presence is not reset on startup: %v", resets)
+	}
+
+	// 租约过期后其他节点清理了本节点的连接，续约时重新上线
+	cachex.Offline(conn)
+	cachex.lock.Lock()
+	cachex.alive = false
+	cachex.lock.Unlock()
+
+	deadline = time.Now().Add(time.Second * 2)
+	for cachex.Find(conn.ID()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not online again")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// slowCache 是一个上线时阻塞的缓存，用于模拟缓存的网络延迟
+type slowCache struct {
+	leaseCache
+	entered chan struct{} // 开始上线时写入
+	release chan struct{} // 关闭后上线完成
+}
+
+func (c *slowCache) Online(conn connection.Peer) error {
+	select {
+	case c.entered <- struct{}{}:
+	default:
+	}
+
+	<-c.release
+	return c.ICache.Online(conn)
+}
+
+func TestLeaseRenewUnlocked(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	conn := connection.NewConnection(connection.WithConn(server), connection.WithID(1), connection.WithContext(ctx))
+	cachex := &slowCache{leaseCache: leaseCache{ICache: cache.NewMemory()}, entered: make(chan struct{}, 1), release: make(chan struct{})}
+	work := &Worker{id: 7, cache: cachex, connections: map[int64]*connection.Connection{1: conn}}
+
+	done := make(chan struct{})
+	go func() {
+		work.renew(cachex)
+		close(done)
+	}()
+
+	<-cachex.entered
+
+	// 重新上线访问缓存期间不持有锁，连接可以正常加入和移除
+	locked := make(chan struct{})
+	go func() {
+		work.lock.Lock()
+		work.lock.Unlock()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("worker lock is held while the cache is accessed")
+	}
+
+	close(cachex.release)
+	<-done
+}
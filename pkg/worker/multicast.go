@@ -0,0 +1,184 @@
+package worker
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cotton-go/socket/pkg/broker"
+	"github.com/cotton-go/socket/pkg/cache"
+)
+
+// Payload 结构体表示批量发送的一个事件
+type Payload struct {
+	Topic string `json:"topic"` // 事件主题
+	Data  any    `json:"data"`  // 事件数据
+}
+
+// DeliveryStatus 表示批量发送时单个目标的发送结果
+type DeliveryStatus string
+
+const (
+	// StatusDelivered 连接在本节点上，事件已写入连接的写缓冲区
+	StatusDelivered DeliveryStatus = "delivered"
+
+	// StatusRouted 连接在其他节点上，事件已通过消息代理转发到所在节点
+	StatusRouted DeliveryStatus = "routed"
+
+	// StatusOffline 连接不在线
+	StatusOffline DeliveryStatus = "offline"
+
+	// StatusFailed 发送或转发失败
+	StatusFailed DeliveryStatus = "failed"
+)
+
+// Delivery 结构体表示批量发送时单个连接的发送结果
+type Delivery struct {
+	ID     int64          `json:"id"`              // 连接 ID
+	Status DeliveryStatus `json:"status"`          // 发送结果
+	Error  string         `json:"error,omitempty"` // 失败的原因
+}
+
+// Multicast 方法用于向多个连接发送一个或多个事件。
+// 缓存实现了 cache.Locator 时一次查询所有连接所在的节点，其他节点上的连接按节点分组，
+// 每个事件对每个节点只转发一次消息，由所在节点发送给其本地的连接。
+//
+// 参数：
+// ids []int64: 连接 ID 列表，重复的 ID 只发送一次。
+// payloads ...Payload: 按顺序发送给每个连接的事件。
+//
+// 返回值：
+// []Delivery: 每个连接的发送结果，顺序与去重后的 ids 一致。
+func (w *Worker) Multicast(ids []int64, payloads ...Payload) []Delivery {
+	results := make([]Delivery, 0, len(ids))
+	index := make(map[int64]int, len(ids))
+	for _, id := range ids {
+		if _, ok := index[id]; ok {
+			continue
+		}
+
+		index[id] = len(results)
+		results = append(results, Delivery{ID: id, Status: StatusOffline})
+	}
+
+	// 先发送给本节点上的连接，剩余的连接再查询缓存
+	var remaining []int64
+	for i := range results {
+		conn := w.Local(results[i].ID)
+		if conn == nil {
+			remaining = append(remaining, results[i].ID)
+			continue
+		}
+
+		results[i].Status = StatusDelivered
+		for _, payload := range payloads {
+			if err := conn.Send(payload.Topic, payload.Data); err != nil {
+				results[i].Status, results[i].Error = StatusFailed, err.Error()
+				break
+			}
+		}
+	}
+
+	groups := make(map[int64][]int64)
+	for id, node := range w.locate(remaining) {
+		// 缓存中记录在本节点但本节点没有该连接，说明缓存已过期
+		if node != w.id {
+			groups[node] = append(groups[node], id)
+		}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+	)
+
+	for node, targets := range groups {
+		wg.Add(1)
+		go func(node int64, targets []int64) {
+			defer wg.Done()
+
+			err := w.publishTargets(node, targets, payloads)
+			lock.Lock()
+			defer lock.Unlock()
+			for _, id := range targets {
+				if err != nil {
+					results[index[id]].Status, results[index[id]].Error = StatusFailed, err.Error()
+					continue
+				}
+
+				results[index[id]].Status = StatusRouted
+			}
+		}(node, targets)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// locate 方法用于查询连接所在的节点，缓存没有实现 cache.Locator 时逐个查询。
+func (w *Worker) locate(ids []int64) map[int64]int64 {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if locator, ok := w.cache.(cache.Locator); ok {
+		nodes, err := locator.Locate(ids)
+		if err == nil {
+			return nodes
+		}
+
+		fmt.Println("multicast locate error", err)
+	}
+
+	nodes := make(map[int64]int64, len(ids))
+	for _, id := range ids {
+		if conn := w.cache.Find(id); conn != nil {
+			nodes[id] = conn.Info().WorkID
+		}
+	}
+
+	return nodes
+}
+
+// publishTargets 方法用于将事件转发到连接所在的节点，每个事件只发布一次消息。
+func (w *Worker) publishTargets(node int64, targets []int64, payloads []Payload) error {
+	if w.broker == nil {
+		return ErrNoBroker
+	}
+
+	for _, payload := range payloads {
+		msg := broker.Message{Targets: targets, Topic: payload.Topic, Data: payload.Data}
+		if err := w.broker.Publish(w.ctx, node, msg); err != nil {
+			return fmt.Errorf("node %d: %w", node, err)
+		}
+	}
+
+	return nil
+}
+
+// MulticastLocal 方法用于向本节点上的多个连接发送事件，忽略不在本节点上的连接。
+//
+// 参数：
+// ids []int64: 连接 ID 列表。
+// topic string: 事件主题。
+// data any: 事件数据。
+//
+// 返回值：
+// int: 发送成功的连接数。
+func (w *Worker) MulticastLocal(ids []int64, topic string, data any) int {
+	count := 0
+	for _, id := range ids {
+		conn := w.Local(id)
+		if conn == nil {
+			continue
+		}
+
+		if err := conn.Send(topic, data); err != nil {
+			fmt.Println("multicast error", err, id)
+			continue
+		}
+
+		count++
+	}
+
+	return count
+}
@@ -2,11 +2,16 @@ package worker
 
 import (
 	"context"
+	"time"
 
+	"github.com/cotton-go/socket/pkg/broker"
 	"github.com/cotton-go/socket/pkg/cache"
 	"github.com/cotton-go/socket/pkg/codec"
 	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/encoding"
 	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/handshake"
+	"github.com/cotton-go/socket/pkg/registry"
 	"github.com/cotton-go/socket/pkg/snowflake"
 )
 
@@ -73,7 +78,7 @@ func WithCodec(value codec.ICodec) Options {
 func WithHandle(value connection.EventHandle) Options {
 	return func(w *Worker) {
 		if value == nil {
-			value = func(c *connection.Connection, e event.Event) {}
+			value = func(p connection.Peer, e event.Event) {}
 		}
 
 		w.handle = value
@@ -97,3 +102,148 @@ func WithContext(value context.Context) Options {
 		w.ctx, w.cancel = context.WithCancel(value)
 	}
 }
+
+// WithHandshake 函数用于设置 Worker 实例的握手配置。
+//
+// 参数：
+// value *handshake.Server: 服务端握手配置。如果为 nil,则连接建立时不进行密钥交换，使用 WithCodec 设置的编解码器。
+//
+// 返回值：
+// Options: 一个闭包函数，接受一个 Worker 实例作为参数，并将其握手配置设置为指定的值。
+func WithHandshake(value *handshake.Server) Options {
+	return func(w *Worker) {
+		w.handshake = value
+	}
+}
+
+// WithSerializer 函数用于设置 Worker 实例创建的连接在连接上序列化事件的格式。
+//
+// 参数：
+// value encoding.Serializer: 序列化器。如果为 nil,则使用 encoding.JSON,客户端需要使用相同的序列化器。
+//
+// 返回值：
+// Options: 一个闭包函数，接受一个 Worker 实例作为参数，并将其序列化器设置为指定的值。
+func WithSerializer(value encoding.Serializer) Options {
+	return func(w *Worker) {
+		w.serializer = value
+	}
+}
+
+// WithBinary 函数用于设置 Worker 实例创建的连接是否使用字节数组格式，见 connection.WithBinary。
+//
+// 参数：
+// value bool: 是否使用字节数组格式。默认为 false,与旧版本客户端兼容；握手协商的协议版本支持时自动启用。
+//
+// 返回值：
+// Options: 一个闭包函数，接受一个 Worker 实例作为参数，并设置其连接是否使用字节数组格式。
+func WithBinary(value bool) Options {
+	return func(w *Worker) {
+		w.binary = value
+	}
+}
+
+// WithSecurity 函数用于设置 Worker 实例的安全事件处理器。
+//
+// 参数：
+// value connection.SecurityHandle: 安全事件处理器，连接上的事件被编解码器拒绝时调用，
+// 可以通过 errors.Is 判断错误是否为 codec.ErrSignature、codec.ErrExpired 或 codec.ErrReplay。
+//
+// 返回值：
+// Options: 一个闭包函数，接受一个 Worker 实例作为参数，并将其安全事件处理器设置为指定的值。
+func WithSecurity(value connection.SecurityHandle) Options {
+	return func(w *Worker) {
+		w.security = value
+	}
+}
+
+// WithBroker 函数用于设置 Worker 实例的消息代理。
+//
+// 参数：
+// value broker.Broker: 消息代理。Worker 会订阅以自身 ID 命名的通道，并通过该代理向其他节点上的连接转发消息，
+// 需要与 cache.Redis 等共享的缓存一起使用。
+//
+// 返回值：
+// Options: 一个闭包函数，接受一个 Worker 实例作为参数，并将其消息代理设置为指定的值。
+func WithBroker(value broker.Broker) Options {
+	return func(w *Worker) {
+		w.broker = value
+	}
+}
+
+// WithLease 函数用于设置 Worker 实例的租约有效期。
+//
+// 参数：
+// value time.Duration: 租约有效期，用于注册中心和支持 cache.Lease 的缓存，每隔 value/3 续约一次。
+// 如果小于等于 0,则使用 registry.DefaultTTL。
+//
+// 返回值：
+// Options: 一个闭包函数，接受一个 Worker 实例作为参数，并将其租约有效期设置为指定的值。
+func WithLease(value time.Duration) Options {
+	return func(w *Worker) {
+		w.lease = value
+	}
+}
+
+// WithRegistry 函数用于设置 Worker 实例的注册中心。
+//
+// 参数：
+// value registry.Registry: 注册中心。Worker 启动后会注册本节点并定期续约，上下文被取消时注销本节点。
+// node registry.Node: 本节点的地址，ID 和负载由 Worker 填写。
+// ttl time.Duration: 租约有效期。如果小于等于 0,则使用 WithLease 设置的值或 registry.DefaultTTL。
+//
+// 返回值：
+// Options: 一个闭包函数，接受一个 Worker 实例作为参数，并将其注册中心设置为指定的值。
+func WithRegistry(value registry.Registry, node registry.Node, ttl time.Duration) Options {
+	return func(w *Worker) {
+		w.registry = value
+		w.node = node
+		if ttl > 0 {
+			w.lease = ttl
+		}
+	}
+}
+
+// WithIDGenerator 函数用于设置 Worker 实例的连接 ID 生成器。
+//
+// 参数：
+// value IDGenerator: 连接 ID 生成器，例如 SnowflakeID、UUIDv7ID 或 IdentityID。如果为 nil,则使用包级别的雪花算法。
+//
+// 返回值：
+// Options: 一个闭包函数，接受一个 Worker 实例作为参数，并将其连接 ID 生成器设置为指定的值。
+func WithIDGenerator(value IDGenerator) Options {
+	return func(w *Worker) {
+		if value == nil {
+			value = SnowflakeID(nil)
+		}
+
+		w.generator = value
+	}
+}
+
+// WithIdentity 函数用于启用客户端身份凭证，连接建立后等待客户端发送 connection.Credentials,
+// 校验通过的身份会传给连接 ID 生成器，并保存在连接属性 "identity" 中。
+//
+// 参数：
+// value Authenticator: 身份校验函数，返回通过校验的身份。如果为 nil,则拒绝所有客户端，不会信任客户端声明的身份。
+//
+// 返回值：
+// Options: 一个闭包函数，接受一个 Worker 实例作为参数，并启用身份凭证。
+func WithIdentity(value Authenticator) Options {
+	return func(w *Worker) {
+		w.identity = true
+		w.auth = value
+	}
+}
+
+// WithConflict 函数用于设置连接 ID 已经在本节点或其他节点上在线时的处理方式。
+//
+// 参数：
+// value ConflictPolicy: 冲突策略，默认为 ConflictReject。
+//
+// 返回值：
+// Options: 一个闭包函数，接受一个 Worker 实例作为参数，并将其冲突策略设置为指定的值。
+func WithConflict(value ConflictPolicy) Options {
+	return func(w *Worker) {
+		w.conflict = value
+	}
+}
@@ -0,0 +1,157 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cotton-go/socket/pkg/broker"
+	"github.com/cotton-go/socket/pkg/cache"
+	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/event"
+)
+
+func TestRemoteSend(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 两个节点共享缓存和消息代理，模拟集群
+	cachex, brokerx := cache.NewMemory(), broker.NewMemory()
+	local := NewWorker(WithContext(ctx), WithCache(cachex), WithBroker(brokerx))
+	remote := NewWorker(WithContext(ctx), WithCache(cachex), WithBroker(brokerx))
+
+	received := make(chan event.Event, 1)
+	server, client := net.Pipe()
+	connection.NewConnection(
+		connection.WithConn(client),
+		connection.WithClient(true),
+		connection.WithContext(ctx),
+		connection.WithHandle(func(_ connection.Peer, e event.Event) {
+			if e.Topic == "hello" {
+				received <- e
+			}
+		}),
+	)
+
+	time.Sleep(time.Millisecond * 100)
+	owner := remote.Connection(server)
+
+	// 等待连接在缓存中上线
+	deadline := time.Now().Add(time.Second * 2)
+	for local.Find(owner.ID()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not online")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	found := local.Find(owner.ID())
+	if info := found.Info(); !info.Remote || info.WorkID != remote.ID() {
+		t.Fatalf("expected remote connection on worker %d, got %+v", remote.ID(), found)
+	}
+
+	if err := local.Send(owner.ID(), "hello", "world"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-received:
+		if e.Data != "world" {
+			t.Fatalf("unexpected data %v", e.Data)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("remote message not delivered")
+	}
+
+	if err := local.Send(1, "hello", "world"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	// 没有网络连接的连接对象发送数据时返回错误，而不是阻塞
+	if err := (&connection.Connection{}).Send("hello", "world"); !errors.Is(err, connection.ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected, got %v", err)
+	}
+}
+
+func TestRemoteRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cachex, brokerx := cache.NewMemory(), broker.NewMemory()
+	local := NewWorker(WithContext(ctx), WithCache(cachex), WithBroker(brokerx))
+	remote := NewWorker(WithContext(ctx), WithCache(cachex), WithBroker(brokerx))
+
+	// 客户端只响应 config 请求，其他请求不响应
+	server, client := net.Pipe()
+	connection.NewConnection(
+		connection.WithConn(client),
+		connection.WithClient(true),
+		connection.WithContext(ctx),
+		connection.WithHandle(func(c connection.Peer, e event.Event) {
+			if e.Topic == "config" && e.Seq != 0 {
+				c.Reply(e, map[string]any{"version": e.Data})
+			}
+		}),
+	)
+
+	time.Sleep(time.Millisecond * 100)
+	owner := remote.Connection(server)
+
+	deadline := time.Now().Add(time.Second * 2)
+	for local.Find(owner.ID()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not online")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// 本节点和其他节点上发起的请求都能收到客户端的响应
+	for _, w := range []*Worker{remote, local} {
+		reqCtx, reqCancel := context.WithTimeout(ctx, time.Second*2)
+		reply, err := w.Request(reqCtx, owner.ID(), "config", "v1")
+		reqCancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if m, ok := reply.(map[string]any); !ok || m["version"] != "v1" {
+			t.Fatalf("unexpected reply %v", reply)
+		}
+	}
+
+	reqCtx, reqCancel := context.WithTimeout(ctx, time.Millisecond*200)
+	defer reqCancel()
+	if _, err := local.Request(reqCtx, owner.ID(), "ignored", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if _, err := local.Request(ctx, 1, "config", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	// 查找到的其他节点上的连接对象可以直接请求和关闭
+	found := local.Find(owner.ID())
+	reqCtx, reqCancel = context.WithTimeout(ctx, time.Second*2)
+	defer reqCancel()
+	e, err := found.Request(reqCtx, "config", "v2")
+	if m, ok := e.Data.(map[string]any); err != nil || !ok || m["version"] != "v2" {
+		t.Fatalf("unexpected reply %v %v", e.Data, err)
+	}
+
+	if err := found.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline = time.Now().Add(time.Second * 2)
+	for remote.Local(owner.ID()) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("remote connection is not closed")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
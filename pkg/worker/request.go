@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cotton-go/socket/pkg/broker"
+	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/metrics"
+)
+
+// defaultRequestTimeout 是请求没有截止时间时，连接所在节点等待客户端响应的时间
+const defaultRequestTimeout = time.Second * 30
+
+// Request 方法用于向连接发送请求事件，并等待客户端通过 Reply 返回的响应。
+// 连接位于其他节点时，消息代理实现了 broker.Requester 则直接调用所在节点，
+// 否则通过消息代理发布请求消息，由所在节点发送请求并将响应发布回本节点。
+//
+// 参数：
+// ctx context.Context: 上下文，用于设置等待响应的超时时间。
+// id int64: 连接的 ID。
+// topic string: 请求事件的主题。
+// data any: 请求事件的数据。
+//
+// 返回值：
+// any: 客户端的响应数据。
+// error: 连接不在线时返回 ErrNotFound,超时时返回 context.DeadlineExceeded,
+// 连接位于其他节点但没有配置消息代理时返回 ErrNoBroker。
+func (w *Worker) Request(ctx context.Context, id int64, topic string, data any) (any, error) {
+	conn := w.Find(id)
+	if conn == nil {
+		return nil, ErrNotFound
+	}
+
+	info := conn.Info()
+	if !info.Remote && info.WorkID == w.id {
+		e, err := conn.Request(ctx, topic, data)
+		if err != nil {
+			return nil, err
+		}
+
+		return e.Data, nil
+	}
+
+	return w.requestTo(ctx, info, topic, data)
+}
+
+// requestTo 方法用于向其他节点上的连接发送请求事件，并等待所在节点返回的响应数据，
+// 也是 connection.Remote 的远程请求函数。
+func (w *Worker) requestTo(ctx context.Context, info connection.Info, topic string, data any) (any, error) {
+	if w.broker == nil {
+		return nil, ErrNoBroker
+	}
+
+	msg := broker.Message{To: info.ID, Topic: topic, Data: data}
+	if requester, ok := w.broker.(broker.Requester); ok {
+		return requester.Request(ctx, info.WorkID, msg)
+	}
+
+	return w.requestRemote(ctx, info.WorkID, msg)
+}
+
+// requestRemote 方法通过消息代理向其他节点发布请求消息，并等待所在节点发布回来的响应消息。
+func (w *Worker) requestRemote(ctx context.Context, node int64, msg broker.Message) (any, error) {
+	ch := make(chan broker.Message, 1)
+	w.rlock.Lock()
+	w.seq++
+	seq := w.seq
+	w.requests[seq] = ch
+	w.rlock.Unlock()
+
+	defer func() {
+		w.rlock.Lock()
+		delete(w.requests, seq)
+		w.rlock.Unlock()
+	}()
+
+	msg.From, msg.Request = w.id, seq
+	if deadline, ok := ctx.Deadline(); ok {
+		msg.Timeout = time.Until(deadline)
+	}
+
+	if err := w.broker.Publish(ctx, node, msg); err != nil {
+		return nil, err
+	}
+
+	select {
+	case reply := <-ch:
+		if reply.Error != "" {
+			return nil, replyError(reply.Error)
+		}
+
+		return reply.Data, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	}
+}
+
+// resolve 方法用于将其他节点发布回来的响应消息交给等待响应的请求，请求已超时或响应重复时丢弃响应。
+func (w *Worker) resolve(msg broker.Message) {
+	w.rlock.Lock()
+	ch, ok := w.requests[msg.Reply]
+	w.rlock.Unlock()
+	if ok {
+		select {
+		case ch <- msg:
+			return
+		default:
+		}
+	}
+
+	fmt.Println("broker reply dropped", msg.Reply)
+	metrics.Dropped.WithLabelValues("reply").Inc()
+}
+
+// serve 方法用于向本节点上的连接发送其他节点发布的请求，并将客户端的响应发布回请求的节点。
+func (w *Worker) serve(msg broker.Message) {
+	timeout := msg.Timeout
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(w.ctx, timeout)
+	defer cancel()
+
+	reply := broker.Message{Reply: msg.Request, Topic: msg.Topic}
+	if conn := w.Local(msg.To); conn == nil {
+		reply.Error = ErrNotFound.Error()
+	} else if e, err := conn.Request(ctx, msg.Topic, msg.Data); err != nil {
+		reply.Error = err.Error()
+	} else {
+		reply.Data = e.Data
+	}
+
+	if err := w.broker.Publish(w.ctx, msg.From, reply); err != nil {
+		fmt.Println("broker reply error", err, msg.From)
+	}
+}
+
+// replyError 将响应消息中的错误信息还原为可以用 errors.Is 判断的错误。
+func replyError(msg string) error {
+	for _, err := range []error{ErrNotFound, context.DeadlineExceeded, connection.ErrClosed} {
+		if msg == err.Error() {
+			return err
+		}
+	}
+
+	return errors.New(msg)
+}
@@ -2,15 +2,36 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
+	"github.com/cotton-go/socket/pkg/broker"
 	"github.com/cotton-go/socket/pkg/cache"
 	"github.com/cotton-go/socket/pkg/codec"
 	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/encoding"
 	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/handshake"
+	"github.com/cotton-go/socket/pkg/metrics"
 	"github.com/cotton-go/socket/pkg/registry"
+	"github.com/cotton-go/socket/pkg/snowflake"
+)
+
+var (
+	// ErrNotFound 表示连接不在线
+	ErrNotFound = errors.New("connection not found")
+
+	// ErrNoBroker 表示连接位于其他节点，但没有配置消息代理
+	ErrNoBroker = errors.New("broker not configured")
+
+	// ErrNoPresence 表示缓存没有实现 cache.Notifier,不支持订阅和查询在线状态
+	ErrNoPresence = errors.New("presence not supported")
+
+	// ErrNoRooms 表示缓存没有实现 cache.Rooms,不支持房间
+	ErrNoRooms = errors.New("rooms not supported")
 )
 
 // Worker代表一个具有其属性和方法的工作对象。
@@ -22,11 +43,25 @@ type Worker struct {
 	cancel      context.CancelFunc               // 用于停止工作对象的取消函数
 	connections map[int64]*connection.Connection // 活动连接的映射表
 	cbuffer     chan *connection.Connection      // 传入连接的缓冲区
-	dbuffer     chan *connection.Connection      // 传出连接的缓冲区
+	dbuffer     chan connection.Peer             // 传出连接的缓冲区
 	cache       cache.ICache                     // 存储数据的缓存接口
 	codec       codec.ICodec                     // 编码和解码数据的编解码器接口
 	handle      connection.EventHandle           // 事件处理器，用于处理事件
 	registry    registry.Registry                // 注册中心处理器，用于注册服务
+	node        registry.Node                    // 注册到注册中心的节点地址
+	lease       time.Duration                    // 注册中心和在线状态租约的有效期
+	handshake   *handshake.Server                // 握手配置，用于为每个连接协商会话密钥
+	serializer  encoding.Serializer              // 事件在连接上的序列化格式
+	binary      bool                             // 连接是否使用字节数组格式
+	security    connection.SecurityHandle        // 安全事件处理器，用于处理被编解码器拒绝的事件
+	broker      broker.Broker                    // 消息代理，用于向其他节点上的连接转发消息
+	generator   IDGenerator                      // 连接 ID 生成器
+	auth        Authenticator                    // 身份校验函数，为空时拒绝所有客户端
+	identity    bool                             // 是否等待客户端的身份凭证
+	conflict    ConflictPolicy                   // 连接 ID 已经在线时的处理方式
+	requests    map[uint64]chan broker.Message   // 等待其他节点返回客户端响应的请求
+	seq         uint64                           // 发往其他节点的请求序号
+	rlock       sync.Mutex                       // 保护 requests 和 seq
 }
 
 // NewWorker 方法用于创建一个新的 Worker 实例。
@@ -40,8 +75,9 @@ func NewWorker(opts ...Options) *Worker {
 	// 初始化 Worker 实例
 	w := &Worker{
 		connections: make(map[int64]*connection.Connection),
+		requests:    make(map[uint64]chan broker.Message),
 		cbuffer:     make(chan *connection.Connection, 100),
-		dbuffer:     make(chan *connection.Connection, 100),
+		dbuffer:     make(chan connection.Peer, 100),
 	}
 
 	// 启动初始化函数
@@ -64,6 +100,7 @@ func (w *Worker) makeOption(opts ...Options) {
 		WithCache(nil),
 		WithCodec(nil),
 		WithContext(context.Background()),
+		WithIDGenerator(nil),
 	}
 
 	// 将传入的选项添加到默认选项中
@@ -85,6 +122,16 @@ func (w *Worker) init(opts ...Options) {
 	// 调用 makeOption 方法设置选项
 	w.makeOption(opts...)
 
+	// 在处理连接前清理本节点上次运行遗留的在线状态，并取得在线状态的租约
+	if lease, ok := w.cache.(cache.Lease); ok {
+		if err := lease.Reset(w.id); err != nil {
+			fmt.Println("cache reset error", err)
+		}
+
+		w.renew(lease)
+		go w.onLease(lease)
+	}
+
 	// 监听服务注册事件处理函数
 	go w.onRegister()
 
@@ -93,19 +140,208 @@ func (w *Worker) init(opts ...Options) {
 
 	// 启动断开连接事件处理函数
 	go w.onDisconnect()
+
+	// 订阅本节点的通道，接收其他节点转发的消息
+	if w.broker != nil {
+		go w.onBroker()
+	}
 }
 
+// onRegister 方法用于注册本节点并定期续约，直到上下文被取消后注销本节点。
+//
+// 参数：无
+//
+// 返回值：无
 func (w *Worker) onRegister() {
-	// w.registry.Register()
+	if w.registry == nil {
+		return
+	}
+
+	err := registry.Keepalive(w.ctx, w.registry, w.lease, func() registry.Node {
+		node := w.node
+		node.ID = w.id
+		node.Load = w.Count()
+		return node
+	})
+	if err != nil {
+		fmt.Println("registry deregister error", err)
+	}
+}
+
+// onLease 方法用于定期续约在线状态的租约，直到上下文被取消后释放租约。
+// 节点宕机后租约过期，其他节点续约时会清理该节点上的连接。
+//
+// 参数：
+// lease cache.Lease: 支持节点租约的缓存。
+//
+// 返回值：无
+func (w *Worker) onLease(lease cache.Lease) {
+	ticker := time.NewTicker(w.leaseTTL() / 3)
+	defer ticker.Stop()
 
-	// 循环处理断开连接事件
 	for {
 		select {
 		case <-w.ctx.Done():
-			w.registry.Deregister()
+			if err := lease.Reset(w.id); err != nil {
+				fmt.Println("cache reset error", err)
+			}
+			return
+		case <-ticker.C:
+			w.renew(lease)
+		}
+	}
+}
+
+// renew 方法用于续约在线状态的租约，租约在续约前已经过期时，本节点的连接可能已被其他节点清理，需要重新上线。
+//
+// 参数：
+// lease cache.Lease: 支持节点租约的缓存。
+//
+// 返回值：无
+func (w *Worker) renew(lease cache.Lease) {
+	alive, err := lease.Renew(w.id, w.leaseTTL())
+	if err != nil {
+		fmt.Println("cache renew error", err)
+		return
+	}
+
+	if alive {
+		return
+	}
+
+	// 在锁内复制连接列表，访问缓存前释放锁，避免缓存的网络延迟阻塞连接的加入和移除
+	w.lock.RLock()
+	conns := make([]*connection.Connection, 0, len(w.connections))
+	for _, conn := range w.connections {
+		conns = append(conns, conn)
+	}
+	w.lock.RUnlock()
+
+	for _, conn := range conns {
+		if err := w.cache.Online(conn); err != nil {
+			fmt.Println("cache online error", err)
+			continue
+		}
+
+		// 重新上线期间连接已经断开时，断开时的离线可能先于上线完成，需要再次离线
+		if w.Local(conn.ID()) == nil {
+			if err := w.cache.Offline(conn); err != nil {
+				fmt.Println("cache offline error", err)
+			}
+		}
+	}
+}
+
+// leaseTTL 方法用于获取租约的有效期，未设置时使用 registry.DefaultTTL。
+func (w *Worker) leaseTTL() time.Duration {
+	if w.lease <= 0 {
+		return registry.DefaultTTL
+	}
+
+	return w.lease
+}
+
+// onBroker 方法用于订阅本节点的通道，订阅失败时每秒重试一次，直到上下文被取消。
+//
+// 参数：无
+//
+// 返回值：无
+func (w *Worker) onBroker() {
+	for {
+		err := w.broker.Subscribe(w.ctx, w.id, w.deliver)
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-time.After(time.Second):
+			fmt.Println("broker subscribe error", err)
+		}
+	}
+}
+
+// deliver 方法用于将其他节点转发的消息发送给本节点上的连接。
+// 连接的写缓冲区已满时丢弃消息并计入指标，读取缓慢的客户端不会阻塞订阅循环。
+//
+// 参数：
+// msg broker.Message: 其他节点转发的消息。
+//
+// 返回值：无
+func (w *Worker) deliver(msg broker.Message) {
+	if msg.Kick {
+		reason, _ := msg.Data.(string)
+		if err := w.Kick(msg.To, reason); err != nil {
+			fmt.Println("broker kick error", err, msg.To)
+		}
+
+		return
+	}
+
+	// 响应消息的 To 为 0,需要先于广播处理
+	if msg.Reply != 0 {
+		w.resolve(msg)
+		return
+	}
+
+	if msg.Request != 0 {
+		go w.serve(msg)
+		return
+	}
+
+	if msg.To == 0 {
+		if len(msg.Targets) > 0 {
+			w.MulticastLocal(msg.Targets, msg.Topic, msg.Data)
 			return
 		}
+
+		w.BroadcastLocal(msg.Room, msg.Topic, msg.Data)
+		return
+	}
+
+	conn := w.Local(msg.To)
+	if conn == nil {
+		fmt.Println("broker deliver error", ErrNotFound, msg.To)
+		metrics.Dropped.WithLabelValues("not_found").Inc()
+		return
+	}
+
+	if err := conn.Send(msg.Topic, msg.Data); err != nil {
+		fmt.Println("broker deliver error", err, msg.To)
+	}
+}
+
+// sendRemote 方法用于将事件转发到连接所在的节点。
+//
+// 参数：
+// info connection.Info: 位于其他节点的连接信息。
+// topic string: 事件主题。
+// data any: 事件数据。
+//
+// 返回值：
+// error: 没有配置消息代理或发布失败时返回错误信息。
+func (w *Worker) sendRemote(info connection.Info, topic string, data any) error {
+	if w.broker == nil {
+		return ErrNoBroker
 	}
+
+	return w.broker.Publish(w.ctx, info.WorkID, broker.Message{To: info.ID, Topic: topic, Data: data})
+}
+
+// closeRemote 方法用于通知连接所在的节点关闭连接。
+//
+// 参数：
+// info connection.Info: 位于其他节点的连接信息。
+//
+// 返回值：
+// error: 没有配置消息代理或发布失败时返回错误信息。
+func (w *Worker) closeRemote(info connection.Info) error {
+	return w.kick(connection.NewRemote(info.ID, info.WorkID, nil), "")
+}
+
+// remote 方法用于创建位于其他节点的连接对象，Send、Request 和 Close 通过消息代理转发到连接所在的节点。
+func (w *Worker) remote(info connection.Info) *connection.Remote {
+	return connection.NewRemote(info.ID, info.WorkID, w.sendRemote,
+		connection.WithRemoteRequest(w.requestTo),
+		connection.WithRemoteClose(w.closeRemote),
+	)
 }
 
 // onConnection 方法用于处理连接事件。
@@ -123,9 +359,14 @@ func (w *Worker) onConnection() {
 		case conn := <-w.cbuffer:
 			// 从缓冲区中获取连接对象
 			w.lock.Lock()
-			id := conn.ID
-			// 计数器加一
-			w.count += 1
+			id := conn.ID()
+			// 计数器加一，相同 ID 的连接替换已经在线的连接时不重复计数
+			if _, ok := w.connections[id]; !ok {
+				w.count += 1
+				metrics.ConnectionsCurrent.Inc()
+			}
+
+			metrics.ConnectionsTotal.Inc()
 			// 将连接对象添加到连接列表中
 			w.connections[id] = conn
 			// 将连接对象设置为在线状态
@@ -133,7 +374,9 @@ func (w *Worker) onConnection() {
 				// 如果设置在线状态失败，则输出错误信息
 				fmt.Println("cache online error", err)
 			}
-			w.handle(conn, event.Event{Topic: event.TopicByLogin})
+			if w.handle != nil {
+				w.handle(conn, event.Event{Topic: event.TopicByLogin})
+			}
 			w.lock.Unlock()
 		}
 	}
@@ -153,13 +396,16 @@ func (w *Worker) onDisconnect() {
 			for _, conn := range w.connections {
 				w.cache.Offline(conn)
 			}
+
+			metrics.ConnectionsCurrent.Sub(float64(len(w.connections)))
 			return
 		case conn := <-w.dbuffer:
-			id := conn.ID
-			// 如果连接对象存在于连接列表中，则将其设置为离线状态
-			if _, ok := w.connections[id]; ok {
+			id := conn.ID()
+			// 如果连接对象存在于连接列表中，则将其设置为离线状态，连接已经被相同 ID 的新连接替换时不做处理
+			if cur, ok := w.connections[id]; ok && connection.Peer(cur) == conn {
 				w.lock.Lock()
 				w.count -= 1
+				metrics.ConnectionsCurrent.Dec()
 				delete(w.connections, id)
 				if err := w.cache.Offline(conn); err != nil {
 					fmt.Println("cache offline error", err)
@@ -179,39 +425,128 @@ func (w *Worker) onDisconnect() {
 // 返回值：
 // *connection.Connection:一个 connection.Connection 类型的连接对象。
 func (w *Worker) Connection(conn net.Conn) *connection.Connection {
+	metrics.Accepts.Inc()
+
 	// 创建一个新的连接对象，并设置其属性
-	c := connection.NewConnection(
-		connection.WithID(0),
+	opts := []connection.Options{
 		connection.WithConn(conn),
 		connection.WithWorkID(w.id),
 		connection.WithCodec(w.codec),
 		connection.WithContext(w.ctx),
 		connection.WithHandle(w._handle),
-	)
+		connection.WithServerHandshake(w.handshake),
+		connection.WithSerializer(w.serializer),
+		connection.WithBinary(w.binary),
+		connection.WithSecurity(w.security),
+		connection.WithReady(w.ready),
+	}
 
+	// 启用身份校验时，连接 ID 在收到客户端的身份凭证后生成
+	if w.identity {
+		opts = append(opts, connection.WithIdentify(w.identify))
+	} else {
+		opts = append(opts, connection.WithID(w.nextID()))
+	}
+
+	return connection.NewConnection(opts...)
+}
+
+// ready 方法在连接完成密钥交换和身份校验后调用，将连接添加到工作器中。
+//
+// 参数：
+// c *connection.Connection: 初始化完成的连接对象。
+func (w *Worker) ready(c *connection.Connection) {
 	// 当连接关闭时，将连接对象发送到工作器的缓冲区中
-	c.On(event.TopicByClose, func(_ *connection.Connection, e event.Event) {
+	c.On(event.TopicByClose, func(_ connection.Peer, e event.Event) {
 		w.dbuffer <- c
 	})
 
 	// 将连接对象发送到工作器的缓冲区中
 	w.cbuffer <- c
-	return c
+}
+
+// nextID 方法使用连接 ID 生成器生成不依赖客户端身份的连接 ID,生成失败时使用雪花算法。
+func (w *Worker) nextID() int64 {
+	if w.generator == nil {
+		return snowflake.Next()
+	}
+
+	id, err := w.generator.Generate("")
+	if err != nil || id < 1 {
+		fmt.Println("generate connection id error", err)
+		return snowflake.Next()
+	}
+
+	return id
+}
+
+// identify 方法校验客户端的身份凭证，根据身份生成连接 ID,并按照冲突策略处理已经在线的相同 ID 的连接。
+//
+// 参数：
+// c *connection.Connection: 连接对象。
+// creds connection.Credentials: 客户端的身份凭证。
+//
+// 返回值：
+// int64: 连接 ID。
+// error: 没有身份校验函数、身份校验失败、生成连接 ID 失败或冲突策略为 ConflictReject 且 ID 已经在线时返回错误信息。
+func (w *Worker) identify(c *connection.Connection, creds connection.Credentials) (int64, error) {
+	// 没有身份校验函数时不能信任客户端声明的身份，否则任何客户端都可以冒充其他用户
+	if w.auth == nil {
+		return 0, ErrNoAuthenticator
+	}
+
+	identity, err := w.auth(creds)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := w.generator.Generate(identity)
+	if err != nil {
+		return 0, err
+	}
+
+	if existing := w.Find(id); existing != nil {
+		if w.conflict == ConflictReject {
+			return 0, ErrConflict
+		}
+
+		// 关闭已经在线的连接，位于其他节点时通过消息代理通知所在节点
+		if err := w.kick(existing, "replaced"); err != nil {
+			fmt.Println("kick conflict connection error", err, id)
+		}
+	}
+
+	c.Attributes().Set("identity", identity)
+	return id, nil
+}
+
+// kick 方法关闭本节点或其他节点上的连接，reason 在关闭前发送给客户端。
+func (w *Worker) kick(conn connection.Peer, reason string) error {
+	info := conn.Info()
+	if !info.Remote && info.WorkID == w.id {
+		return w.Kick(info.ID, reason)
+	}
+
+	if w.broker == nil {
+		return ErrNoBroker
+	}
+
+	return w.broker.Publish(w.ctx, info.WorkID, broker.Message{To: info.ID, Kick: true, Data: reason})
 }
 
 // _handle 方法用于处理连接事件，并根据事件类型执行相应的操作。
 //
 // 参数：
-// conn *connection.Connection: 一个指向 connection.Connection 类型的指针，表示要处理的连接。
+// conn connection.Peer: 表示要处理的连接。
 // e event.Event: 一个 event.Event 类型的变量，表示要处理的事件。
 //
 // 返回值：
 // 无返回值。
-func (w *Worker) _handle(conn *connection.Connection, e event.Event) {
+func (w *Worker) _handle(conn connection.Peer, e event.Event) {
 	// 如果事件主题是关闭连接，将连接添加到缓冲区中，并打印关闭信息。
 	if e.Topic == event.TopicByClose {
 		w.dbuffer <- conn
-		fmt.Println("connection close", conn.ID)
+		fmt.Println("connection close", conn.ID())
 	}
 
 	// 如果存在 handle 方法，则调用该方法处理事件。
@@ -223,8 +558,8 @@ func (w *Worker) _handle(conn *connection.Connection, e event.Event) {
 // Disconnect 方法用于断开与指定连接的连接。
 //
 // 参数：
-// conn *connection.Connection: 一个指向 connection.Connection 类型的指针，表示要断开的连接。
-func (w *Worker) Disconnect(conn *connection.Connection) {
+// conn connection.Peer: 表示要断开的连接。
+func (w *Worker) Disconnect(conn connection.Peer) {
 	w.dbuffer <- conn
 }
 
@@ -241,9 +576,20 @@ func (w *Worker) ID() int64 {
 // 返回值：
 // int64: Worker 实例的任务数量。
 func (w *Worker) Count() int64 {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
 	return w.count
 }
 
+// Cache 方法用于获取 Worker 实例保存在线连接的缓存。
+//
+// 返回值：
+// cache.ICache: 缓存，可以通过 cache.Lister 等可选接口查询所有节点上的在线连接。
+func (w *Worker) Cache() cache.ICache {
+	return w.cache
+}
+
 // Connections 方法用于获取 Worker 实例的所有连接。
 //
 // 返回值：
@@ -258,23 +604,361 @@ func (w *Worker) Connections() map[int64]*connection.Connection {
 // id int64: 要查找的连接的 ID。
 //
 // 返回值：
-// *connection.Connection: 如果找到了指定 ID 的连接，则返回对应的 connection.Connection 类型指针；否则返回 nil。
-func (w *Worker) Find(id int64) *connection.Connection {
+// connection.Peer: 如果找到了指定 ID 的连接，则返回对应的连接；否则返回 nil。
+// 连接位于其他节点时返回 *connection.Remote,调用 Send、Request 和 Close 会通过消息代理转发到连接所在的节点。
+func (w *Worker) Find(id int64) connection.Peer {
+	// 查询远程缓存前释放锁，避免缓存的网络延迟阻塞连接的加入和移除
+	if conn := w.Local(id); conn != nil {
+		return conn
+	}
+
+	// 缓存中记录在本节点但本节点没有该连接，说明缓存已过期
+	if conn := w.cache.Find(id); conn != nil {
+		if info := conn.Info(); info.WorkID != w.id {
+			return w.remote(info)
+		}
+	}
+
+	return nil
+}
+
+// Local 方法用于查找本节点上指定 ID 的连接，不查询缓存。
+//
+// 参数：
+// id int64: 要查找的连接的 ID。
+//
+// 返回值：
+// *connection.Connection: 连接在本节点上时返回对应的连接；否则返回 nil。
+func (w *Worker) Local(id int64) *connection.Connection {
 	w.lock.RLock()
 	defer w.lock.RUnlock()
 
-	if conn, ok := w.connections[id]; ok {
-		return conn
+	return w.connections[id]
+}
+
+// Broadcast 方法用于向本节点上的所有连接发送事件。
+//
+// 参数：
+// topic string: 事件主题。
+// data any: 事件数据。
+//
+// 返回值：
+// int: 发送成功的连接数。
+func (w *Worker) Broadcast(topic string, data any) int {
+	return w.BroadcastLocal("", topic, data)
+}
+
+// BroadcastLocal 方法用于向本节点上的房间成员发送事件。
+//
+// 参数：
+// room string: 房间名称，为空时发送给本节点上的所有连接。
+// topic string: 事件主题。
+// data any: 事件数据。
+//
+// 返回值：
+// int: 发送成功的连接数。
+func (w *Worker) BroadcastLocal(room string, topic string, data any) int {
+	var conns []*connection.Connection
+	if room == "" {
+		w.lock.RLock()
+		conns = make([]*connection.Connection, 0, len(w.connections))
+		for _, conn := range w.connections {
+			conns = append(conns, conn)
+		}
+		w.lock.RUnlock()
+	} else {
+		members, err := w.Members(room)
+		if err != nil {
+			fmt.Println("broadcast error", err, room)
+			return 0
+		}
+
+		for _, info := range members {
+			if info.WorkID != w.id {
+				continue
+			}
+
+			if conn := w.Local(info.ID); conn != nil {
+				conns = append(conns, conn)
+			}
+		}
 	}
 
-	if conn := w.cache.Find(id); conn != nil {
-		return conn
+	count := 0
+	for _, conn := range conns {
+		if err := conn.Send(topic, data); err != nil {
+			fmt.Println("broadcast error", err, conn.ID())
+			continue
+		}
+
+		count++
+	}
+
+	return count
+}
+
+// BroadcastRoom 方法用于向所有节点上的房间成员发送事件，每个有成员的节点只会收到一次消息，
+// 由节点发送给其本地的成员。
+//
+// 参数：
+// room string: 房间名称，为空时发送给注册中心中所有节点上的连接，没有配置注册中心时只发送给本节点上的连接。
+// topic string: 事件主题。
+// data any: 事件数据。
+//
+// 返回值：
+// error: 查询房间成员失败或向其他节点转发失败时返回错误信息。
+func (w *Worker) BroadcastRoom(room string, topic string, data any) error {
+	nodes, err := w.broadcastNodes(room)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, node := range nodes {
+		if node == w.id {
+			w.BroadcastLocal(room, topic, data)
+			continue
+		}
+
+		if w.broker == nil {
+			errs = append(errs, ErrNoBroker)
+			continue
+		}
+
+		if err := w.broker.Publish(w.ctx, node, broker.Message{Room: room, Topic: topic, Data: data}); err != nil {
+			errs = append(errs, fmt.Errorf("node %d: %w", node, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// broadcastNodes 方法用于返回需要转发广播的节点，房间为空时返回注册中心中的所有节点，否则返回有房间成员的节点。
+func (w *Worker) broadcastNodes(room string) ([]int64, error) {
+	set := make(map[int64]struct{})
+	if room == "" {
+		set[w.id] = struct{}{}
+		if w.registry != nil {
+			nodes, err := w.registry.Discover(w.ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, node := range nodes {
+				set[node.ID] = struct{}{}
+			}
+		}
+	} else {
+		members, err := w.Members(room)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, info := range members {
+			set[info.WorkID] = struct{}{}
+		}
+	}
+
+	nodes := make([]int64, 0, len(set))
+	for node := range set {
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// Join 方法用于将连接加入房间，连接可以位于其他节点。
+//
+// 参数：
+// room string: 房间名称。
+// id int64: 连接的 ID。
+//
+// 返回值：
+// error: 缓存不支持房间时返回 ErrNoRooms,连接不在线时返回 ErrNotFound。
+func (w *Worker) Join(room string, id int64) error {
+	rooms, ok := w.cache.(cache.Rooms)
+	if !ok {
+		return ErrNoRooms
+	}
+
+	conn := w.Find(id)
+	if conn == nil {
+		return ErrNotFound
+	}
+
+	return rooms.Join(room, conn)
+}
+
+// Leave 方法用于将连接移出房间。
+//
+// 参数：
+// room string: 房间名称。
+// id int64: 连接的 ID。
+//
+// 返回值：
+// error: 缓存不支持房间时返回 ErrNoRooms。
+func (w *Worker) Leave(room string, id int64) error {
+	rooms, ok := w.cache.(cache.Rooms)
+	if !ok {
+		return ErrNoRooms
+	}
+
+	return rooms.Leave(room, connection.NewRemote(id, 0, nil))
+}
+
+// Members 方法用于查询所有节点上的房间成员。
+//
+// 参数：
+// room string: 房间名称。
+//
+// 返回值：
+// []connection.Info: 房间成员列表。
+// error: 缓存不支持房间时返回 ErrNoRooms。
+func (w *Worker) Members(room string) ([]connection.Info, error) {
+	rooms, ok := w.cache.(cache.Rooms)
+	if !ok {
+		return nil, ErrNoRooms
+	}
+
+	return rooms.Members(room)
+}
+
+// Rooms 方法用于查询连接加入的所有房间。
+//
+// 参数：
+// id int64: 连接的 ID。
+//
+// 返回值：
+// []string: 房间名称列表。
+// error: 缓存不支持房间时返回 ErrNoRooms。
+func (w *Worker) Rooms(id int64) ([]string, error) {
+	rooms, ok := w.cache.(cache.Rooms)
+	if !ok {
+		return nil, ErrNoRooms
+	}
+
+	return rooms.Joined(id)
+}
+
+// Kick 方法用于关闭本节点上指定 ID 的连接。
+//
+// 参数：
+// id int64: 连接的 ID。
+// reason string: 踢出的原因，不为空时先以 event.TopicByKick 事件发送给客户端再关闭连接。
+//
+// 返回值：
+// error: 连接不在本节点上时返回 ErrNotFound。
+func (w *Worker) Kick(id int64, reason string) error {
+	conn := w.Local(id)
+	if conn == nil {
+		return ErrNotFound
+	}
+
+	if reason == "" {
+		return conn.Close()
+	}
+
+	return conn.Kick(reason)
+}
+
+// Evict 方法用于关闭本节点或其他节点上指定 ID 的连接，连接位于其他节点时通过消息代理通知所在节点。
+//
+// 参数：
+// id int64: 连接的 ID。
+// reason string: 踢出的原因，不为空时在关闭前发送给客户端。
+//
+// 返回值：
+// error: 连接不在线时返回 ErrNotFound,连接位于其他节点但没有配置消息代理时返回 ErrNoBroker。
+func (w *Worker) Evict(id int64, reason string) error {
+	conn := w.Find(id)
+	if conn == nil {
+		return ErrNotFound
+	}
+
+	return w.kick(conn, reason)
+}
+
+// Lookup 方法用于根据客户端的身份查找连接。
+// 启用身份凭证时先使用连接 ID 生成器计算身份对应的连接 ID,找不到时查找本节点上身份属性相同的连接。
+//
+// 参数：
+// identity string: 客户端通过校验的身份。
+//
+// 返回值：
+// connection.Peer: 找不到连接时返回 nil。
+func (w *Worker) Lookup(identity string) connection.Peer {
+	if w.identity {
+		if id, err := w.generator.Generate(identity); err == nil {
+			if conn := w.Find(id); conn != nil {
+				return conn
+			}
+		}
+	}
+
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	for _, conn := range w.connections {
+		if value, ok := conn.Attributes().Get("identity"); ok && value == identity {
+			return conn
+		}
 	}
 
-	fmt.Println("connection not found", id)
 	return nil
 }
 
+// Subscribe 方法用于订阅所有节点上连接的在线状态变化。
+//
+// 参数：
+// ctx context.Context: 上下文，被取消时关闭返回的通道。
+//
+// 返回值：
+// <-chan cache.Presence: 在线状态的通道。
+// error: 缓存不支持在线状态时返回 ErrNoPresence。
+func (w *Worker) Subscribe(ctx context.Context) (<-chan cache.Presence, error) {
+	notifier, ok := w.cache.(cache.Notifier)
+	if !ok {
+		return nil, ErrNoPresence
+	}
+
+	return notifier.Subscribe(ctx)
+}
+
+// Status 方法用于查询连接的在线状态，连接离线后返回最后一次在线的时间。
+//
+// 参数：
+// id int64: 连接的 ID。
+//
+// 返回值：
+// cache.Presence: 连接的在线状态。
+// error: 缓存不支持在线状态时返回 ErrNoPresence。
+func (w *Worker) Status(id int64) (cache.Presence, error) {
+	notifier, ok := w.cache.(cache.Notifier)
+	if !ok {
+		return cache.Presence{}, ErrNoPresence
+	}
+
+	return notifier.Status(id)
+}
+
+// Send 方法用于向指定 ID 的连接发送事件，连接位于本节点或其他节点时使用相同的方式调用。
+//
+// 参数：
+// id int64: 连接的 ID。
+// topic string: 事件主题。
+// data any: 事件数据。
+//
+// 返回值：
+// error: 连接不在线时返回 ErrNotFound,连接位于其他节点但没有配置消息代理时返回 ErrNoBroker。
+func (w *Worker) Send(id int64, topic string, data any) error {
+	conn := w.Find(id)
+	if conn == nil {
+		return ErrNotFound
+	}
+
+	return conn.Send(topic, data)
+}
+
 // Close 方法用于关闭 Worker 实例的所有连接。
 func (w *Worker) Close() {
 	w.cancel()
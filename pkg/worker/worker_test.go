@@ -19,13 +19,17 @@ import (
 
 func TestWork(t *testing.T) {
 	addr := "127.0.0.1:8080"
-	icodec := codec.New("AESECB", "1234567890123456")
+	icodec, err := codec.New("AESECB", "1234567890123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	work := NewWorker(
 		WithCache(cache.NewRedis(redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"}))),
 		WithContext(ctx),
 		WithCodec(icodec),
-		WithHandle(func(c *connection.Connection, e event.Event) {
+		WithHandle(func(c connection.Peer, e event.Event) {
 			fmt.Println("on handle", "topic", e.Topic, "value", e.Data)
 		}),
 	)
@@ -68,8 +72,8 @@ func TestWork(t *testing.T) {
 
 				// 启动一个 goroutine 处理连接
 				c := work.Connection(conn)
-				c.On("msg", func(c *connection.Connection, e event.Event) {
-					fmt.Println("on msg", e.Data, "conn", c.ID)
+				c.On("msg", func(c connection.Peer, e event.Event) {
+					fmt.Println("on msg", e.Data, "conn", c.ID())
 					c.Send("rev", e.Data)
 				})
 			}
@@ -91,12 +95,12 @@ func TestWork(t *testing.T) {
 			return
 		}
 
-		c.Subscription("rev", func(c *connection.Connection, e event.Event) {
+		c.Subscription("rev", func(c connection.Peer, e event.Event) {
 			fmt.Println("on rev", e.Data)
 			fmt.Println("count", work.Count())
 			// fmt.Println("work", work)
 			// fmt.Println("connID", c.ID)
-			fmt.Println("conn", work.Find(c.ID))
+			fmt.Println("conn", work.Find(c.ID()))
 			fmt.Println()
 		})
 
@@ -3,15 +3,16 @@ package socket
 import (
 	"github.com/cotton-go/socket/pkg/app"
 	"github.com/cotton-go/socket/pkg/config"
-	"github.com/cotton-go/socket/pkg/log"
 	"github.com/cotton-go/socket/pkg/worker"
 )
 
-func NewServer(conf config.Config, opts ...worker.Options) *app.App {
-	logger := log.NewLog(conf.Logger)
-	return app.NewApp(app.WithServer(
-		InitTCPServer(conf.TCP, logger, opts...),
-		InitHTTPServer(conf.HTTP, logger),
-		// InitGRPCServer(conf.GRPC, logger),
-	))
+// NewServer 根据配置创建 Worker 和服务器，返回管理所有服务器的应用程序。
+// 需要获取 Worker、注册自定义路由或添加服务器时使用 New。配置无效时返回错误信息。
+func NewServer(conf config.Config, opts ...worker.Options) (*app.App, error) {
+	b, err := New(conf, WithWorkerOptions(opts...))
+	if err != nil {
+		return nil, err
+	}
+
+	return b.App(), nil
 }
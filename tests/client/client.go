@@ -12,7 +12,9 @@ import (
 	"github.com/cotton-go/socket/pkg/codec"
 	"github.com/cotton-go/socket/pkg/config"
 	"github.com/cotton-go/socket/pkg/connection"
+	"github.com/cotton-go/socket/pkg/encoding"
 	"github.com/cotton-go/socket/pkg/event"
+	"github.com/cotton-go/socket/pkg/handshake"
 )
 
 func main() {
@@ -37,14 +39,43 @@ func main() {
 	}
 	ctx, cannel := context.WithCancel(context.Background())
 	addrress := fmt.Sprintf("%v:%v", conf.TCP.Host, conf.TCP.Port)
-	ci, err := client.New(
-		addrress,
+	icodec, err := codec.New(conf.TCP.Codec, conf.TCP.Secret)
+	if err != nil {
+		panic(err)
+	}
+
+	opts := []connection.Options{
 		connection.WithHandle(handler),
-		connection.WithCodec(codec.NewDESECB(conf.TCP.Secret)),
-		connection.WithClose(func(c *connection.Connection, e event.Event) {
+		connection.WithCodec(icodec),
+		connection.WithClose(func(c connection.Peer, e event.Event) {
 			cannel()
 		}),
-	)
+	}
+
+	if conf.TCP.Serializer != "" {
+		serializer, err := encoding.Get(conf.TCP.Serializer)
+		if err != nil {
+			panic(err)
+		}
+
+		opts = append(opts, connection.WithSerializer(serializer))
+	}
+
+	if conf.TCP.Handshake != nil {
+		shakeOpts, err := conf.TCP.Handshake.Options(nil)
+		if err != nil {
+			panic(err)
+		}
+
+		shake, err := handshake.NewClient(conf.TCP.Handshake.PublicKey, shakeOpts...)
+		if err != nil {
+			panic(err)
+		}
+
+		opts = append(opts, connection.WithClientHandshake(shake))
+	}
+
+	ci, err := client.New(addrress, opts...)
 
 	if err != nil {
 		panic(err)
@@ -57,6 +88,6 @@ func main() {
 	}
 }
 
-func handler(c *connection.Connection, e event.Event) {
+func handler(c connection.Peer, e event.Event) {
 	fmt.Println("print msg", "topic", e.Topic, "data", e.Data)
 }
@@ -37,15 +37,21 @@ func main() {
 		return
 	}
 
-	server := socket.NewServer(conf, worker.WithHandle(handler))
+	server, err := socket.NewServer(conf, worker.WithHandle(handler))
+	if err != nil {
+		fmt.Println("Error creating server:", err)
+		return
+	}
+
 	server.Run(context.Background())
 }
 
-func handler(c *connection.Connection, e event.Event) {
-	fmt.Println("connection", c.ID, "workID", c.WorkID, "topic", e.Topic, "data", e.Data)
+func handler(c connection.Peer, e event.Event) {
+	info := c.Info()
+	fmt.Println("connection", info.ID, "workID", info.WorkID, "topic", e.Topic, "data", e.Data)
 	if e.Topic == event.TopicByLogin {
 		c.Send("msg1", 1)
 		c.Send("msg", map[string]string{"time": time.Now().String()})
-		c.Send("msg", c)
+		c.Send("msg", info)
 	}
 }